@@ -0,0 +1,75 @@
+// Command jk is a small text editor for the terminal.
+//
+// Usage:
+//
+//	jk [--listen socket] [--log level] [--secret] [filename]
+//	jk --remote [--server socket] filename
+//	jk --headless --script script filename
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hibiken/mini/pkg/editor"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--headless" {
+		headless(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "--remote" {
+		remote(os.Args[2:])
+		return
+	}
+
+	restart, err := editor.Run(os.Args[1:], editor.Options{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jk: %s\n", err)
+	}
+
+	// The exit status tells the wrapper script to start the rebuilt editor
+	if restart {
+		os.Exit(2)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// remote opens a file in an editor started with --listen.
+func remote(args []string) {
+	flags := flag.NewFlagSet("jk --remote", flag.ExitOnError)
+	server := flags.String("server", editor.DefaultSocket(), "socket the editor is listening on")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: jk --remote [--server socket] filename")
+		os.Exit(1)
+	}
+
+	if err := editor.Remote(*server, flags.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "jk: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// headless edits a file with a script instead of a terminal.
+func headless(args []string) {
+	flags := flag.NewFlagSet("jk --headless", flag.ExitOnError)
+	script := flags.String("script", "", "file with the commands and keys to run")
+	flags.Parse(args)
+
+	if *script == "" || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: jk --headless --script script filename")
+		os.Exit(1)
+	}
+
+	if err := editor.Headless(flags.Arg(0), *script, editor.Options{}); err != nil {
+		fmt.Fprintf(os.Stderr, "jk: %s\n", err)
+		os.Exit(1)
+	}
+}
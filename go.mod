@@ -5,10 +5,8 @@ go 1.18
 require (
 	github.com/mattn/go-runewidth v0.0.10
 	github.com/pkg/errors v0.9.1
+	github.com/rivo/uniseg v0.1.0
+	github.com/yuin/gopher-lua v1.1.1
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1
 	golang.org/x/term v0.0.0-20210927222741-03fcf44c2211
 )
-
-require (
-	github.com/rivo/uniseg v0.1.0 // indirect
-	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
-)
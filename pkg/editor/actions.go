@@ -0,0 +1,484 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// action is a named operation that keys can be bound to.
+type Action struct {
+	Description string
+	Run         func(e SDK) error
+}
+
+// builtinActions are the actions every editor starts with.
+var builtinActions = map[string]Action{
+	"move-up":    {"move the cursor up", moveUp},
+	"move-down":  {"move the cursor down", moveDown},
+	"move-left":  {"move the cursor left", moveLeft},
+	"move-right": {"move the cursor right", moveRight},
+
+	"display-line-up":   {"move up a screen line when wrapping", func(e SDK) error { e.moveDisplayLine(-1); return nil }},
+	"display-line-down": {"move down a screen line when wrapping", func(e SDK) error { e.moveDisplayLine(1); return nil }},
+
+	"screen-top":     {"move to the top of the screen", func(e SDK) error { e.SetY(e.ScreenTop()); return nil }},
+	"screen-bottom":  {"move to the bottom of the screen", func(e SDK) error { e.SetY(e.ScreenBottom()); return nil }},
+	"half-page-up":   {"scroll up half a screen", halfPageUp},
+	"half-page-down": {"scroll down half a screen", halfPageDown},
+
+	"line-start":           {"move to the start of the line", func(e SDK) error { e.SetX(0); return nil }},
+	"line-end":             {"move to the end of the line", func(e SDK) error { e.SetX(len(e.Row(e.Y()))); return nil }},
+	"first-line":           {"move to the first line, or the line of the count", goToLine(0)},
+	"last-line":            {"move to the last line, or the line of the count", goToLine(-1)},
+	"first-non-blank":      {"move to the first non-blank character of the line", firstNonBlank},
+	"first-non-blank-line": {"move to the first non-blank character, count-1 lines down", firstNonBlankLine},
+	"word":                 {"move to the next word", func(e SDK) error { e.SetX(e.Word()); return nil }},
+	"back-word":            {"move to the previous word", func(e SDK) error { e.SetX(e.BackWord()); return nil }},
+
+	"next-paragraph": {"move to the blank line after the paragraph", nextParagraph},
+	"prev-paragraph": {"move to the blank line before the paragraph", prevParagraph},
+	"next-sentence":  {"move to the start of the next sentence", nextSentence},
+	"prev-sentence":  {"move to the start of the sentence", prevSentence},
+
+	"match-bracket": {"move to the matching bracket", jumpToMatchingBracket},
+	"next-heading":  {"move to the next markdown heading", nextHeading(1)},
+	"prev-heading":  {"move to the previous markdown heading", nextHeading(-1)},
+	"outline":       {"pick a markdown heading to jump to", outline},
+	"next-hunk":     {"move to the next change from the git index", moveToHunk(1)},
+	"prev-hunk":     {"move to the previous change from the git index", moveToHunk(-1)},
+	"stage-hunk":    {"add the change under the cursor to the git index", func(e SDK) error { return e.stageHunk(e.Y()) }},
+	"revert-hunk":   {"undo the change under the cursor", func(e SDK) error { return e.revertHunk(e.Y()) }},
+	"next-change":   {"move to the next change since the last save", moveToChange(1)},
+	"prev-change":   {"move to the previous change since the last save", moveToChange(-1)},
+	"revert-line":   {"put the line back the way it is in the file", func(e SDK) error { return e.revertLine(e.Y()) }},
+
+	"next-conflict": {"move to the next merge conflict", moveToConflict(1)},
+	"prev-conflict": {"move to the previous merge conflict", moveToConflict(-1)},
+	"take-ours":     {"resolve the conflict with our side", func(e SDK) error { return resolveConflict(e, "ours") }},
+	"take-theirs":   {"resolve the conflict with their side", func(e SDK) error { return resolveConflict(e, "theirs") }},
+	"take-both":     {"resolve the conflict with both sides", func(e SDK) error { return resolveConflict(e, "both") }},
+	"next-cell":     {"move to the next cell of a CSV or TSV file, or the next word", moveToCell(1)},
+	"prev-cell":     {"move to the previous cell of a CSV or TSV file, or the previous word", moveToCell(-1)},
+
+	"insert-mode":       {"enter insert mode", func(e SDK) error { e.SetMode(InsertMode); return nil }},
+	"replace-mode":      {"enter replace mode, typing over the text", func(e SDK) error { e.SetMode(ReplaceMode); return nil }},
+	"replace-char":      {"replace the character under the cursor with the next key", replaceCharUnderCursor},
+	"command-mode":      {"return to command mode", func(e SDK) error { e.SetMode(CommandMode); return nil }},
+	"open-line-below":   {"open a line below and enter insert mode", openLineBelow},
+	"open-line-above":   {"open a line above and enter insert mode", openLineAbove},
+	"append":            {"enter insert mode after the cursor", appendAfterCursor},
+	"append-line-end":   {"enter insert mode at the end of the line", appendAtLineEnd},
+	"insert-line-start": {"enter insert mode at the first non-blank character", insertAtLineStart},
+
+	"split-line":         {"split the line at the cursor", splitLine},
+	"insert-tab":         {"insert a tab, or spaces to the next tabstop with expandtab", insertTab},
+	"dedent-line":        {"shift the line left by shiftwidth", dedentLine},
+	"insert-date":        {"type the date in the dateformat option's format", insertDate},
+	"insert-calc":        {"type the result of an arithmetic expression", insertCalc},
+	"insert-digraph":     {"insert the character of the digraph typed next, e.g. -> for →", insertDigraph},
+	"complete-path":      {"complete the file path before the cursor", completePath},
+	"go-to-file":         {"open the file under the cursor", goToFile(false)},
+	"go-to-file-line":    {"open the file under the cursor at its :line suffix", goToFile(true)},
+	"open-location":      {"open the file at the location on the row of the output buffer", openOutputLocation},
+	"open-url":           {"open the URL under the cursor in the browser", openURLUnderCursor},
+	"delete-char-before": {"delete the character before the cursor", deleteCharBefore},
+	"delete-word-before": {"delete the word before the cursor", deleteWordBefore},
+	"delete-line":        {"delete the line", deleteLine},
+	"clear-line":         {"clear the line", clearLine},
+	"yank-line":          {"copy the line to the kill ring", yankLine},
+	"delete":             {"delete the text of a motion or text object", startOperator("delete")},
+	"change":             {"change the text of a motion or text object", startOperator("change")},
+	"yank":               {"copy the text of a motion or text object to the kill ring", startOperator("yank")},
+	"lowercase":          {"make the text of a motion or text object lowercase", startOperator("lowercase")},
+	"uppercase":          {"make the text of a motion or text object uppercase", startOperator("uppercase")},
+	"togglecase":         {"switch the case of the text of a motion or text object", startOperator("togglecase")},
+	"indent":             {"shift the lines of a motion or text object right", startOperator("indent")},
+	"dedent":             {"shift the lines of a motion or text object left", startOperator("dedent")},
+	"undo":               {"undo the last change", func(e SDK) error { return e.Undo() }},
+	"redo":               {"redo the last undone change", func(e SDK) error { return e.Redo() }},
+	"paste":              {"paste the last yanked or deleted text after the cursor", paste(true)},
+	"paste-before":       {"paste the last yanked or deleted text before the cursor", paste(false)},
+	"paste-history":      {"pick earlier yanked or deleted text to paste", pasteFromHistory},
+	"toggle-comment":     {"comment or uncomment the line", toggleComment},
+
+	"find":             {"search interactively", func(e SDK) error { e.FindInteractive(); return nil }},
+	"find-next":        {"repeat the last search", repeatSearch(false)},
+	"find-prev":        {"repeat the last search in the other direction", repeatSearch(true)},
+	"search-word":      {"search forward for the word under the cursor", searchWord(false)},
+	"search-word-back": {"search backward for the word under the cursor", searchWord(true)},
+
+	"quit":         {"quit, asking first if there are unsaved changes", quit},
+	"save":         {"save the file", save},
+	"open-file":    {"open a file", openFile},
+	"file-info":    {"show file information", func(e SDK) error { e.SetMessage("%s", fileInfo(e)); return nil }},
+	"count":        {"show the number of lines, words and characters", func(e SDK) error { return e.ExecCommand("count") }},
+	"command-line": {"run a command", func(e SDK) error { e.StaticPrompt(":", e.ExecCommand, CommandCompletion(e)); return nil }},
+	"help":         {"show the key bindings and commands", func(e SDK) error { return e.ExecCommand("help") }},
+	"reload":       {"run reloadcmd and restart the editor, keeping unsaved changes", func(e SDK) error { return RestartEditor }},
+}
+
+// RunAction runs the action with the given name.
+func (e *Editor) RunAction(name string) error {
+	action, ok := e.action(name)
+	if !ok {
+		return fmt.Errorf("unknown action: %s", name)
+	}
+
+	return action.Run(e)
+}
+
+// goToLine returns an action moving to the line of the count, or to line y
+// without one. -1 is the last line.
+func goToLine(y int) func(e SDK) error {
+	return func(e SDK) error {
+		switch n := e.Count(); {
+		case n > 0:
+			e.SetY(n - 1)
+			e.WrapCursorY()
+		case y == -1:
+			e.SetY(e.NumRows())
+		default:
+			e.SetY(y)
+		}
+		return nil
+	}
+}
+
+func firstNonBlank(e SDK) error {
+	e.SetX(len(leadingWhitespace(cursorRow(e))))
+	return nil
+}
+
+// firstNonBlankLine moves to the first non-blank character of the line
+// count-1 lines down, or of the current line without a count.
+func firstNonBlankLine(e SDK) error {
+	if n := e.Count(); n > 1 {
+		e.SetY(e.Y() + n - 1)
+		e.WrapCursorY()
+	}
+
+	return firstNonBlank(e)
+}
+
+func halfPageUp(e SDK) error {
+	e.SetY(e.Y() - (e.Rows() / 2))
+	e.CenterCursor()
+	return nil
+}
+
+func halfPageDown(e SDK) error {
+	e.SetY(e.Y() + (e.Rows() / 2))
+	e.CenterCursor()
+	return nil
+}
+
+func openLineBelow(e SDK) error {
+	e.InsertRow(e.Y()+1, []rune(""))
+	e.SetY(e.Y() + 1)
+	e.SetMode(InsertMode)
+	return nil
+}
+
+// openLineAbove opens a line above the cursor, indented like the current
+// one with autoindent, and enters insert mode.
+func openLineAbove(e SDK) error {
+	var indent []rune
+	if e.Options().Autoindent {
+		indent = leadingWhitespace(cursorRow(e))
+	}
+
+	e.InsertRow(e.Y(), indent)
+	e.SetX(len(indent))
+	e.SetMode(InsertMode)
+	return nil
+}
+
+// appendAfterCursor enters insert mode after the character under the cursor.
+func appendAfterCursor(e SDK) error {
+	if row := cursorRow(e); e.X() < len(row) {
+		e.SetX(nextCluster(row, e.X()))
+	}
+
+	e.SetMode(InsertMode)
+	return nil
+}
+
+func appendAtLineEnd(e SDK) error {
+	e.SetX(len(cursorRow(e)))
+	e.SetMode(InsertMode)
+	return nil
+}
+
+func insertAtLineStart(e SDK) error {
+	e.SetX(len(leadingWhitespace(cursorRow(e))))
+	e.SetMode(InsertMode)
+	return nil
+}
+
+func splitLine(e SDK) error {
+	row := e.Row(e.Y())
+	row, row2 := row[:e.X()], row[e.X():]
+
+	var indent []rune
+	if e.Options().Autoindent {
+		indent = leadingWhitespace(row)
+	}
+
+	e.SetRow(e.Y(), row)
+	e.InsertRow(e.Y()+1, append(indent, row2...))
+
+	e.SetY(e.Y() + 1)
+	e.SetX(len(indent))
+	return nil
+}
+
+func insertTab(e SDK) error {
+	if !e.Options().ExpandTab {
+		e.InsertChars(e.Y(), e.X(), '\t')
+		e.SetX(e.X() + 1)
+		return nil
+	}
+
+	// Spaces go up to the next level of indentation
+	opts := e.Options()
+	sw := opts.ShiftWidth
+	if sw <= 0 {
+		sw = opts.Tabstop
+	}
+	n := sw - e.VisualWidth(e.Row(e.Y())[:e.X()])%sw
+
+	e.InsertChars(e.Y(), e.X(), []rune(strings.Repeat(" ", n))...)
+	e.SetX(e.X() + n)
+	return nil
+}
+
+// dedentLine shifts the current line left by one level of indentation,
+// keeping the cursor on the same character.
+func dedentLine(e SDK) error {
+	row := e.Row(e.Y())
+	shifted := shiftLine(e, row, -1)
+	if runesEqual(shifted, row) {
+		e.Bell()
+		return nil
+	}
+
+	x := e.X() + len(shifted) - len(row)
+	if indent := len(leadingWhitespace(shifted)); x < indent {
+		x = indent
+	}
+
+	e.SetRow(e.Y(), shifted)
+	e.SetX(x)
+	return nil
+}
+
+// visualWidth returns the number of columns chars take up on the screen.
+func visualWidth(chars []rune, tabstop int, cond *runewidth.Condition) int {
+	width := 0
+	widths := charWidths(chars, cond)
+	for i, r := range chars {
+		if r == '\t' {
+			width += tabstop - (width % tabstop)
+		} else {
+			width += widths[i]
+		}
+	}
+
+	return width
+}
+
+// leadingWhitespace returns a copy of the whitespace at the start of row.
+func leadingWhitespace(row []rune) []rune {
+	i := Find(row, func(r rune) bool { return !unicode.IsSpace(r) })
+	if i == -1 {
+		i = len(row)
+	}
+
+	return append([]rune(nil), row[:i]...)
+}
+
+// toggleComment comments out the line using the commentstring option, or
+// uncomments it if it already starts with a comment.
+func toggleComment(e SDK) error {
+	cs := []rune(e.Options().CommentString)
+	if len(cs) == 0 {
+		return fmt.Errorf("commentstring isn't set for this filetype")
+	}
+
+	row := e.Row(e.Y())
+	indent := len(leadingWhitespace(row))
+	text := row[indent:]
+
+	var res []rune
+	if len(text) >= len(cs) && string(text[:len(cs)]) == string(cs) {
+		text = text[len(cs):]
+		if len(text) > 0 && text[0] == ' ' {
+			text = text[1:]
+		}
+
+		res = append(append(res, row[:indent]...), text...)
+	} else {
+		res = append(append(append(res, row[:indent]...), cs...), ' ')
+		res = append(res, text...)
+	}
+
+	e.SetRow(e.Y(), res)
+	return nil
+}
+
+// The cursor movements ring the bell when the cursor is already at the edge
+// of the file.
+
+func moveUp(e SDK) error {
+	if e.Y() == 0 {
+		e.Bell()
+	}
+
+	e.SetY(e.Y() - 1)
+	return nil
+}
+
+func moveDown(e SDK) error {
+	if e.Y() >= e.NumRows()-1 {
+		e.Bell()
+	}
+
+	e.SetY(e.Y() + 1)
+	return nil
+}
+
+func moveLeft(e SDK) error {
+	if e.X() == 0 {
+		e.Bell()
+	}
+
+	e.SetX(prevCluster(cursorRow(e), e.X()))
+	return nil
+}
+
+func moveRight(e SDK) error {
+	if e.X() >= len(cursorRow(e)) {
+		e.Bell()
+	}
+
+	e.SetX(nextCluster(cursorRow(e), e.X()))
+	return nil
+}
+
+// deleteCharBefore deletes the character before the cursor, joining the line
+// onto the previous one at the start of a line. With expandtab, backspacing
+// in the indentation deletes back to the previous multiple of shiftwidth.
+func deleteCharBefore(e SDK) error {
+	x, y := e.X(), e.Y()
+	if x == 0 && y == 0 {
+		// Nothing before the start of the file
+		return nil
+	}
+
+	if n := indentBackspace(e); n > 1 {
+		e.Delete(y, x-n, x-1)
+		e.SetX(x - n)
+	} else if x != 0 {
+		start := prevCluster(e.Row(y), x)
+		e.Delete(y, start, x-1)
+		e.SetX(start)
+	} else {
+		e.SetY(y - 1)
+		e.SetX(len(e.Row(y - 1)))
+
+		e.SetRow(y-1, append(e.Row(y-1), e.Row(y)...))
+		e.DeleteRow(y)
+	}
+
+	return nil
+}
+
+// indentBackspace returns the number of spaces that a backspace should
+// delete, or 0 if the cursor isn't in indentation made of spaces.
+func indentBackspace(e SDK) int {
+	opts := e.Options()
+	row, x := e.Row(e.Y()), e.X()
+	if !opts.ExpandTab || opts.ShiftWidth <= 0 || x == 0 {
+		return 0
+	}
+
+	for _, r := range row[:x] {
+		if r != ' ' {
+			return 0
+		}
+	}
+
+	return x - (x-1)/opts.ShiftWidth*opts.ShiftWidth
+}
+
+func quit(e SDK) error {
+	if !e.IsModified() {
+		return ErrQuitEditor
+	}
+
+	e.Prompt("WARNING!!! File has unsaved changes. Press Ctrl-Q again to quit.",
+		func(k Key) (string, bool) {
+			if k == Key(ctrl('q')) {
+				e.ErrChan() <- ErrQuitEditor
+			}
+
+			return "", true
+		})
+
+	return nil
+}
+
+func save(e SDK) error {
+	logDebugf("saving %s", e.Filename())
+	if err := e.Save(); err != nil {
+		if err == errAskingPassphrase {
+			// The file is saved once it is entered
+			return nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("%w (:sudosave saves as root)", err)
+		}
+		return err
+	}
+
+	e.SetMessage("saved file: %s", e.Filename())
+	return nil
+}
+
+func openFile(e SDK) error {
+	e.StaticPrompt("File name: ", func(res string) error {
+		if len(res) == 0 {
+			return fmt.Errorf("No file name")
+		}
+
+		return e.OpenFile(expandPath(res))
+	}, FileCompletion)
+
+	return nil
+}
+
+// fileInfo describes the file and the cursor position within it, like vi's Ctrl-G.
+func fileInfo(e SDK) string {
+	filename := e.Filename()
+	if len(filename) == 0 {
+		filename = "[No Name]"
+	}
+
+	modified := ""
+	if e.IsModified() {
+		modified = " [Modified]"
+	}
+
+	percent := 0
+	if e.NumRows() > 0 {
+		percent = (e.Y() + 1) * 100 / e.NumRows()
+	}
+
+	return fmt.Sprintf("%q%s %d lines --%d%%-- line %d, col %d",
+		filename, modified, e.NumRows(), percent, e.Y()+1, e.X()+1)
+}
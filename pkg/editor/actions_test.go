@@ -0,0 +1,35 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeleteCharBefore(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		keys  string
+		want  []string
+		// cursor after the keys
+		x, y int
+	}{
+		{"character", []string{"ab"}, "A<BS>", []string{"a"}, 1, 0},
+		{"joins lines", []string{"ab", "cd"}, "ji<BS>", []string{"abcd"}, 2, 0},
+		{"start of the file", []string{"b"}, "ia<BS><BS>", []string{"b"}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.lines...)
+			pressKeys(t, e, tt.keys)
+
+			if got := rowStrings(e.rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if e.cx != tt.x || e.cy != tt.y {
+				t.Errorf("cursor at %d, %d, want %d, %d", e.cx, e.cy, tt.x, tt.y)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// alignCommand lines up the first match of a regexp in each line of the
+// range, or of the paragraph under the cursor, by padding the text before
+// it with spaces: "align <regexp>", e.g. "align =" or "align //".
+func alignCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: align <regexp>")
+	}
+
+	re, err := regexp.Compile(args)
+	if err != nil {
+		return fmt.Errorf("align: %s", err)
+	}
+
+	if e.NumRows() == 0 {
+		return nil
+	}
+
+	rng := paragraphRange(e)
+	if r != nil {
+		rng = *r
+	}
+
+	// The text before the match on each line, without trailing spaces, or
+	// nil for lines without a match
+	lefts := make([][]rune, rng.End-rng.Start+1)
+	rights := make([]string, len(lefts))
+	width := 0
+	for y := rng.Start; y <= rng.End; y++ {
+		line := string(e.Row(y))
+		loc := re.FindStringIndex(line)
+		if loc == nil || loc[0] == loc[1] {
+			continue
+		}
+
+		left := []rune(strings.TrimRightFunc(line[:loc[0]], unicode.IsSpace))
+		lefts[y-rng.Start] = left
+		rights[y-rng.Start] = line[loc[0]:]
+		if w := e.VisualWidth(left); w > width {
+			width = w
+		}
+	}
+
+	for i, left := range lefts {
+		if left == nil {
+			continue
+		}
+
+		pad := width - e.VisualWidth(left)
+		if len(left) > 0 {
+			pad++
+		}
+
+		line := string(left) + strings.Repeat(" ", pad) + rights[i]
+		if y := rng.Start + i; line != string(e.Row(y)) {
+			e.SetRow(y, []rune(line))
+		}
+	}
+
+	return nil
+}
+
+// paragraphRange returns the range of the lines around the cursor that
+// aren't blank.
+func paragraphRange(e SDK) Range {
+	blank := func(y int) bool {
+		return len(strings.TrimSpace(string(e.Row(y)))) == 0
+	}
+
+	start, end := e.Y(), e.Y()
+	for start > 0 && !blank(start-1) {
+		start--
+	}
+	for end+1 < e.NumRows() && !blank(end+1) {
+		end++
+	}
+
+	return Range{start, end}
+}
@@ -0,0 +1,54 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Values of the ambiwidth option
+const (
+	AmbiWidthAuto   = "auto"
+	AmbiWidthSingle = "single"
+	AmbiWidthDouble = "double"
+)
+
+// localeEastAsian is whether the locale makes East Asian characters of
+// ambiguous width double width, as runewidth found at startup.
+var localeEastAsian = runewidth.EastAsianWidth
+
+// setAmbiWidth sets how wide characters of ambiguous width are drawn:
+// "single", "double", or "auto" to go by the locale.
+func (e *Editor) setAmbiWidth(value string) error {
+	switch value {
+	case AmbiWidthAuto, AmbiWidthSingle, AmbiWidthDouble:
+	default:
+		return fmt.Errorf("invalid value for ambiwidth: %s", value)
+	}
+
+	e.cfg.AmbiWidth = value
+	e.applyAmbiWidth()
+	for i := range e.rows {
+		e.updateRow(i)
+	}
+
+	return nil
+}
+
+// applyAmbiWidth makes the widths of runes in the editor follow the
+// ambiwidth option. The rows have to be updated afterwards.
+func (e *Editor) applyAmbiWidth() {
+	switch e.cfg.AmbiWidth {
+	case AmbiWidthSingle:
+		e.width.EastAsianWidth = false
+	case AmbiWidthDouble:
+		e.width.EastAsianWidth = true
+	default:
+		e.width.EastAsianWidth = localeEastAsian
+	}
+}
+
+// VisualWidth returns the number of columns chars take up on the screen.
+func (e *Editor) VisualWidth(chars []rune) int {
+	return visualWidth(chars, e.cfg.Tabstop, e.width)
+}
@@ -0,0 +1,45 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestAmbiWidthIsPerEditor(t *testing.T) {
+	global := runewidth.DefaultCondition.EastAsianWidth
+
+	single := newTestEditor(t, "±±")
+	double := newTestEditor(t, "±±")
+	if err := single.SetOption("ambiwidth=single"); err != nil {
+		t.Fatal(err)
+	}
+	if err := double.SetOption("ambiwidth=double"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		e     *Editor
+		width int
+	}{
+		{"single", single, 2},
+		{"double", double, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.VisualWidth(tt.e.rows[0].chars); got != tt.width {
+				t.Errorf("width = %d, want %d", got, tt.width)
+			}
+
+			if got := tt.e.rowCxToRx(tt.e.rows[0], 2); got != tt.width {
+				t.Errorf("rx = %d, want %d", got, tt.width)
+			}
+		})
+	}
+
+	if runewidth.DefaultCondition.EastAsianWidth != global {
+		t.Error("the ambiwidth option changed the runewidth defaults")
+	}
+}
@@ -0,0 +1,145 @@
+package editor
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long to wait for the terminal to report its background color.
+// Terminals that don't support the query never answer, and a reply coming
+// later is dropped from the input, see backgroundReplyLength.
+const backgroundQueryTimeout = 100 * time.Millisecond
+
+// The start of a reply to the OSC 11 query, and how long one can be
+const (
+	backgroundReplyPrefix = "\x1b]11;"
+	maxBackgroundReply    = 64
+)
+
+// Values of the background option
+const (
+	BackgroundAuto  = "auto"
+	BackgroundDark  = "dark"
+	BackgroundLight = "light"
+)
+
+// background returns whether the terminal background is "light" or "dark",
+// from the background option or, when that is "auto", from the terminal.
+func (e *Editor) background() string {
+	if e.cfg.Background != BackgroundAuto {
+		return e.cfg.Background
+	}
+
+	if e.lightTerminal {
+		return BackgroundLight
+	}
+
+	return BackgroundDark
+}
+
+// queryBackground asks the terminal for its background color with OSC 11
+// and reports whether it is light. ok is false if the terminal didn't answer.
+// The terminal must be in raw mode. Terminals that can't wait for input with
+// a timeout aren't asked.
+func queryBackground(t Terminal) (light, ok bool) {
+	w, canWait := t.(inputWaiter)
+	if !canWait {
+		return false, false
+	}
+
+	if _, err := io.WriteString(t, "\x1b]11;?\x07"); err != nil {
+		return false, false
+	}
+
+	var (
+		reply    []byte
+		buf      = make([]byte, 64)
+		deadline = time.Now().Add(backgroundQueryTimeout)
+	)
+
+	// The reply ends with either BEL or ST, depending on the terminal
+	for !bytes.HasSuffix(reply, []byte("\x07")) && !bytes.HasSuffix(reply, []byte("\x1b\\")) {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return false, false
+		}
+
+		if !w.waitInput(timeout) {
+			return false, false
+		}
+
+		n, err := t.Read(buf)
+		if err != nil {
+			return false, false
+		}
+		reply = append(reply, buf[:n]...)
+	}
+
+	return parseBackgroundReply(string(reply))
+}
+
+// backgroundReplyLength returns the length of the reply to the OSC 11 query
+// that b starts with, 0 if b could be the start of one that isn't complete
+// yet, or -1 if it isn't a reply.
+func backgroundReplyLength(b []byte) int {
+	prefix := []byte(backgroundReplyPrefix)
+	if len(b) < len(prefix) {
+		if bytes.HasPrefix(prefix, b) {
+			return 0
+		}
+		return -1
+	}
+	if !bytes.HasPrefix(b, prefix) {
+		return -1
+	}
+
+	// It ends with BEL or ST
+	for i := len(prefix); i < len(b) && i < maxBackgroundReply; i++ {
+		switch {
+		case b[i] == '\x07':
+			return i + 1
+		case b[i] == '\x1b' && i+1 == len(b):
+			return 0
+		case b[i] == '\x1b' && b[i+1] == '\\':
+			return i + 2
+		case b[i] == '\x1b':
+			return -1
+		}
+	}
+
+	if len(b) >= maxBackgroundReply {
+		return -1
+	}
+	return 0
+}
+
+// parseBackgroundReply parses a reply to the OSC 11 query, e.g.
+// "\x1b]11;rgb:ffff/ffff/dddd\x07", and reports whether the color is light.
+func parseBackgroundReply(reply string) (light, ok bool) {
+	_, color, found := strings.Cut(reply, "rgb:")
+	if !found {
+		return false, false
+	}
+	color = strings.TrimRight(color, "\x07\x1b\\")
+
+	parts := strings.Split(color, "/")
+	if len(parts) != 3 {
+		return false, false
+	}
+
+	// Each component has between 1 and 4 hex digits
+	var rgb [3]float64
+	for i, p := range parts {
+		v, err := strconv.ParseUint(p, 16, 16)
+		if err != nil || len(p) == 0 || len(p) > 4 {
+			return false, false
+		}
+		rgb[i] = float64(v) / float64(uint64(1)<<(4*len(p))-1)
+	}
+
+	luminance := 0.2126*rgb[0] + 0.7152*rgb[1] + 0.0722*rgb[2]
+	return luminance > 0.5, true
+}
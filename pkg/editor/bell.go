@@ -0,0 +1,64 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Values of the bell option
+const (
+	BellNone    = "none"
+	BellAudible = "audible"
+	BellVisual  = "visual"
+)
+
+// How long the screen stays inverted for the visual bell
+const visualBellDuration = 100 * time.Millisecond
+
+const (
+	reverseVideoCode = "\x1b[?5h"
+	normalVideoCode  = "\x1b[?5l"
+)
+
+// setBell sets how the editor signals that something couldn't be done:
+// "none", "audible" to ring the terminal bell, or "visual" to flash the
+// screen.
+func (e *Editor) setBell(value string) error {
+	switch value {
+	case BellNone, BellAudible, BellVisual:
+	default:
+		return fmt.Errorf("invalid value for bell: %s", value)
+	}
+
+	e.cfg.Bell = value
+	return nil
+}
+
+// Bell signals that a key did nothing, e.g. because it isn't bound or the
+// cursor is already at the edge of the file, as set by the bell option.
+func (e *Editor) Bell() {
+	switch e.cfg.Bell {
+	case BellAudible:
+		io.WriteString(e.term, "\a")
+	case BellVisual:
+		e.bellTime = time.Now()
+		time.AfterFunc(visualBellDuration, e.requestRedraw)
+	}
+}
+
+// drawBell inverts the screen while the visual bell is on, and turns it
+// back once it is over.
+func (e *Editor) drawBell(w io.Writer) {
+	flash := time.Since(e.bellTime) < visualBellDuration
+	if flash == e.flashing {
+		return
+	}
+
+	e.flashing = flash
+	if flash {
+		io.WriteString(w, reverseVideoCode)
+	} else {
+		io.WriteString(w, normalVideoCode)
+	}
+}
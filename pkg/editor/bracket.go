@@ -0,0 +1,179 @@
+package editor
+
+import "github.com/mattn/go-runewidth"
+
+// bracketPairs maps each opening bracket to its closing one.
+var bracketPairs = map[rune]rune{
+	'(': ')',
+	'[': ']',
+	'{': '}',
+}
+
+// position is a location in the file, in runes and rows.
+type position struct {
+	x, y int
+}
+
+// matchingBracket returns the position of the bracket matching the one at
+// x, y, searching no further than the rows from minY to maxY. ok is false if
+// there isn't a bracket at x, y or its match wasn't found.
+func (e *Editor) matchingBracket(x, y, minY, maxY int) (pos position, ok bool) {
+	if y < 0 || y >= len(e.rows) || x < 0 || x >= len(e.rows[y].chars) {
+		return position{}, false
+	}
+
+	// Brackets of the same kind nest, those of the other kind unnest
+	this := e.rows[y].chars[x]
+	other, forward := bracketPairs[this]
+	if !forward {
+		for o, c := range bracketPairs {
+			if c == this {
+				other, ok = o, true
+			}
+		}
+		if !ok {
+			return position{}, false
+		}
+	}
+
+	step := 1
+	if !forward {
+		step = -1
+	}
+
+	if minY < 0 {
+		minY = 0
+	}
+	if maxY >= len(e.rows) {
+		maxY = len(e.rows) - 1
+	}
+
+	depth := 0
+	for y >= minY && y <= maxY {
+		chars := e.rows[y].chars
+		for ; x >= 0 && x < len(chars); x += step {
+			switch chars[x] {
+			case this:
+				depth++
+			case other:
+				depth--
+				if depth == 0 {
+					return position{x, y}, true
+				}
+			}
+		}
+
+		y += step
+		if y >= 0 && y < len(e.rows) && !forward {
+			x = len(e.rows[y].chars) - 1
+		} else {
+			x = 0
+		}
+	}
+
+	return position{}, false
+}
+
+// matchingBracketAt returns the position of the bracket matching the one at
+// x, y anywhere in the file, or -1, -1 if there isn't one.
+func (e *Editor) matchingBracketAt(x, y int) (int, int) {
+	pos, ok := e.matchingBracket(x, y, 0, len(e.rows)-1)
+	if !ok {
+		return -1, -1
+	}
+
+	return pos.x, pos.y
+}
+
+// jumpToMatchingBracket moves to the bracket matching the one under the
+// cursor, or the first bracket after the cursor on the line, like vi's '%'.
+func jumpToMatchingBracket(e SDK) error {
+	row := e.Row(e.Y())
+	for x := e.X(); x < len(row); x++ {
+		if mx, my := e.matchingBracketAt(x, e.Y()); mx != -1 {
+			e.SetY(my)
+			e.SetX(mx)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// updateMatchedBracket finds the bracket matching the one under the cursor
+// if it is on the screen, for it to be highlighted.
+func (e *Editor) updateMatchedBracket() {
+	e.matchedBracket = nil
+
+	pos, ok := e.matchingBracket(e.cx, e.cy, e.rowOffset, e.rowOffset+e.screenRows-1)
+	if ok {
+		e.matchedBracket = &pos
+	}
+}
+
+// rowHighlight returns the highlighting to draw a row with, which is its
+// syntax highlighting plus the highlighting that depends on the cursor.
+func (e *Editor) rowHighlight(filerow int) []SyntaxHL {
+	row := e.rows[filerow]
+	hl := row.hl
+
+	if m := e.errorMark; m != nil && m.y == filerow && m.tick == e.changeTick {
+		hl = make([]SyntaxHL, len(row.hl))
+		for i := range hl {
+			hl[i] = hlError
+		}
+	}
+
+	if c := conflictHighlight(e.conflicts, filerow); c != 0 {
+		hl = make([]SyntaxHL, len(row.hl))
+		for i := range hl {
+			hl[i] = c
+		}
+	}
+
+	if s, ok := e.highlightedSearch(); ok {
+		hl = e.searchHighlight(filerow, s, hl)
+	}
+
+	if m := e.matchedBracket; m != nil && (m.y == filerow || e.cy == filerow) {
+		hl = append([]SyntaxHL(nil), hl...)
+		if m.y == filerow {
+			hl[e.renderIndex(row, m.x)] = hlMatchParen
+		}
+		if e.cy == filerow {
+			hl[e.renderIndex(row, e.cx)] = hlMatchParen
+		}
+	}
+
+	return hl
+}
+
+// renderIndex returns the index in the render string of the rune at cx.
+func (e *Editor) renderIndex(row *Row, cx int) int {
+	if e.columns != nil {
+		_, index := e.columns.layout(row.chars)
+		return index[cx]
+	}
+
+	return tabRenderIndex(row.chars, cx, e.cfg.Tabstop, e.width)
+}
+
+// tabRenderIndex returns the index in the render string of the rune at cx,
+// following the tab expansion of updateRow.
+func tabRenderIndex(chars []rune, cx, tabstop int, cond *runewidth.Condition) int {
+	idx, cols := 0, 0
+	widths := charWidths(chars, cond)
+	for i, r := range chars[:cx] {
+		if r == '\t' {
+			n := tabstop - cols%tabstop
+			idx += n
+			cols += n
+			continue
+		}
+
+		idx++
+		cols += widths[i]
+	}
+
+	return idx
+}
@@ -0,0 +1,147 @@
+package editor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cacheDir returns the directory for cached data, the CacheDir option's or
+// jk in the XDG cache directory.
+func (e *Editor) cacheDir() string {
+	if len(e.opts.CacheDir) != 0 {
+		return e.opts.CacheDir
+	}
+
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+
+	return filepath.Join(dir, "jk")
+}
+
+// stateDir returns the directory for data kept between runs that, unlike the
+// cache, shouldn't be thrown away, like the log and the options set for each
+// file.
+func stateDir() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, "jk")
+}
+
+// cachePath returns the path, without an extension, of a kind of data kept
+// about a file, e.g. "session". Each file gets its own entry, named after a
+// hash of its absolute path.
+func (e *Editor) cachePath(kind, filename string) (string, error) {
+	return filePath(e.cacheDir(), kind, filename)
+}
+
+// statePath is like cachePath, for data kept in the state directory.
+func statePath(kind, filename string) (string, error) {
+	return filePath(stateDir(), kind, filename)
+}
+
+// filePath returns the path of a kind of data kept about a file in dir.
+func filePath(dir, kind, filename string) (string, error) {
+	if len(dir) == 0 {
+		return "", os.ErrNotExist
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, kind, hex.EncodeToString(sum[:16])), nil
+}
+
+// readCache decodes the data of the given kind kept about filename into v.
+func (e *Editor) readCache(kind, filename string, v interface{}) error {
+	path, err := e.cachePath(kind, filename)
+	if err != nil {
+		return err
+	}
+
+	return readJSON(path+".json", v)
+}
+
+// readJSON decodes the file at path into v.
+func readJSON(path string, v interface{}) error {
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(out, v)
+}
+
+// removeCache removes the data of the given kind kept about filename.
+func (e *Editor) removeCache(kind, filename string) error {
+	path, err := e.cachePath(kind, filename)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path + ".json")
+}
+
+// writeCache keeps v as the data of the given kind about filename.
+func (e *Editor) writeCache(kind, filename string, v interface{}) error {
+	path, err := e.cachePath(kind, filename)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(path+".json", v)
+}
+
+// writeJSON writes v to the file at path as JSON, creating its directory.
+func writeJSON(path string, v interface{}) error {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writePrivate(path, out)
+}
+
+// writePrivate writes data to the file at path, creating its directory, so
+// that only the user can read it, as what the cache and state directories
+// hold about a file can be as private as the file. A file made readable by
+// others before is made private again before data is written to it.
+func writePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
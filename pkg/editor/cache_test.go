@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteCacheIsPrivate(t *testing.T) {
+	e := newTestEditor(t)
+
+	path, err := e.cachePath("session", "file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As left by a version writing the cache readable by others
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".json", []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.writeCache("session", "file.txt", DisplaySettings{X: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.writeCache("options", "file.txt", []string{"wrap"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []string{"session", "options"} {
+		path, err := e.cachePath(kind, "file.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info, err := os.Stat(filepath.Dir(path)); err != nil {
+			t.Error(err)
+		} else if perm := info.Mode().Perm(); perm != 0o700 {
+			t.Errorf("%s directory permissions are %o, want 700", kind, perm)
+		}
+		if info, err := os.Stat(path + ".json"); err != nil {
+			t.Error(err)
+		} else if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s file permissions are %o, want 600", kind, perm)
+		}
+	}
+}
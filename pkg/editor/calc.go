@@ -0,0 +1,400 @@
+package editor
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// maxCalcBits limits the size of the numbers in an expression, for a typo
+// like 10**10**10 not to take forever.
+const maxCalcBits = 1 << 14
+
+// calcParser evaluates an expression by recursive descent, one precedence
+// level per method.
+type calcParser struct {
+	s   string
+	pos int
+}
+
+// evalExpr evaluates an arithmetic expression. Expressions have the usual
+// operators and precedence, "**" for powers, the integer operators "%", "&",
+// "|", "<<" and ">>", and numbers in decimal, hexadecimal ("0x"), octal
+// ("0o") or binary ("0b"). The result is exact, with whole numbers of any
+// size and decimal fractions, except for powers with a fractional exponent.
+func evalExpr(s string) (*big.Rat, error) {
+	p := &calcParser{s: s}
+	v, err := p.bitOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, errors.Errorf("unexpected %q in expression", p.s[p.pos:])
+	}
+
+	return v, nil
+}
+
+// formatCalc formats the result of an expression, without a fraction when it
+// is a whole number. Other numbers are rounded to the nearest float64.
+func formatCalc(v *big.Rat) (string, error) {
+	if v.IsInt() {
+		return v.Num().String(), nil
+	}
+
+	f, _ := v.Float64()
+	if math.IsInf(f, 0) {
+		return "", errors.New("result too large")
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+func (p *calcParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept skips op if the expression continues with it.
+func (p *calcParser) accept(op string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.s[p.pos:], op) {
+		return false
+	}
+
+	p.pos += len(op)
+	return true
+}
+
+// integers returns the operands of an integer operator, or an error if
+// either has a fraction.
+func integers(op string, a, b *big.Rat) (*big.Int, *big.Int, error) {
+	if !a.IsInt() || !b.IsInt() {
+		return nil, nil, errors.Errorf("%s needs whole numbers", op)
+	}
+
+	return a.Num(), b.Num(), nil
+}
+
+func (p *calcParser) bitOr() (*big.Rat, error) {
+	v, err := p.bitAnd()
+	for err == nil && p.accept("|") {
+		var w *big.Rat
+		if w, err = p.bitAnd(); err != nil {
+			break
+		}
+
+		var a, b *big.Int
+		if a, b, err = integers("|", v, w); err == nil {
+			v = new(big.Rat).SetInt(new(big.Int).Or(a, b))
+		}
+	}
+
+	return v, err
+}
+
+func (p *calcParser) bitAnd() (*big.Rat, error) {
+	v, err := p.shift()
+	for err == nil && p.accept("&") {
+		var w *big.Rat
+		if w, err = p.shift(); err != nil {
+			break
+		}
+
+		var a, b *big.Int
+		if a, b, err = integers("&", v, w); err == nil {
+			v = new(big.Rat).SetInt(new(big.Int).And(a, b))
+		}
+	}
+
+	return v, err
+}
+
+func (p *calcParser) shift() (*big.Rat, error) {
+	v, err := p.sum()
+	for err == nil {
+		var op string
+		switch {
+		case p.accept("<<"):
+			op = "<<"
+		case p.accept(">>"):
+			op = ">>"
+		default:
+			return v, nil
+		}
+
+		var w *big.Rat
+		if w, err = p.sum(); err != nil {
+			break
+		}
+
+		var a, b *big.Int
+		if a, b, err = integers(op, v, w); err != nil {
+			break
+		}
+		if b.Sign() < 0 || b.Cmp(big.NewInt(63)) > 0 {
+			return nil, errors.Errorf("invalid shift: %s", b)
+		}
+
+		if op == "<<" {
+			v = new(big.Rat).SetInt(new(big.Int).Lsh(a, uint(b.Uint64())))
+		} else {
+			v = new(big.Rat).SetInt(new(big.Int).Rsh(a, uint(b.Uint64())))
+		}
+	}
+
+	return v, err
+}
+
+func (p *calcParser) sum() (*big.Rat, error) {
+	v, err := p.product()
+	for err == nil {
+		var w *big.Rat
+		switch {
+		case p.accept("+"):
+			if w, err = p.product(); err == nil {
+				v = new(big.Rat).Add(v, w)
+			}
+		case p.accept("-"):
+			if w, err = p.product(); err == nil {
+				v = new(big.Rat).Sub(v, w)
+			}
+		default:
+			return v, nil
+		}
+	}
+
+	return v, err
+}
+
+func (p *calcParser) product() (*big.Rat, error) {
+	v, err := p.unary()
+	for err == nil {
+		var w *big.Rat
+		p.skipSpace()
+		switch {
+		// "**" is a power, not a product
+		case strings.HasPrefix(p.s[p.pos:], "**"):
+			return v, nil
+		case p.accept("*"):
+			if w, err = p.unary(); err == nil {
+				v = new(big.Rat).Mul(v, w)
+			}
+		case p.accept("/"):
+			if w, err = p.unary(); err != nil {
+				break
+			}
+			if w.Sign() == 0 {
+				return nil, errors.New("division by zero")
+			}
+			v = new(big.Rat).Quo(v, w)
+		case p.accept("%"):
+			if w, err = p.unary(); err != nil {
+				break
+			}
+
+			var a, b *big.Int
+			if a, b, err = integers("%", v, w); err != nil {
+				break
+			}
+			if b.Sign() == 0 {
+				return nil, errors.New("division by zero")
+			}
+			v = new(big.Rat).SetInt(new(big.Int).Rem(a, b))
+		default:
+			return v, nil
+		}
+	}
+
+	return v, err
+}
+
+func (p *calcParser) unary() (*big.Rat, error) {
+	switch {
+	case p.accept("-"):
+		v, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).Neg(v), nil
+	case p.accept("+"):
+		return p.unary()
+	}
+
+	return p.power()
+}
+
+// power is right-associative, so that 2**3**2 is 2**9, and binds tighter
+// than a minus sign on its left, so that -2**2 is -4.
+func (p *calcParser) power() (*big.Rat, error) {
+	v, err := p.operand()
+	if err != nil || !p.accept("**") {
+		return v, err
+	}
+
+	w, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	return calcPower(v, w)
+}
+
+// calcPower returns v**w, exactly when w is a whole number.
+func calcPower(v, w *big.Rat) (*big.Rat, error) {
+	if !w.IsInt() {
+		x, _ := v.Float64()
+		y, _ := w.Float64()
+		f := math.Pow(x, y)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, errors.New("result is not a finite number")
+		}
+		return new(big.Rat).SetFloat64(f), nil
+	}
+
+	n := new(big.Int).Abs(w.Num())
+	switch {
+	case n.Sign() == 0:
+		return big.NewRat(1, 1), nil
+	case v.Sign() == 0 && w.Sign() < 0:
+		return nil, errors.New("division by zero")
+	case v.Sign() == 0 || v.IsInt() && v.Num().CmpAbs(big.NewInt(1)) == 0:
+		// 0, 1 and -1 stay the same whatever the exponent, but for the
+		// sign, so a small one with the same parity does
+		n = big.NewInt(int64(2 + n.Bit(0)))
+	}
+
+	bits := v.Num().BitLen() + v.Denom().BitLen()
+	if !n.IsInt64() || n.Int64()*int64(bits) > maxCalcBits {
+		return nil, errors.New("result too large")
+	}
+
+	num := new(big.Int).Exp(v.Num(), n, nil)
+	den := new(big.Int).Exp(v.Denom(), n, nil)
+	if w.Sign() < 0 {
+		num, den = den, num
+	}
+
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+func (p *calcParser) operand() (*big.Rat, error) {
+	if p.accept("(") {
+		v, err := p.bitOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, errors.New("missing )")
+		}
+		return v, nil
+	}
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		// The sign of an exponent, as in 1e-3
+		exponent := (c == '-' || c == '+') && p.pos > start+1 &&
+			(p.s[p.pos-1] == 'e' || p.s[p.pos-1] == 'E') && !hasBasePrefix(p.s[start:p.pos])
+		if !exponent && !unicode.IsDigit(rune(c)) && !unicode.IsLetter(rune(c)) && c != '.' && c != '_' {
+			break
+		}
+		p.pos++
+	}
+
+	num := p.s[start:p.pos]
+	if len(num) == 0 {
+		if p.pos == len(p.s) {
+			return nil, errors.New("unexpected end of expression")
+		}
+		return nil, errors.Errorf("unexpected %q in expression", p.s[p.pos:])
+	}
+
+	return parseCalcNumber(num)
+}
+
+// hasBasePrefix reports whether a number starts with "0x", "0o" or "0b".
+func hasBasePrefix(s string) bool {
+	return len(s) > 2 && s[0] == '0' && strings.ContainsRune("xXoObB", rune(s[1]))
+}
+
+// parseCalcNumber parses a number of an expression, in decimal or with a
+// base prefix.
+func parseCalcNumber(s string) (*big.Rat, error) {
+	if hasBasePrefix(s) {
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, errors.Errorf("invalid number: %s", s)
+		}
+		return new(big.Rat).SetInt(n), nil
+	}
+
+	digits := strings.ReplaceAll(s, "_", "")
+	if i := strings.IndexAny(digits, "eE"); i != -1 {
+		// big.Rat would write out 1e999999999 in full
+		exp, err := strconv.Atoi(digits[i+1:])
+		if err != nil || exp > maxCalcBits/3 || exp < -maxCalcBits/3 {
+			return nil, errors.Errorf("invalid number: %s", s)
+		}
+	}
+
+	v, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return nil, errors.Errorf("invalid number: %s", s)
+	}
+
+	return v, nil
+}
+
+// calcCommand evaluates an expression and inserts its result before the
+// cursor, for sizes and offsets in config files and the like: "calc <expr>".
+func calcCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: calc <expr>")
+	}
+
+	v, err := evalExpr(args)
+	if err != nil {
+		return err
+	}
+
+	result, err := formatCalc(v)
+	if err != nil {
+		return err
+	}
+
+	insertBeforeCursor(e, result)
+	e.SetMessage("%s = %s", args, result)
+	return nil
+}
+
+// insertCalc asks for an expression and types its result, for Ctrl-X = in
+// insert mode.
+func insertCalc(e SDK) error {
+	e.StaticPrompt("=", func(expr string) error {
+		if len(strings.TrimSpace(expr)) == 0 {
+			return nil
+		}
+
+		v, err := evalExpr(expr)
+		if err != nil {
+			return err
+		}
+
+		result, err := formatCalc(v)
+		if err != nil {
+			return err
+		}
+
+		e.InsertText(result)
+		return nil
+	}, nil)
+	return nil
+}
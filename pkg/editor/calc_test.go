@@ -0,0 +1,71 @@
+package editor
+
+import "testing"
+
+func TestCalc(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+		// the error, if there is one
+		err string
+	}{
+		{expr: "1 + 2 * 3", want: "7"},
+		{expr: "(1 + 2) * 3", want: "9"},
+		{expr: "7 / 2", want: "3.5"},
+		{expr: "1 / 3", want: "0.3333333333333333"},
+		{expr: "0.1 + 0.2", want: "0.3"},
+		{expr: "0.1 * 3 - 0.3", want: "0"},
+		{expr: "2 ** 3 ** 2", want: "512"},
+		{expr: "-2 ** 2", want: "-4"},
+		{expr: "2 ** -2", want: "0.25"},
+		{expr: "(-1) ** 1000001", want: "-1"},
+		{expr: "0 ** 123456789", want: "0"},
+		{expr: "2 ** 0.5", want: "1.4142135623730951"},
+		{expr: "2 ** 53 + 1", want: "9007199254740993"},
+		{expr: "2 ** 64", want: "18446744073709551616"},
+		{expr: "1e20", want: "100000000000000000000"},
+		{expr: "1.5e-3", want: "0.0015"},
+		{expr: "1_000 * 3", want: "3000"},
+		{expr: "0x10 + 0o10 + 0b10", want: "26"},
+		{expr: "7 % 3", want: "1"},
+		{expr: "-7 % 3", want: "-1"},
+		{expr: "6 & 3 | 8", want: "10"},
+		{expr: "1 << 63", want: "9223372036854775808"},
+		{expr: "-8 >> 1", want: "-4"},
+		{expr: "1 / 0", err: "division by zero"},
+		{expr: "0 ** -1", err: "division by zero"},
+		{expr: "5 % 0", err: "division by zero"},
+		{expr: "1.5 % 1", err: "% needs whole numbers"},
+		{expr: "1 << 64", err: "invalid shift: 64"},
+		{expr: "(-1) ** 0.5", err: "result is not a finite number"},
+		{expr: "10 ** 400.5", err: "result is not a finite number"},
+		{expr: "10 ** 10 ** 10", err: "result too large"},
+		{expr: "1e999999999", err: "invalid number: 1e999999999"},
+		{expr: "inf", err: "invalid number: inf"},
+		{expr: "nan", err: "invalid number: nan"},
+		{expr: "1 +", err: "unexpected end of expression"},
+		{expr: "(1", err: "missing )"},
+		{expr: "1 ) 2", err: `unexpected ") 2" in expression`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			var got string
+			v, err := evalExpr(tt.expr)
+			if err == nil {
+				got, err = formatCalc(v)
+			}
+
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("got %q, %v, want error %q", got, err, tt.err)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package editor
+
+import (
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The lines changed since the file was last opened or saved are marked in a
+// column of the gutter when the changemarks option is set, like the git signs
+// but for the unsaved changes.
+
+// Width of the column of unsaved change marks in the gutter
+const changeColumnWidth = 2
+
+// unsavedChanges holds the text of the file as last read or written, and
+// the differences from it.
+type unsavedChanges struct {
+	saved []string
+	hunks []DiffHunk
+	signs map[int]diffSign
+	// changeTick of the text the hunks were found for, or -1
+	tick int
+}
+
+// rowStrings returns the text of each row.
+func rowStrings(rows []*Row) []string {
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = string(row.chars)
+	}
+
+	return lines
+}
+
+// markSavedText records the text as the one in the file.
+func (e *Editor) markSavedText() {
+	e.changes = unsavedChanges{saved: rowStrings(e.rows), tick: -1}
+}
+
+// updateChanges diffs the text against the saved one if it changed since the
+// last time.
+func (e *Editor) updateChanges() {
+	c := &e.changes
+	if c.tick == e.changeTick {
+		return
+	}
+
+	c.hunks = diffLines(c.saved, rowStrings(e.rows))
+	c.signs = diffSigns(c.hunks)
+	c.tick = e.changeTick
+}
+
+// unsavedChanges returns the differences between the file as it was last
+// opened or saved and the text.
+func (e *Editor) unsavedChanges() []DiffHunk {
+	e.updateChanges()
+	return e.changes.hunks
+}
+
+// changeWidth returns the width of the column of unsaved change marks.
+func (e *Editor) changeWidth() int {
+	if !e.cfg.ChangeMarks {
+		return 0
+	}
+
+	return changeColumnWidth
+}
+
+// drawChangeMark marks filerow if it changed since the file was saved. A
+// negative filerow draws blanks.
+func (e *Editor) drawChangeMark(w io.Writer, filerow int) {
+	if e.changeWidth() == 0 {
+		return
+	}
+
+	text := " "
+	if filerow >= 0 {
+		e.updateChanges()
+		switch e.changes.signs[filerow] {
+		case signAdded, signChanged:
+			text = "*"
+		case signDeleted:
+			text = "_"
+		case signDeletedAbove:
+			text = "‾"
+		}
+	}
+
+	if text != " " {
+		setStyle(w, e.color(hlDiffChange))
+	}
+	w.Write([]byte(text + strings.Repeat(" ", changeColumnWidth-1)))
+	clearFormatting(w)
+}
+
+// moveToChange moves to the start of the next unsaved change, or the
+// previous one when n is -1.
+func moveToChange(n int) func(e SDK) error {
+	return func(e SDK) error {
+		hunks := e.unsavedChanges()
+
+		if n < 0 {
+			for i := len(hunks) - 1; i >= 0; i-- {
+				if hunks[i].NewStart < e.Y() {
+					e.SetY(hunks[i].NewStart)
+					return nil
+				}
+			}
+		} else {
+			for _, h := range hunks {
+				if h.NewStart > e.Y() {
+					e.SetY(h.NewStart)
+					return nil
+				}
+			}
+		}
+
+		e.SetMessage("no more changes")
+		return nil
+	}
+}
+
+// revertLine puts row y back the way it is in the file. An added row is
+// deleted, and rows deleted after it, or before the first row, are put back.
+func (e *Editor) revertLine(y int) error {
+	h, ok := hunkAt(e.unsavedChanges(), y)
+	if !ok {
+		return errors.New("the line hasn't changed")
+	}
+
+	saved := e.changes.saved
+	switch i := y - h.NewStart; {
+	case h.NewLen == 0:
+		for j, line := range saved[h.OldStart : h.OldStart+h.OldLen] {
+			e.InsertRow(h.NewStart+j, []rune(line))
+		}
+	case i < h.OldLen:
+		e.SetRow(y, []rune(saved[h.OldStart+i]))
+	default:
+		e.DeleteRow(y)
+		e.WrapCursorY()
+	}
+
+	return nil
+}
+
+// changesCommand counts the lines changed since the file was saved, or puts
+// the lines of the range, the cursor's by default, back the way they are in
+// the file: "changes [revert]".
+func changesCommand(e SDK, r *Range, args string) error {
+	switch args {
+	case "":
+		n := 0
+		for _, h := range e.unsavedChanges() {
+			n += h.NewLen
+		}
+		e.SetMessage("%d lines changed since the last save", n)
+		return nil
+	case "revert":
+		if r == nil {
+			return e.revertLine(e.Y())
+		}
+
+		// From the bottom up, so that deleting rows doesn't move the
+		// ones left to revert
+		for y := r.End; y >= r.Start; y-- {
+			if _, ok := hunkAt(e.unsavedChanges(), y); !ok {
+				continue
+			}
+
+			if err := e.revertLine(y); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.New("usage: changes [revert]")
+	}
+}
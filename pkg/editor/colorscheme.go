@@ -0,0 +1,189 @@
+package editor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// highlightNames are the names of the highlight groups in colorscheme files.
+var highlightNames = map[string]SyntaxHL{
+	"normal":         hlNormal,
+	"comment":        hlComment,
+	"mlcomment":      hlMlComment,
+	"keyword1":       hlKeyword1,
+	"keyword2":       hlKeyword2,
+	"string":         hlString,
+	"number":         hlNumber,
+	"match":          hlMatch,
+	"todo":           hlTodo,
+	"url":            hlURL,
+	"matchparen":     hlMatchParen,
+	"diffadd":        hlDiffAdd,
+	"diffchange":     hlDiffChange,
+	"diffdelete":     hlDiffDelete,
+	"conflictmarker": hlConflictMarker,
+	"conflictours":   hlConflictOurs,
+	"conflictbase":   hlConflictBase,
+	"conflicttheirs": hlConflictTheirs,
+	"difftext":       hlDiffText,
+	"column":         hlColumn,
+	"error":          hlError,
+	"menu":           hlMenu,
+	"statusbar":      hlStatusBar,
+	"linenumber":     hlLineNumber,
+	"selection":      hlSelection,
+	"scrollbar":      hlScrollbar,
+}
+
+// The colorscheme that is always available, without a file
+const defaultColorschemeName = "default"
+
+// colorschemeDir returns the directory that colorscheme files are loaded
+// from, next to the config file.
+func colorschemeDir() string {
+	path := configPath()
+	if len(path) == 0 {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(path), "themes")
+}
+
+// loadColorscheme reads a colorscheme file. Each line is the name of a
+// highlight group followed by its SGR parameters, e.g. "keyword1 1;34".
+// Groups the file doesn't mention keep the colors of base.
+func loadColorscheme(path string, base Colorscheme) (Colorscheme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cs := make(Colorscheme, len(base))
+	for hl, color := range base {
+		cs[hl] = color
+	}
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("%s:%d: expected a highlight group and its color", path, n)
+		}
+
+		hl, ok := highlightNames[fields[0]]
+		if !ok {
+			return nil, errors.Errorf("%s:%d: unknown highlight group: %s", path, n, fields[0])
+		}
+
+		if !validSGR(fields[1]) {
+			return nil, errors.Errorf("%s:%d: invalid color: %s", path, n, fields[1])
+		}
+
+		cs[hl] = fields[1]
+	}
+
+	return cs, s.Err()
+}
+
+// validSGR reports whether s is a list of numeric SGR parameters separated
+// by semicolons.
+func validSGR(s string) bool {
+	for _, p := range strings.Split(s, ";") {
+		if len(p) == 0 || strings.Trim(p, "0123456789") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setColorscheme switches to the named colorscheme, loading it from the
+// themes directory unless it is the default one. A variant for the terminal
+// background, e.g. "<name>-light", is used in preference to "<name>".
+func (e *Editor) setColorscheme(name string) error {
+	base := defaultColorscheme
+	if e.background() == BackgroundLight {
+		base = defaultLightColorscheme
+	}
+
+	if name == defaultColorschemeName {
+		e.colorscheme = base
+		e.colorschemeName = name
+		return nil
+	}
+
+	if strings.ContainsRune(name, filepath.Separator) {
+		return errors.Errorf("invalid colorscheme name: %s", name)
+	}
+
+	dir := colorschemeDir()
+	if len(dir) == 0 {
+		return errors.New("can't find the themes directory")
+	}
+
+	path := filepath.Join(dir, name)
+	if variant := path + "-" + e.background(); fileExists(variant) {
+		path = variant
+	}
+
+	cs, err := loadColorscheme(path, base)
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.Errorf("no such colorscheme: %s", name)
+	} else if err != nil {
+		return err
+	}
+
+	e.colorscheme = cs
+	e.colorschemeName = name
+	return nil
+}
+
+// colorschemeCommand switches colorscheme, or shows the current one when
+// given no arguments.
+func colorschemeCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		e.SetMessage("%s", e.currentColorscheme())
+		return nil
+	}
+
+	return e.setColorscheme(args)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (e *Editor) currentColorscheme() string {
+	return e.colorschemeName
+}
+
+// colorschemeNames returns the names of the available colorschemes, with
+// the light and dark variants counting as the colorscheme they belong to.
+func colorschemeNames() []string {
+	names := []string{defaultColorschemeName}
+
+	files, _ := os.ReadDir(colorschemeDir())
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimSuffix(f.Name(), "-"+BackgroundLight), "-"+BackgroundDark)
+		if name != names[len(names)-1] {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
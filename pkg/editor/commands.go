@@ -0,0 +1,387 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExCommand is a command that can be run from the ':' prompt. r is the range
+// of lines given before the command name, or nil if there wasn't one. args
+// contains everything after the command name, with surrounding whitespace
+// removed.
+type ExCommand func(e SDK, r *Range, args string) error
+
+// Range is an inclusive range of rows that an ex command applies to.
+type Range struct {
+	Start, End int
+}
+
+// builtinCommands are the ex commands every editor starts with.
+var builtinCommands = map[string]ExCommand{
+	"set":         setCommand,
+	"messages":    messagesCommand,
+	"help":        helpCommand,
+	"config":      configCommand,
+	"map":         mapCommand,
+	"unmap":       unmapCommand,
+	"filetype":    filetypeCommand,
+	"retab":       retabCommand,
+	"colorscheme": colorschemeCommand,
+	"highlight":   highlightCommand,
+	"hunk":        hunkCommand,
+	"conflict":    conflictCommand,
+	"diff":        diffCommand,
+	"count":       countCommand,
+	"goto":        gotoCommand,
+	"encode":      encodeCommand,
+	"decode":      decodeCommand,
+	"json":        jsonCommand,
+	"align":       alignCommand,
+	"uniq":        uniqCommand,
+	"reverse":     reverseCommand,
+	"sudosave":    sudoSaveCommand,
+	"plugins":     pluginsCommand,
+	"lua":         luaCommand,
+	"autocmd":     autocmdCommand,
+	"job":         jobCommand,
+	"jobs":        jobsCommand,
+	"grep":        grepCommand,
+	"changes":     changesCommand,
+	"undo":        undoCommand,
+	"redo":        redoCommand,
+	"let":         letCommand,
+	"unlet":       unletCommand,
+	"cd":          cdCommand,
+	"lcd":         lcdCommand,
+	"pwd":         pwdCommand,
+	"new":         newCommand,
+	"scratch":     scratchCommand,
+
+	// Characters that can't be typed, by code point
+	"insert-unicode": insertUnicodeCommand,
+
+	// Long output, in the output buffer
+	"!":      shellCommand,
+	"make":   makeCommand,
+	"output": outputCommand,
+
+	// Arithmetic, with the result inserted
+	"calc": calcCommand,
+
+	// Dates and templates
+	"date":     dateCommand,
+	"template": templateCommand,
+
+	// Options remembered for the file
+	"forget-options": forgetOptionsCommand,
+	"secret":         secretCommand,
+}
+
+// ExecCommand runs a line entered at the ':' prompt.
+func (e *Editor) ExecCommand(line string) error {
+	line = strings.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+
+	r, line, err := e.parseRange(line)
+	if err != nil {
+		return err
+	}
+
+	// The name ends at the first character that can't be part of one, so
+	// that e.g. "retab!" is the retab command with the argument "!".
+	end := strings.IndexFunc(line, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '-'
+	})
+	if end == -1 {
+		end = len(line)
+	}
+	if end == 0 && strings.HasPrefix(line, "!") {
+		end = 1
+	}
+	name, args := line[:end], line[end:]
+
+	cmd, ok := e.command(name)
+	if !ok {
+		return fmt.Errorf("unknown command: %s", name)
+	}
+
+	return cmd(e, r, strings.TrimSpace(args))
+}
+
+// parseRange parses the range at the start of a command line, returning it
+// along with the rest of the line. The range is nil if the line doesn't
+// start with one.
+//
+// A range is either "%" for the whole file, or one or two addresses
+// separated by a comma. An address is a line number, "." for the current
+// line or "$" for the last line, optionally followed by offsets such as "+2".
+func (e *Editor) parseRange(line string) (*Range, string, error) {
+	if strings.HasPrefix(line, "%") {
+		return &Range{0, e.NumRows() - 1}, strings.TrimSpace(line[1:]), nil
+	}
+
+	start, line, ok, err := e.parseAddress(line)
+	if err != nil || !ok {
+		return nil, line, err
+	}
+
+	end := start
+	if strings.HasPrefix(line, ",") {
+		if end, line, ok, err = e.parseAddress(line[1:]); err != nil {
+			return nil, line, err
+		} else if !ok {
+			return nil, line, fmt.Errorf("invalid range: missing address after ','")
+		}
+	}
+
+	if start > end {
+		start, end = end, start
+	}
+
+	if start < 0 || end >= e.NumRows() {
+		return nil, line, fmt.Errorf("invalid range: %d,%d", start+1, end+1)
+	}
+
+	return &Range{start, end}, strings.TrimSpace(line), nil
+}
+
+// parseAddress parses a line address at the start of s, returning the row
+// it refers to and the rest of s. ok is false if s doesn't start with one.
+func (e *Editor) parseAddress(s string) (row int, rest string, ok bool, err error) {
+	s = strings.TrimLeft(s, " ")
+
+	switch {
+	case strings.HasPrefix(s, "."):
+		row, s, ok = e.cy, s[1:], true
+	case strings.HasPrefix(s, "$"):
+		row, s, ok = e.NumRows()-1, s[1:], true
+	case len(s) > 0 && s[0] >= '0' && s[0] <= '9':
+		n, i := leadingNumber(s)
+		row, s, ok = n-1, s[i:], true
+	case strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-"):
+		// Offsets on their own are relative to the current line
+		row, ok = e.cy, true
+	}
+
+	for ok && len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+
+		n, i := leadingNumber(s[1:])
+		if i == 0 {
+			// A bare "+" or "-" means one line
+			n = 1
+		}
+
+		row += sign * n
+		s = s[1+i:]
+	}
+
+	return row, s, ok, nil
+}
+
+// leadingNumber returns the decimal number at the start of s and its length
+// in bytes.
+func leadingNumber(s string) (int, int) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	n, _ := strconv.Atoi(s[:i])
+	return n, i
+}
+
+// orAll returns the range, or the whole file if r is nil.
+func (r *Range) orAll(e SDK) Range {
+	if r == nil {
+		return Range{0, e.NumRows() - 1}
+	}
+
+	return *r
+}
+
+// orLine returns the range, or the line under the cursor if there isn't
+// one.
+func (r *Range) orLine(e SDK) Range {
+	if r == nil {
+		return Range{e.Y(), e.Y()}
+	}
+
+	return *r
+}
+
+func setCommand(e SDK, r *Range, args string) error {
+	for _, arg := range splitArgs(args) {
+		if err := e.SetOption(arg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitArgs splits args on whitespace. A space preceded by a backslash is
+// kept as part of the argument, e.g. "statusleft=a\ b".
+func splitArgs(args string) []string {
+	var (
+		res []string
+		cur strings.Builder
+	)
+
+	for i := 0; i < len(args); i++ {
+		switch c := args[i]; {
+		case c == '\\' && i+1 < len(args) && args[i+1] == ' ':
+			cur.WriteByte(' ')
+			i++
+		case c == ' ' || c == '\t':
+			if cur.Len() > 0 {
+				res = append(res, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+
+	if cur.Len() > 0 {
+		res = append(res, cur.String())
+	}
+
+	return res
+}
+
+// messagesCommand shows the messages in the output buffer, or over the text
+// while the file has unsaved changes: "messages".
+func messagesCommand(e SDK, r *Range, args string) error {
+	if e.IsModified() {
+		e.ShowLines(e.messageHistory())
+		return nil
+	}
+
+	if err := e.openOutput("messages"); err != nil {
+		return err
+	}
+
+	e.appendOutput(e.messageHistory()...)
+	return nil
+}
+
+// mapCommand binds keys to an action: "map <keymap> <keys> <action>".
+func mapCommand(e SDK, r *Range, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) != 3 {
+		return fmt.Errorf("usage: map <keymap> <keys> <action>")
+	}
+
+	return e.Map(fields[0], fields[1], fields[2])
+}
+
+// unmapCommand removes a binding: "unmap <keymap> <keys>".
+func unmapCommand(e SDK, r *Range, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return fmt.Errorf("usage: unmap <keymap> <keys>")
+	}
+
+	return e.Unmap(fields[0], fields[1])
+}
+
+// retabCommand rewrites the indentation of the lines in the range, or the
+// whole file, using spaces if expandtab is set and tabs otherwise. An
+// argument sets a new tabstop, with the indentation keeping its width as
+// measured with the old one.
+func retabCommand(e SDK, r *Range, args string) error {
+	tabstop := e.Options().Tabstop
+	newTabstop := tabstop
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid tabstop: %s", args)
+		}
+		newTabstop = n
+	}
+
+	rng := r.orAll(e)
+	changed := 0
+	for y := rng.Start; y <= rng.End; y++ {
+		row := e.Row(y)
+
+		i := Find(row, func(c rune) bool { return c != ' ' && c != '\t' })
+		if i == -1 {
+			i = len(row)
+		}
+
+		width := e.VisualWidth(row[:i])
+
+		var indent string
+		if e.Options().ExpandTab {
+			indent = strings.Repeat(" ", width)
+		} else {
+			indent = strings.Repeat("\t", width/newTabstop) + strings.Repeat(" ", width%newTabstop)
+		}
+
+		if indent != string(row[:i]) {
+			e.SetRow(y, append([]rune(indent), row[i:]...))
+			changed++
+		}
+	}
+
+	if newTabstop != tabstop {
+		if err := e.SetOption(fmt.Sprintf("tabstop=%d", newTabstop)); err != nil {
+			return err
+		}
+	}
+
+	e.SetMessage("retab: %d lines changed", changed)
+	return nil
+}
+
+// gotoCommand moves the cursor to a byte offset in the file, counting from
+// 0 and with a newline at the end of every line: "goto <offset>".
+func gotoCommand(e SDK, r *Range, args string) error {
+	offset, err := strconv.Atoi(args)
+	if err != nil || offset < 0 {
+		return fmt.Errorf("usage: goto <offset>")
+	}
+
+	x, y, ok := bytePosition(e, offset)
+	if !ok {
+		return fmt.Errorf("offset %d is past the end of the file", offset)
+	}
+
+	e.SetY(y)
+	e.SetX(x)
+	e.CenterCursor()
+	return nil
+}
+
+// bytePosition returns the rune and row at a byte offset in the file. An
+// offset within a multibyte rune gives that rune, and the offset of a
+// newline gives the end of its row.
+func bytePosition(e SDK, offset int) (x, y int, ok bool) {
+	for y = 0; y < e.NumRows(); y++ {
+		row := e.Row(y)
+		for x = range row {
+			offset -= utf8.RuneLen(row[x])
+			if offset < 0 {
+				return x, y, true
+			}
+		}
+
+		// the newline
+		if offset == 0 {
+			return len(row), y, true
+		}
+		offset--
+	}
+
+	return 0, 0, false
+}
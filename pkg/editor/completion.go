@@ -0,0 +1,285 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Most candidates shown at once by the completion menu
+const maxMenuHeight = 10
+
+// completionMenu holds the candidates for completing the input of a
+// prompt. Repeated Tab and Shift-Tab presses cycle through them.
+type completionMenu struct {
+	items []CmplItem
+	// index of the candidate in the input, or -1 before cycling
+	selected int
+}
+
+// complete returns the input completed with comp in the direction of
+// Tab (1) or Shift-Tab (-1). A single candidate is filled in, and several
+// ones are shown in the menu after filling in what they have in common.
+// Further presses while the menu is open cycle through them.
+func (e *Editor) complete(input string, comp CompletionFunc, dir int) string {
+	if m := e.completion; m != nil {
+		m.selected = (m.selected + dir + len(m.items)) % len(m.items)
+
+		return m.items[m.selected].Real
+	}
+
+	items, err := comp(input)
+	if err != nil || len(items) == 0 {
+		return input
+	}
+
+	if len(items) == 1 {
+		return items[0].Real
+	}
+
+	e.completion = &completionMenu{items: items, selected: -1}
+	if prefix := commonPrefix(items); len(prefix) > len(input) {
+		return prefix
+	}
+
+	// Nothing more in common, so start cycling straight away
+	return e.complete(input, comp, dir)
+}
+
+// CompleteAtCursor completes the text of the current row from start to the
+// cursor with comp, the way Tab does in prompts. While the menu is open, Tab
+// and Ctrl-N cycle forward through the candidates and Shift-Tab and Ctrl-P
+// backward, and any other key closes it before doing what it usually does.
+func (e *Editor) CompleteAtCursor(start int, comp CompletionFunc) {
+	if e.cy >= len(e.rows) {
+		e.Bell()
+		return
+	}
+
+	replace := func(text string) {
+		row := e.rows[e.cy].chars
+		chars := append(append(copyRunes(row[:start]), []rune(text)...), row[e.cx:]...)
+		e.SetRow(e.cy, chars)
+		e.cx = start + utf8.RuneCountInString(text)
+	}
+
+	input := string(e.rows[e.cy].chars[start:e.cx])
+	e.completion = nil
+	text := e.complete(input, comp, 1)
+	if text != input {
+		replace(text)
+	}
+	if e.completion == nil {
+		if text == input {
+			e.Bell()
+		}
+		return
+	}
+
+	e.keymapping = append([]KeyMap{{
+		Name: CompletionName,
+		Handler: func(_ SDK, k Key) (bool, error) {
+			dir := 0
+			switch k {
+			case Key('\t'), Key(ctrl('n')):
+				dir = 1
+			case keyShiftTab, Key(ctrl('p')):
+				dir = -1
+			}
+
+			if dir == 0 || e.completion == nil {
+				e.closeCompletion()
+				return false, nil
+			}
+
+			replace(e.complete("", comp, dir))
+			return true, nil
+		},
+	}}, e.keymapping...)
+}
+
+// closeCompletion closes the menu of CompleteAtCursor.
+func (e *Editor) closeCompletion() {
+	e.completion = nil
+	for i, keymap := range e.keymapping {
+		if keymap.Name == CompletionName {
+			e.keymapping = append(e.keymapping[:i:i], e.keymapping[i+1:]...)
+			return
+		}
+	}
+}
+
+// isPathChar reports whether r can be part of a file path written in the
+// text, which ends at spaces, quotes and brackets.
+func isPathChar(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune("\"'`<>()[]{},;=|&", r)
+}
+
+// completePath completes the file path before the cursor with FileCompletion.
+func completePath(e SDK) error {
+	row, x := cursorRow(e), e.X()
+	start := x
+	for start > 0 && isPathChar(row[start-1]) {
+		start--
+	}
+
+	e.CompleteAtCursor(start, FileCompletion)
+	return nil
+}
+
+// commonPrefix returns the longest prefix shared by the completions.
+func commonPrefix(items []CmplItem) string {
+	prefix := []rune(items[0].Real)
+	for _, item := range items[1:] {
+		real := []rune(item.Real)
+		i := 0
+		for i < len(prefix) && i < len(real) && prefix[i] == real[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+
+	return string(prefix)
+}
+
+// drawCompletionMenu draws the candidates over the bottom of the text
+// area, scrolled to keep the selected one visible.
+func (e *Editor) drawCompletionMenu(w io.Writer) {
+	m := e.completion
+	if m == nil {
+		return
+	}
+
+	height := len(m.items)
+	if height > maxMenuHeight {
+		height = maxMenuHeight
+	}
+	if height > e.screenRows {
+		height = e.screenRows
+	}
+
+	first := 0
+	if m.selected >= height {
+		first = m.selected - height + 1
+	}
+
+	for i := 0; i < height; i++ {
+		item := m.items[first+i]
+		fmt.Fprintf(w, "\x1b[%d;1H", e.screenRows-height+i+1)
+
+		hl := hlMenu
+		if first+i == m.selected {
+			hl = hlSelection
+		}
+		setStyle(w, e.color(hl))
+
+		line := e.width.Truncate(" "+item.Display, e.screenCols, "")
+		w.Write([]byte(e.width.FillRight(line, e.screenCols)))
+		clearFormatting(w)
+	}
+}
+
+// WordCompletion returns a completion of the last word of the input from
+// the words returned by words, which are looked up on every completion.
+func WordCompletion(words func() []string) CompletionFunc {
+	return func(a string) ([]CmplItem, error) {
+		i := strings.LastIndexAny(a, " \t") + 1
+		before, word := a[:i], a[i:]
+
+		var res []CmplItem
+		for _, w := range words() {
+			if strings.HasPrefix(w, word) {
+				res = append(res, CmplItem{Display: w, Real: before + w})
+			}
+		}
+
+		return res, nil
+	}
+}
+
+// staticWords is a list of words for WordCompletion.
+func staticWords(words ...string) func() []string {
+	return func() []string { return words }
+}
+
+// builtinCompletions complete the arguments of the built-in ex commands.
+var builtinCompletions = map[string]CompletionFunc{
+	"set":         WordCompletion(optionNames),
+	"colorscheme": WordCompletion(colorschemeNames),
+	"filetype":    WordCompletion(filetypeNames),
+	"highlight":   WordCompletion(highlightGroupNames),
+	"hunk":        WordCompletion(staticWords("stage", "revert")),
+	"jobs":        WordCompletion(staticWords("kill")),
+	"conflict":    WordCompletion(staticWords("ours", "theirs", "both")),
+	"json":        WordCompletion(staticWords("fmt", "min")),
+	"encode":      WordCompletion(transformList),
+	"decode":      WordCompletion(transformList),
+	"diff":        FileCompletion,
+	"cd":          FileCompletion,
+	"lcd":         FileCompletion,
+	"autocmd":     WordCompletion(eventNames),
+	"template":    WordCompletion(templateNames),
+}
+
+// CommandCompletion completes the name of an ex command and then its
+// arguments, keeping any range before it.
+func CommandCompletion(e SDK) CompletionFunc {
+	return func(a string) ([]CmplItem, error) {
+		start := strings.IndexFunc(a, unicode.IsLetter)
+		if start == -1 {
+			return nil, nil
+		}
+		rng, line := a[:start], a[start:]
+
+		name, args, hasArgs := strings.Cut(line, " ")
+		if !hasArgs {
+			var res []CmplItem
+			for _, cmd := range e.commandNames() {
+				if strings.HasPrefix(cmd, name) {
+					res = append(res, CmplItem{Display: cmd, Real: rng + cmd})
+				}
+			}
+
+			return res, nil
+		}
+
+		comp := e.argCompletion(name)
+		if comp == nil {
+			return nil, nil
+		}
+
+		items, err := comp(args)
+		for i := range items {
+			items[i].Real = rng + name + " " + items[i].Real
+		}
+
+		return items, err
+	}
+}
+
+func filetypeNames() []string {
+	var names []string
+	for _, syntax := range syntaxes {
+		names = append(names, syntax.filetype)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func highlightGroupNames() []string {
+	names := []string{"clear"}
+	for name := range highlightNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func transformList() []string {
+	return strings.Split(transformNames(), "|")
+}
@@ -0,0 +1,295 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const Version = "dev"
+
+// KeyMap binds keys to actions for one mode.
+//
+// bindings maps a sequence of keys in key notation (e.g. "gj" or "<C-s>") to
+// the name of an action in Actions. Keys that aren't bound are passed to
+// Handler, if there is one, which reports whether it handled the key.
+type KeyMap struct {
+	Name     KeyMapName
+	Bindings map[string]string
+	Handler  func(e SDK, k Key) (bool, error)
+}
+
+// hasPrefix reports whether keys is the beginning of a longer binding.
+func (m KeyMap) hasPrefix(keys string) bool {
+	for seq := range m.Bindings {
+		if len(seq) > len(keys) && strings.HasPrefix(seq, keys) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type KeyMapName string
+
+const (
+	BasicMapName    KeyMapName = "Basic"
+	InsertModeName  KeyMapName = "Insert"
+	CommandModeName KeyMapName = "Command"
+	PendingKeyName  KeyMapName = "Pending"
+
+	OperatorPendingName KeyMapName = "OperatorPending"
+	CompletionName      KeyMapName = "Completion"
+)
+
+// defaultBindings are the bindings of each keymap before any changes from
+// the config file.
+var defaultBindings = map[KeyMapName]map[string]string{
+	BasicMapName: {
+		"<PageUp>":   "screen-top",
+		"<PageDown>": "screen-bottom",
+		"<Up>":       "move-up",
+		"<Down>":     "move-down",
+		"<Left>":     "move-left",
+		"<Right>":    "move-right",
+		"<C-q>":      "quit",
+		"<C-s>":      "save",
+		"<C-e>":      "open-file",
+		"<C-f>":      "find",
+		"<C-g>":      "file-info",
+		"<C-w>":      "delete-word-before",
+		"<C-r>":      "reload",
+		"<C-u>":      "half-page-up",
+		"<C-d>":      "half-page-down",
+		"<F1>":       "help",
+	},
+	InsertModeName: {
+		"<Enter>":    "split-line",
+		"<CR>":       "split-line",
+		"<Del>":      "delete-char-before",
+		"<BS>":       "delete-char-before",
+		"<C-c>":      "command-mode",
+		"<Tab>":      "insert-tab",
+		"<S-Tab>":    "dedent-line",
+		"<C-k>":      "insert-digraph",
+		"<C-x>=":     "insert-calc",
+		"<C-x><C-f>": "complete-path",
+	},
+	CommandModeName: {
+		"j":      "move-down",
+		"k":      "move-up",
+		"h":      "move-left",
+		"l":      "move-right",
+		"gj":     "display-line-down",
+		"gk":     "display-line-up",
+		"i":      "insert-mode",
+		"R":      "replace-mode",
+		"r":      "replace-char",
+		"o":      "open-line-below",
+		"O":      "open-line-above",
+		"a":      "append",
+		"A":      "append-line-end",
+		"I":      "insert-line-start",
+		"0":      "line-start",
+		"$":      "line-end",
+		"G":      "last-line",
+		"gg":     "first-line",
+		"^":      "first-non-blank",
+		"_":      "first-non-blank-line",
+		"D":      "delete-line",
+		"C":      "clear-line",
+		"gcc":    "toggle-comment",
+		"w":      "word",
+		"b":      "back-word",
+		":":      "command-line",
+		"n":      "find-next",
+		"N":      "find-prev",
+		"*":      "search-word",
+		"#":      "search-word-back",
+		"%":      "match-bracket",
+		"}":      "next-paragraph",
+		"{":      "prev-paragraph",
+		")":      "next-sentence",
+		"(":      "prev-sentence",
+		"]]":     "next-heading",
+		"[[":     "prev-heading",
+		"gO":     "outline",
+		"gf":     "go-to-file",
+		"gF":     "go-to-file-line",
+		"gx":     "open-url",
+		"]c":     "next-hunk",
+		"[c":     "prev-hunk",
+		"]u":     "next-change",
+		"[u":     "prev-change",
+		"]x":     "next-conflict",
+		"[x":     "prev-conflict",
+		"W":      "next-cell",
+		"g<C-g>": "count",
+		"B":      "prev-cell",
+		"y":      "yank",
+		"d":      "delete",
+		"c":      "change",
+		"gu":     "lowercase",
+		"gU":     "uppercase",
+		"g~":     "togglecase",
+		">":      "indent",
+		"<lt>":   "dedent",
+		"u":      "undo",
+		"U":      "redo",
+		"p":      "paste",
+		"P":      "paste-before",
+		"<C-p>":  "paste-history",
+
+		// Locations in the output buffer
+		"<Enter>": "open-location",
+		"<CR>":    "open-location",
+	},
+}
+
+// copyBindings returns a copy of the default bindings of the keymap.
+func copyBindings(name KeyMapName) map[string]string {
+	bindings := make(map[string]string, len(defaultBindings[name]))
+	for keys, action := range defaultBindings[name] {
+		bindings[keys] = action
+	}
+
+	return bindings
+}
+
+// newKeymaps returns the keymaps of each mode with their default bindings.
+func newKeymaps() map[KeyMapName]KeyMap {
+	return map[KeyMapName]KeyMap{
+		BasicMapName: {
+			Name:     BasicMapName,
+			Bindings: copyBindings(BasicMapName),
+		},
+		InsertModeName: {
+			Name:     InsertModeName,
+			Bindings: copyBindings(InsertModeName),
+			Handler:  insertModeHandler,
+		},
+		CommandModeName: {
+			Name:     CommandModeName,
+			Bindings: copyBindings(CommandModeName),
+		},
+	}
+}
+
+// resetBindings restores the default bindings of every keymap. The maps are
+// changed in place since copies of the keymaps share them.
+func (e *Editor) resetBindings() {
+	for name, keymap := range e.keymaps {
+		for keys := range keymap.Bindings {
+			delete(keymap.Bindings, keys)
+		}
+		for keys, action := range defaultBindings[name] {
+			keymap.Bindings[keys] = action
+		}
+	}
+
+	e.applyPluginBindings()
+}
+
+// findKeyMap returns the keymap with the given name, ignoring case.
+func (e *Editor) findKeyMap(name string) (KeyMap, error) {
+	for n, keymap := range e.keymaps {
+		if strings.EqualFold(string(n), name) {
+			return keymap, nil
+		}
+	}
+
+	return KeyMap{}, fmt.Errorf("unknown keymap: %s", name)
+}
+
+// bindings returns a copy of the bindings of the named keymap.
+func (e *Editor) bindings(keymap KeyMapName) map[string]string {
+	bindings := make(map[string]string, len(e.keymaps[keymap].Bindings))
+	for keys, action := range e.keymaps[keymap].Bindings {
+		bindings[keys] = action
+	}
+
+	return bindings
+}
+
+// leader returns the key that "<leader>" stands for in mappings.
+func (e *Editor) leader() (Key, error) {
+	keys, err := parseKeys(e.cfg.Leader, 0)
+	if err != nil || len(keys) != 1 {
+		return 0, fmt.Errorf("leader must be a single key: %s", e.cfg.Leader)
+	}
+
+	return keys[0], nil
+}
+
+// Map binds keys, written in key notation, to the named action in a keymap.
+// "<leader>" is replaced by the current leader key, so changing the leader
+// only affects the mappings made afterwards.
+func (e *Editor) Map(keymap, keys, action string) error {
+	m, err := e.findKeyMap(keymap)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := e.action(action); !ok {
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	leader, err := e.leader()
+	if err != nil {
+		return err
+	}
+
+	keys, err = normalizeKeys(keys, leader)
+	if err != nil {
+		return err
+	}
+
+	m.Bindings[keys] = action
+	return nil
+}
+
+// Unmap removes the binding of keys from a keymap.
+func (e *Editor) Unmap(keymap, keys string) error {
+	m, err := e.findKeyMap(keymap)
+	if err != nil {
+		return err
+	}
+
+	leader, err := e.leader()
+	if err != nil {
+		return err
+	}
+
+	keys, err = normalizeKeys(keys, leader)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m.Bindings[keys]; !ok {
+		return fmt.Errorf("no such mapping: %s", keys)
+	}
+
+	delete(m.Bindings, keys)
+	return nil
+}
+
+// insertModeHandler inserts the printable keys that aren't bound to anything,
+// or types them over the text in replace mode.
+func insertModeHandler(e SDK, k Key) (bool, error) {
+	if !isPrintable(k) {
+		return true, nil
+	}
+
+	typeChar(e, rune(k))
+	return true, nil
+}
+
+// typeChar inserts c at the cursor, or types it over the text in replace
+// mode, and moves the cursor after it.
+func typeChar(e SDK, c rune) {
+	if e.CurrentMode() == ReplaceMode {
+		replaceChar(e, e.Y(), e.X(), c)
+	} else {
+		e.InsertChars(e.Y(), e.X(), c)
+	}
+	e.SetX(e.X() + 1)
+}
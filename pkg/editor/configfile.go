@@ -0,0 +1,122 @@
+package editor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// configPath returns the path of the config file, following the XDG base
+// directory specification.
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "jk", "config")
+}
+
+// loadConfig resets the options and key bindings to their defaults and then runs each line of
+// the config file as an ex command, e.g. "set tabstop=4". Empty lines and
+// lines starting with '#' are ignored, and a missing config file is not an
+// error. Every line is run even if an earlier one fails, and the first error
+// is returned. The Lua init script next to the config file is run last.
+func (e *Editor) loadConfig() error {
+	e.cfg = defaultDisplayConfig
+	e.filetypeCommands = nil
+	e.hooks = nil
+	e.resetBindings()
+	e.setColorscheme(defaultColorschemeName)
+	e.highlightRules = nil
+
+	e.applyingOptions++
+	defer func() { e.applyingOptions-- }()
+
+	// Whatever the config file sets is the base for the filetype settings
+	defer func() {
+		e.globalCfg = e.cfg
+		e.applyFiletypeOptions()
+		e.rehighlight()
+	}()
+
+	err := e.runConfigFile()
+	if luaErr := e.runInitScript(); luaErr != nil && err == nil {
+		err = luaErr
+	}
+
+	return err
+}
+
+// runConfigFile runs the lines of the config file, see loadConfig.
+func (e *Editor) runConfigFile() error {
+	path := configPath()
+	if len(path) == 0 {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var firstErr error
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := e.ExecCommand(line); err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "%s:%d", path, n)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	return firstErr
+}
+
+func configCommand(e SDK, r *Range, args string) error {
+	switch args {
+	case "reload":
+		if err := e.loadConfig(); err != nil {
+			return err
+		}
+
+		e.SetMessage("reloaded %s", configPath())
+	case "":
+		e.SetMessage("%s", configPath())
+	default:
+		return errors.Errorf("unknown config command: %s", args)
+	}
+
+	return nil
+}
+
+// filetypeCommand runs a command for each file of a filetype, e.g.
+// "filetype go set tabstop=4".
+func filetypeCommand(e SDK, r *Range, args string) error {
+	filetype, cmd, _ := strings.Cut(args, " ")
+	cmd = strings.TrimSpace(cmd)
+	if len(filetype) == 0 || len(cmd) == 0 {
+		return errors.New("usage: filetype <filetype> <command>")
+	}
+
+	e.addFiletypeCommand(filetype, cmd)
+	return nil
+}
@@ -0,0 +1,55 @@
+package editor
+
+import "unicode"
+
+// highlightKeys highlights the keys of a config format like JSON or YAML,
+// where sep separates each key from its value. Quoted keys are found
+// anywhere in the row, and unquoted ones at the start of the row, after any
+// YAML list marker. With tableHeaders, rows like "[section]" are
+// highlighted too.
+func highlightKeys(row *Row, runes []rune, sep rune, tableHeaders bool) {
+	start := 0
+	for start < len(runes) && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	if start+1 < len(runes) && runes[start] == '-' && runes[start+1] == ' ' {
+		start += 2
+	}
+
+	if start == len(runes) || row.hl[start] == hlComment || row.hl[start] == hlMlComment {
+		return
+	}
+
+	if tableHeaders && runes[start] == '[' {
+		for i := start; i < len(runes) && row.hl[i] != hlComment; i++ {
+			row.hl[i] = hlKeyword2
+		}
+		return
+	}
+
+	bare := row.hl[start] != hlString
+	for i := start; i < len(runes); i++ {
+		if runes[i] != sep || row.hl[i] != hlNormal {
+			continue
+		}
+
+		end := i
+		for end > start && unicode.IsSpace(runes[end-1]) {
+			end--
+		}
+
+		if end > start && row.hl[end-1] == hlString {
+			// Quoted key, e.g. "name": in JSON
+			for j := end - 1; j >= start && row.hl[j] == hlString; j-- {
+				row.hl[j] = hlKeyword1
+			}
+		} else if bare {
+			for j := start; j < end; j++ {
+				row.hl[j] = hlKeyword1
+			}
+		}
+
+		// Only the first separator can follow an unquoted key
+		bare = false
+	}
+}
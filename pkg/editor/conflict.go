@@ -0,0 +1,167 @@
+package editor
+
+import (
+	"errors"
+	"strings"
+)
+
+// conflict is a merge conflict in the text, given by the rows of its
+// markers:
+//
+//	<<<<<<< ours       start
+//	||||||| base       base, or -1 without diff3 style conflicts
+//	=======            sep
+//	>>>>>>> theirs     end
+type conflict struct {
+	start, base, sep, end int
+}
+
+// findConflicts returns the merge conflicts in the text, in order.
+func findConflicts(e SDK) []conflict {
+	var (
+		conflicts []conflict
+		cur       *conflict
+	)
+
+	for y := 0; y < e.NumRows(); y++ {
+		line := string(e.Row(y))
+		switch {
+		case strings.HasPrefix(line, "<<<<<<<"):
+			cur = &conflict{start: y, base: -1, sep: -1}
+		case cur == nil:
+		case strings.HasPrefix(line, "|||||||") && cur.sep == -1:
+			cur.base = y
+		case strings.HasPrefix(line, "=======") && cur.sep == -1:
+			cur.sep = y
+		case strings.HasPrefix(line, ">>>>>>>") && cur.sep != -1:
+			cur.end = y
+			conflicts = append(conflicts, *cur)
+			cur = nil
+		}
+	}
+
+	return conflicts
+}
+
+// conflictHighlight returns the highlight group for row y if it is part of
+// a conflict, or 0.
+func conflictHighlight(conflicts []conflict, y int) SyntaxHL {
+	for _, c := range conflicts {
+		switch {
+		case y < c.start || y > c.end:
+			continue
+		case y == c.start || y == c.base || y == c.sep || y == c.end:
+			return hlConflictMarker
+		case c.base != -1 && y > c.base && y < c.sep:
+			return hlConflictBase
+		case y < c.sep:
+			return hlConflictOurs
+		default:
+			return hlConflictTheirs
+		}
+	}
+
+	return 0
+}
+
+// updateConflicts finds the conflicts to highlight if the text changed.
+func (e *Editor) updateConflicts() {
+	if e.conflictsTick == e.changeTick && e.conflicts != nil {
+		return
+	}
+
+	e.conflicts = findConflicts(e)
+	if e.conflicts == nil {
+		e.conflicts = []conflict{}
+	}
+	e.conflictsTick = e.changeTick
+}
+
+// conflictAt returns the conflict containing row y.
+func conflictAt(e SDK, y int) (conflict, bool) {
+	for _, c := range findConflicts(e) {
+		if y >= c.start && y <= c.end {
+			return c, true
+		}
+	}
+
+	return conflict{}, false
+}
+
+// moveToConflict moves to the start of the next conflict, or the previous
+// one when n is -1.
+func moveToConflict(n int) func(e SDK) error {
+	return func(e SDK) error {
+		conflicts := findConflicts(e)
+
+		if n < 0 {
+			for i := len(conflicts) - 1; i >= 0; i-- {
+				if conflicts[i].start < e.Y() {
+					e.SetY(conflicts[i].start)
+					return nil
+				}
+			}
+		} else {
+			for _, c := range conflicts {
+				if c.start > e.Y() {
+					e.SetY(c.start)
+					return nil
+				}
+			}
+		}
+
+		e.SetMessage("no more conflicts")
+		return nil
+	}
+}
+
+// resolveConflict replaces the conflict under the cursor with one of its
+// sides, "ours" or "theirs", or "both" of them one after the other.
+func resolveConflict(e SDK, side string) error {
+	c, ok := conflictAt(e, e.Y())
+	if !ok {
+		return errors.New("no conflict under the cursor")
+	}
+
+	oursEnd := c.sep
+	if c.base != -1 {
+		oursEnd = c.base
+	}
+
+	var keep [][]rune
+	switch side {
+	case "ours":
+		keep = copyRows(e, c.start+1, oursEnd)
+	case "theirs":
+		keep = copyRows(e, c.sep+1, c.end)
+	case "both":
+		keep = append(copyRows(e, c.start+1, oursEnd), copyRows(e, c.sep+1, c.end)...)
+	default:
+		return errors.New("usage: conflict ours|theirs|both")
+	}
+
+	for y := c.end; y >= c.start; y-- {
+		e.DeleteRow(y)
+	}
+	for i, row := range keep {
+		e.InsertRow(c.start+i, row)
+	}
+
+	e.SetY(c.start)
+	e.SetX(0)
+	return nil
+}
+
+// copyRows returns copies of the rows from start up to end.
+func copyRows(e SDK, start, end int) [][]rune {
+	var rows [][]rune
+	for y := start; y < end; y++ {
+		rows = append(rows, append([]rune(nil), e.Row(y)...))
+	}
+
+	return rows
+}
+
+func conflictCommand(e SDK, r *Range, args string) error {
+	return resolveConflict(e, args)
+}
@@ -0,0 +1,121 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// crashCache is the kind of cached data describing the unsaved text of a
+// file that was saved when the editor crashed.
+const crashCache = "crash"
+
+// crashSnapshot is kept when the editor crashes with unsaved changes, which
+// are in the file at Path. That file is named after the time of the crash,
+// apart from the recovery file of "-z", so that neither replaces the other.
+type crashSnapshot struct {
+	Time time.Time
+	Path string
+	DisplaySettings
+}
+
+// saveCrashSnapshot writes the unsaved text to a file of its own, to be
+// offered back the next time the file is opened, and returns the path of
+// that file. It does nothing without unsaved changes, or for a secret file.
+func (e *Editor) saveCrashSnapshot() (path string, err error) {
+	if !e.modified || len(e.filename) == 0 || e.isSecret() {
+		return "", nil
+	}
+
+	// The editor is in whatever state made it crash, which might make it
+	// crash again
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	base, err := e.cachePath(crashCache, e.filename)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the last crash is offered back
+	var last crashSnapshot
+	if e.readCache(crashCache, e.filename, &last) == nil && len(last.Path) != 0 {
+		os.Remove(last.Path)
+	}
+
+	now := time.Now()
+	path = fmt.Sprintf("%s-%s.txt", base, now.Format("20060102-150405.000"))
+	if err := e.writeText(path); err != nil {
+		return "", err
+	}
+
+	err = e.writeCache(crashCache, e.filename, crashSnapshot{
+		Time: now,
+		Path: path,
+		DisplaySettings: DisplaySettings{
+			X:         e.cx,
+			Y:         e.cy,
+			RowOffset: e.rowOffset,
+			ColOffset: e.colOffset,
+			Modified:  true,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// crashError saves the unsaved changes when the editor panics with r, and
+// returns the error the panic is reported as, with the stack and where the
+// changes were saved.
+func (e *Editor) crashError(r interface{}, stack []byte) error {
+	msg := fmt.Sprintf("%+v\nstack: %s", r, stack)
+
+	if path, err := e.saveCrashSnapshot(); err != nil {
+		msg += fmt.Sprintf("\ncould not save the unsaved changes: %s", err)
+	} else if len(path) != 0 {
+		msg += fmt.Sprintf("\nunsaved changes saved to %s, open %s again to restore them", path, e.filename)
+	}
+
+	return errors.New(msg)
+}
+
+// offerCrashRecovery asks whether to restore the text saved when the editor
+// last crashed while editing the current file, if it did.
+func (e *Editor) offerCrashRecovery() {
+	var snap crashSnapshot
+	if err := e.readCache(crashCache, e.filename, &snap); err != nil || len(snap.Path) == 0 {
+		return
+	}
+
+	e.Prompt(fmt.Sprintf("jk crashed on %s with unsaved changes to %s, restore them? (y/n) ",
+		snap.Time.Format("Jan 2 15:04"), e.filename), func(k Key) (string, bool) {
+		switch k {
+		case Key('y'), Key('Y'):
+			e.removeCache(crashCache, e.filename)
+			if err := e.restoreText(snap.Path); err != nil {
+				e.SetMessage("recovery: %s", err)
+				return "", true
+			}
+
+			e.cx, e.cy = snap.X, snap.Y
+			e.rowOffset, e.colOffset = snap.RowOffset, snap.ColOffset
+			e.SetMessage("restored the unsaved changes")
+			return "", true
+		case Key('n'), Key('N'), keyEscape, Key(ctrl('q')):
+			e.removeCache(crashCache, e.filename)
+			os.Remove(snap.Path)
+
+			e.SetMessage("")
+			return "", true
+		}
+
+		return "", false
+	})
+}
@@ -0,0 +1,66 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCrashSnapshot(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   []string
+	}{
+		{"y", []string{"crashed", "saved"}},
+		{"n", []string{"saved"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			e := newTestEditor(t)
+			file := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(file, []byte("saved\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.OpenFile(file); err != nil {
+				t.Fatal(err)
+			}
+
+			pressKeys(t, e, "Ocrashed<C-c>")
+			path, err := e.saveCrashSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recovery, _ := e.recoveryPath(); path == recovery {
+				t.Fatalf("the crash snapshot is in the recovery file %s", path)
+			}
+
+			// A reload with other changes doesn't touch the snapshot
+			pressKeys(t, e, "Oreloaded<C-c>")
+			if err := e.saveSession(); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.recoverText(); err != nil {
+				t.Fatal(err)
+			}
+
+			e2, err := New(headlessTerminal{}, Options{CacheDir: e.opts.CacheDir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e2.OpenFile(file); err != nil {
+				t.Fatal(err)
+			}
+			e2.offerCrashRecovery()
+			pressKeys(t, e2, tt.answer)
+
+			if got := rowStrings(e2.rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("the snapshot is still there: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// maxColumnWidth limits how far a column is padded, so that one long cell
+// doesn't push the rest of the table off the screen.
+const maxColumnWidth = 40
+
+// columnLayout lines up the cells of delimited files by padding them on the
+// screen. The text itself is left as it is.
+type columnLayout struct {
+	delim rune
+	// widths of the columns, as of changeTick tick and the ambiguous width
+	// runes being wide or not
+	widths    []int
+	tick      int
+	eastAsian bool
+	// the editor's widths of runes
+	width *runewidth.Condition
+}
+
+// splitCells returns the start and end of each cell of a row, without the
+// delimiters. Delimiters within double quotes are part of the cell.
+func splitCells(chars []rune, delim rune) [][2]int {
+	var (
+		cells  [][2]int
+		start  int
+		quoted bool
+	)
+
+	for i, r := range chars {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delim && !quoted:
+			cells = append(cells, [2]int{start, i})
+			start = i + 1
+		}
+	}
+
+	return append(cells, [2]int{start, len(chars)})
+}
+
+// columnWidths returns the width of the widest cell of each column.
+func columnWidths(rows []*Row, delim rune, cond *runewidth.Condition) []int {
+	widths := []int{}
+	for _, row := range rows {
+		for c, cell := range splitCells(row.chars, delim) {
+			width := cond.StringWidth(string(row.chars[cell[0]:cell[1]]))
+			if width > maxColumnWidth {
+				width = maxColumnWidth
+			}
+
+			if c == len(widths) {
+				widths = append(widths, width)
+			} else if width > widths[c] {
+				widths[c] = width
+			}
+		}
+	}
+
+	return widths
+}
+
+// layout returns the render string of a row, with each cell padded to the
+// width of its column, and the index in it of each rune of the row. The
+// index has an extra entry for the end of the row.
+func (l *columnLayout) layout(chars []rune) (string, []int) {
+	var b strings.Builder
+	index := make([]int, len(chars)+1)
+	n := 0
+
+	cells := splitCells(chars, l.delim)
+	for c, cell := range cells {
+		if c > 0 {
+			// tabs take up a single column between the cells
+			index[cell[0]-1] = n
+			if l.delim == '\t' {
+				b.WriteRune(' ')
+			} else {
+				b.WriteRune(l.delim)
+			}
+			n++
+		}
+
+		width := 0
+		for i := cell[0]; i < cell[1]; i++ {
+			r := chars[i]
+			if r == '\t' {
+				r = ' '
+			}
+
+			index[i] = n
+			b.WriteRune(r)
+			n++
+			width += l.width.RuneWidth(r)
+		}
+
+		// Don't leave trailing spaces after the last cell
+		if c == len(cells)-1 || c >= len(l.widths) {
+			continue
+		}
+
+		for ; width < l.widths[c]; width++ {
+			b.WriteRune(' ')
+			n++
+		}
+	}
+
+	index[len(chars)] = n
+	return b.String(), index
+}
+
+// visualCols returns the column on the screen of each rune of the row, plus
+// one for the end of the row.
+func (l *columnLayout) visualCols(chars []rune) []int {
+	render, index := l.layout(chars)
+	runes := []rune(render)
+
+	cols := make([]int, len(index))
+	col, j := 0, 0
+	for i, idx := range index {
+		for ; j < idx; j++ {
+			col += l.width.RuneWidth(runes[j])
+		}
+		cols[i] = col
+	}
+
+	return cols
+}
+
+// highlight colors every other column of the row, leaving the syntax
+// highlighting of the rest alone.
+func (l *columnLayout) highlight(row *Row) {
+	_, index := l.layout(row.chars)
+	for c, cell := range splitCells(row.chars, l.delim) {
+		if c%2 == 0 {
+			continue
+		}
+
+		for i := index[cell[0]]; i < index[cell[1]]; i++ {
+			if row.hl[i] == hlNormal {
+				row.hl[i] = hlColumn
+			}
+		}
+	}
+}
+
+// updateColumns lines up the columns again if the text or filetype
+// changed, redoing the render strings when the widths of the columns did.
+func (e *Editor) updateColumns() {
+	delim := e.delimiter()
+	if delim == 0 {
+		if e.columns != nil {
+			e.columns = nil
+			for i := range e.rows {
+				e.updateRow(i)
+			}
+		}
+
+		return
+	}
+
+	eastAsian := e.width.EastAsianWidth
+	if e.columns != nil && e.columns.delim == delim && e.columns.tick == e.changeTick && e.columns.eastAsian == eastAsian {
+		return
+	}
+
+	widths := columnWidths(e.rows, delim, e.width)
+	if e.columns != nil && e.columns.delim == delim && equalInts(e.columns.widths, widths) {
+		e.columns.tick = e.changeTick
+		e.columns.eastAsian = eastAsian
+		return
+	}
+
+	e.columns = &columnLayout{delim: delim, widths: widths, tick: e.changeTick, eastAsian: eastAsian, width: e.width}
+	for i := range e.rows {
+		e.updateRow(i)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// delimiter returns the character separating the cells of the file, or 0
+// if it isn't tabular data.
+func (e *Editor) delimiter() rune {
+	if e.syntax == nil {
+		return 0
+	}
+
+	return e.syntax.delimiter
+}
+
+// moveToCell returns an action moving the cursor to the start of the next
+// cell, or the previous one if n is negative, carrying on onto the
+// neighbouring rows. In files that aren't delimited it moves by words, like
+// "W" and "B" in vi.
+func moveToCell(n int) func(e SDK) error {
+	return func(e SDK) error {
+		delim := e.delimiter()
+		if delim == 0 {
+			if n > 0 {
+				e.SetX(e.Word())
+			} else {
+				e.SetX(e.BackWord())
+			}
+			return nil
+		}
+
+		x, y := e.X(), e.Y()
+		cells := splitCells(e.Row(y), delim)
+		c := 0
+		for c+1 < len(cells) && x > cells[c][1] {
+			c++
+		}
+
+		if n > 0 {
+			if c+1 < len(cells) {
+				e.SetX(cells[c+1][0])
+			} else if y+1 < e.NumRows() {
+				e.SetY(y + 1)
+				e.SetX(0)
+			}
+
+			return nil
+		}
+
+		switch {
+		case x > cells[c][0]:
+			e.SetX(cells[c][0])
+		case c > 0:
+			e.SetX(cells[c-1][0])
+		case y > 0:
+			cells = splitCells(e.Row(y-1), delim)
+			e.SetY(y - 1)
+			e.SetX(cells[len(cells)-1][0])
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCellMotions(t *testing.T) {
+	tests := []struct {
+		name     string
+		filetype string
+		lines    []string
+		keys     string
+		want     []string
+		x, y     int
+	}{
+		{"next cell", "csv", []string{"a b,c,d"}, "W", []string{"a b,c,d"}, 4, 0},
+		{"cells with a count", "csv", []string{"a,b,c", "d"}, "3W", []string{"a,b,c", "d"}, 0, 1},
+		{"previous cell", "tsv", []string{"a\tb c\td"}, "$B", []string{"a\tb c\td"}, 6, 0},
+		{"delete a cell", "csv", []string{"a b,c"}, "dW", []string{"c"}, 0, 0},
+		{"next word", "", []string{"a,b c,d e"}, "W", []string{"a,b c,d e"}, 4, 0},
+		{"words with a count", "", []string{"a,b c,d e"}, "2W", []string{"a,b c,d e"}, 8, 0},
+		{"previous word", "", []string{"a,b c,d e"}, "2WB", []string{"a,b c,d e"}, 4, 0},
+		{"delete a word", "", []string{"a,b c,d"}, "dW", []string{"c,d"}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.lines...)
+			if err := e.SetFiletype(tt.filetype); err != nil {
+				t.Fatal(err)
+			}
+			pressKeys(t, e, tt.keys)
+
+			if got := rowStrings(e.rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if e.cx != tt.x || e.cy != tt.y {
+				t.Errorf("cursor at %d, %d, want %d, %d", e.cx, e.cy, tt.x, tt.y)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package editor
+
+// DiffHunk is a run of changed lines between an old and a new version of a
+// file: OldLen lines from OldStart in the old version were replaced by
+// NewLen lines from NewStart in the new one. Either length may be zero for
+// pure additions and deletions.
+type DiffHunk struct {
+	OldStart, OldLen int
+	NewStart, NewLen int
+}
+
+// maxDiffEdits limits the work done by diffLines. Files that differ by more
+// edits than this are reported as one big change.
+const maxDiffEdits = 4000
+
+// diffLines returns the hunks that turn a into b, using Myers' algorithm.
+func diffLines(a, b []string) []DiffHunk {
+	// The lines in common at either end don't need the full algorithm
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	a, b = a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	ops, ok := myers(a, b)
+	if !ok {
+		return []DiffHunk{{pre, len(a), pre, len(b)}}
+	}
+
+	// Group the runs of edits between equal lines into hunks
+	var (
+		hunks []DiffHunk
+		cur   *DiffHunk
+		x, y  = pre, pre
+	)
+	for _, op := range ops {
+		if op == diffEqual {
+			cur = nil
+			x++
+			y++
+			continue
+		}
+
+		if cur == nil {
+			hunks = append(hunks, DiffHunk{OldStart: x, NewStart: y})
+			cur = &hunks[len(hunks)-1]
+		}
+
+		if op == diffDelete {
+			cur.OldLen++
+			x++
+		} else {
+			cur.NewLen++
+			y++
+		}
+	}
+
+	return hunks
+}
+
+type diffOp uint8
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// myers returns the shortest edit script turning a into b. ok is false if it
+// takes more than maxDiffEdits edits.
+//
+// It is the linear space version of the algorithm: the middle snake of the
+// shortest path splits it into two smaller problems, so only the furthest
+// points reached on each diagonal are kept, rather than those of every
+// round.
+func myers(a, b []string) (ops []diffOp, ok bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return myersSplit(nil, a, b, 0), true
+	}
+
+	x, y, u, v, d, ok := middleSnake(a, b, maxDiffEdits)
+	if !ok {
+		return nil, false
+	}
+
+	return myersAround(nil, a, b, x, y, u, v, d), true
+}
+
+// myersSplit appends the edits turning a into b to ops. They are known to
+// take at most limit edits.
+func myersSplit(ops []diffOp, a, b []string, limit int) []diffOp {
+	switch {
+	case len(a) == 0:
+		for range b {
+			ops = append(ops, diffInsert)
+		}
+		return ops
+	case len(b) == 0:
+		for range a {
+			ops = append(ops, diffDelete)
+		}
+		return ops
+	}
+
+	x, y, u, v, d, _ := middleSnake(a, b, limit)
+	return myersAround(ops, a, b, x, y, u, v, d)
+}
+
+// myersAround appends the edits turning a into b to ops, given the middle
+// snake from (x, y) to (u, v) of a path with d edits.
+func myersAround(ops []diffOp, a, b []string, x, y, u, v, d int) []diffOp {
+	if d <= 1 {
+		// One line was added or removed, after the lines in common
+		i := 0
+		for i < len(a) && i < len(b) && a[i] == b[i] {
+			ops = append(ops, diffEqual)
+			i++
+		}
+
+		n := len(a)
+		switch {
+		case len(a) > len(b):
+			ops = append(ops, diffDelete)
+			n = len(b)
+		case len(a) < len(b):
+			ops = append(ops, diffInsert)
+		}
+
+		for ; i < n; i++ {
+			ops = append(ops, diffEqual)
+		}
+		return ops
+	}
+
+	// Each side of the snake takes fewer edits than the whole
+	ops = myersSplit(ops, a[:x], b[:y], d)
+	for i := x; i < u; i++ {
+		ops = append(ops, diffEqual)
+	}
+	return myersSplit(ops, a[u:], b[v:], d)
+}
+
+// middleSnake finds the snake in the middle of a shortest path from the
+// start of a and b to their ends, by searching forward from the start and
+// backward from the end until the two meet. It returns the snake from (x, y)
+// to (u, v) and the number of edits d of the whole path. ok is false if that
+// is more than limit.
+func middleSnake(a, b []string, limit int) (x, y, u, v, d int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+
+	rounds := (n + m + 1) / 2
+	if r := (limit + 1) / 2; r < rounds {
+		rounds = r
+	}
+
+	// vf[k+off] is the furthest x reached on diagonal k going forward, and
+	// vb[k+off] the same going backward, counting from the ends
+	off := rounds + 1
+	vf := make([]int, 2*rounds+3)
+	vb := make([]int, 2*rounds+3)
+
+	for r := 0; r <= rounds; r++ {
+		for k := -r; k <= r; k += 2 {
+			if k == -r || (k != r && vf[k-1+off] < vf[k+1+off]) {
+				x = vf[k+1+off]
+			} else {
+				x = vf[k-1+off] + 1
+			}
+
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			vf[k+off] = u
+
+			// Backward diagonal delta-k is forward diagonal k
+			if kb := delta - k; odd && kb >= -(r-1) && kb <= r-1 && u+vb[kb+off] >= n {
+				d = 2*r - 1
+				return x, y, u, v, d, d <= limit
+			}
+		}
+
+		for k := -r; k <= r; k += 2 {
+			var bx int
+			if k == -r || (k != r && vb[k-1+off] < vb[k+1+off]) {
+				bx = vb[k+1+off]
+			} else {
+				bx = vb[k-1+off] + 1
+			}
+
+			by := bx - k
+			bu, bv := bx, by
+			for bu < n && bv < m && a[n-1-bu] == b[m-1-bv] {
+				bu++
+				bv++
+			}
+			vb[k+off] = bu
+
+			if kf := delta - k; !odd && kf >= -r && kf <= r && bu+vf[kf+off] >= n {
+				d = 2 * r
+				return n - bu, m - bv, n - bx, m - by, d, d <= limit
+			}
+		}
+	}
+
+	return 0, 0, 0, 0, 0, false
+}
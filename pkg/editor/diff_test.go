@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []DiffHunk
+	}{
+		{"same", "a b c", "a b c", nil},
+		{"empty", "", "", nil},
+		{"all added", "", "a b", []DiffHunk{{0, 0, 0, 2}}},
+		{"all removed", "a b", "", []DiffHunk{{0, 2, 0, 0}}},
+		{"added", "a c", "a b c", []DiffHunk{{1, 0, 1, 1}}},
+		{"removed", "a b c", "a c", []DiffHunk{{1, 1, 1, 0}}},
+		{"changed", "a b c", "a x c", []DiffHunk{{1, 1, 1, 1}}},
+		{"two hunks", "a b c d e", "x b c d y", []DiffHunk{{0, 1, 0, 1}, {4, 1, 4, 1}}},
+		{"moved", "a b c", "b c a", []DiffHunk{{0, 1, 0, 0}, {3, 0, 2, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(strings.Fields(tt.a), strings.Fields(tt.b)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// lcsLen returns the length of the longest common subsequence of a and b.
+func lcsLen(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// TestMyersRandom checks that the edits turn a into b, and that there are
+// no more of them than needed.
+func TestMyersRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rnd.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rnd.Intn(3)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := random(), random()
+		ops, ok := myers(a, b)
+		if !ok {
+			t.Fatalf("%q -> %q: too many edits", a, b)
+		}
+
+		var got []string
+		x, y, edits := 0, 0, 0
+		for _, op := range ops {
+			switch op {
+			case diffEqual:
+				if a[x] != b[y] {
+					t.Fatalf("%q -> %q: %q and %q aren't equal", a, b, a[x], b[y])
+				}
+				got = append(got, a[x])
+				x++
+				y++
+			case diffDelete:
+				x++
+				edits++
+			case diffInsert:
+				got = append(got, b[y])
+				y++
+				edits++
+			}
+		}
+
+		if x != len(a) || !reflect.DeepEqual(got, b) && len(b) > 0 {
+			t.Fatalf("%q -> %q: got %q", a, b, got)
+		}
+		if want := len(a) + len(b) - 2*lcsLen(a, b); edits != want {
+			t.Fatalf("%q -> %q: %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffTooManyEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, "a"+strconv.Itoa(i))
+		b = append(b, "b"+strconv.Itoa(i))
+	}
+
+	want := []DiffHunk{{0, len(a), 0, len(b)}}
+	if got := diffLines(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func BenchmarkDiffLines(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	old := make([]string, 100000)
+	for i := range old {
+		old[i] = strconv.Itoa(rnd.Int())
+	}
+
+	// About a thousand lines changed throughout the file
+	new := append([]string(nil), old...)
+	for i := 0; i < 1000; i++ {
+		new[rnd.Intn(len(new))] = "changed"
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		diffLines(old, new)
+	}
+}
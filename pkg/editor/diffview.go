@@ -0,0 +1,269 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// diffLine is a screen line of the side-by-side diff. A side is nil where
+// the other side has lines that it doesn't, e.g. for added lines.
+type diffLine struct {
+	left, right []rune
+	hl          SyntaxHL
+}
+
+// diffView shows two versions of a file side by side, with the lines lined
+// up and the changes highlighted.
+type diffView struct {
+	leftName, rightName string
+
+	lines []diffLine
+	// index into lines of the start of each hunk
+	hunks  []int
+	offset int
+}
+
+// newDiffView lines up the old and new lines using the hunks between them.
+func newDiffView(old, new []string) *diffView {
+	v := &diffView{}
+	x, y := 0, 0
+
+	equal := func(n int) {
+		for ; n > 0; n-- {
+			v.lines = append(v.lines, diffLine{[]rune(old[x]), []rune(new[y]), hlNormal})
+			x++
+			y++
+		}
+	}
+
+	for _, h := range diffLines(old, new) {
+		equal(h.OldStart - x)
+		v.hunks = append(v.hunks, len(v.lines))
+
+		hl := hlDiffChange
+		switch {
+		case h.OldLen == 0:
+			hl = hlDiffAdd
+		case h.NewLen == 0:
+			hl = hlDiffDelete
+		}
+
+		for i := 0; i < h.OldLen || i < h.NewLen; i++ {
+			line := diffLine{hl: hl}
+			if i < h.OldLen {
+				line.left = []rune(old[x])
+				x++
+			}
+			if i < h.NewLen {
+				line.right = []rune(new[y])
+				y++
+			}
+
+			v.lines = append(v.lines, line)
+		}
+	}
+	equal(len(old) - x)
+
+	return v
+}
+
+// showDiff shows the differences between the text and the file at path
+// side by side, or with the file on disk if path is empty.
+func (e *Editor) showDiff(path string) error {
+	if len(path) == 0 {
+		path = e.filename
+	}
+	if len(path) == 0 {
+		return errors.New("no file to compare with")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	old := strings.Split(string(data), "\n")
+	if len(old) > 0 && old[len(old)-1] == "" {
+		old = old[:len(old)-1]
+	}
+
+	lines := make([]string, len(e.rows))
+	for i, row := range e.rows {
+		lines[i] = string(row.chars)
+	}
+
+	v := newDiffView(old, lines)
+	v.leftName, v.rightName = path, "buffer"
+	if len(v.hunks) == 0 {
+		e.SetMessage("no differences")
+		return nil
+	}
+
+	e.showDiffView(v)
+	return nil
+}
+
+func (e *Editor) showDiffView(v *diffView) {
+	e.diffView = v
+
+	var pending Key
+	e.Prompt("-- ]c/[c for changes, q to quit --", func(k Key) (string, bool) {
+		prev := pending
+		pending = 0
+
+		switch {
+		case prev == ']' && k == 'c':
+			v.jump(1)
+		case prev == '[' && k == 'c':
+			v.jump(-1)
+		case k == ']' || k == '[':
+			pending = k
+		case k == 'j' || k == keyArrowDown || k == keyEnter || k == keyCarriageReturn:
+			v.scroll(1, e.screenRows-1)
+		case k == 'k' || k == keyArrowUp:
+			v.scroll(-1, e.screenRows-1)
+		case k == ' ' || k == keyPageDown || k == Key(ctrl('d')):
+			v.scroll(e.screenRows-1, e.screenRows-1)
+		case k == 'b' || k == keyPageUp || k == Key(ctrl('u')):
+			v.scroll(-(e.screenRows - 1), e.screenRows-1)
+		case k == 'q' || k == keyEscape || k == Key(ctrl('q')):
+			e.diffView = nil
+			e.SetMessage("")
+			return "", true
+		}
+
+		return "", false
+	})
+}
+
+// scroll moves the view by n lines, where rows lines fit on the screen.
+func (v *diffView) scroll(n, rows int) {
+	v.offset += n
+	if last := len(v.lines) - rows; v.offset > last {
+		v.offset = last
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+}
+
+// jump scrolls to the next hunk below the top of the screen, or the
+// previous one when n is -1.
+func (v *diffView) jump(n int) {
+	if n < 0 {
+		for i := len(v.hunks) - 1; i >= 0; i-- {
+			if v.hunks[i] < v.offset {
+				v.offset = v.hunks[i]
+				return
+			}
+		}
+	} else {
+		for _, h := range v.hunks {
+			if h > v.offset {
+				v.offset = h
+				return
+			}
+		}
+	}
+}
+
+// drawDiffView draws both sides of the diff with a header naming them. The
+// changed part of changed lines is highlighted.
+func (e *Editor) drawDiffView(w io.Writer) {
+	v := e.diffView
+	width := (e.screenCols - 1) / 2
+
+	header := e.width.FillRight(e.width.Truncate(v.leftName, width, "..."), width) +
+		"|" + e.width.Truncate(v.rightName, e.screenCols-width-1, "...")
+	setStyle(w, e.color(hlStatusBar))
+	w.Write([]byte(e.width.FillRight(header, e.screenCols)))
+	clearFormatting(w)
+	w.Write([]byte("\r\n"))
+
+	for y := 0; y < e.screenRows-1; y++ {
+		if i := y + v.offset; i < len(v.lines) {
+			line := v.lines[i]
+			left, right := line.left, line.right
+
+			lhl, rhl := line.hl, line.hl
+			var start, lend, rend int
+			if line.hl == hlDiffChange && left != nil && right != nil {
+				start, lend, rend = changedRunes(left, right)
+			}
+
+			e.drawDiffSide(w, left, lhl, start, lend, width)
+			w.Write([]byte("|"))
+			e.drawDiffSide(w, right, rhl, start, rend, e.screenCols-width-1)
+		} else {
+			w.Write([]byte("~"))
+		}
+
+		w.Write([]byte(ClearLineCode))
+		w.Write([]byte("\r\n"))
+	}
+}
+
+// drawDiffSide draws one side of a line of the diff in width columns, with
+// the runes from start to end highlighted as changed text. A nil side is
+// drawn as filler.
+func (e *Editor) drawDiffSide(w io.Writer, chars []rune, hl SyntaxHL, start, end, width int) {
+	if chars == nil {
+		setStyle(w, e.color(hlLineNumber))
+		w.Write([]byte(strings.Repeat("-", width)))
+		clearFormatting(w)
+		return
+	}
+
+	var (
+		b   strings.Builder
+		hls []SyntaxHL
+	)
+	for i, r := range chars {
+		h := hl
+		if i >= start && i < end {
+			h = hlDiffText
+		}
+
+		// Expand tabs like the text area does
+		if r == '\t' {
+			n := e.cfg.Tabstop - e.width.StringWidth(b.String())%e.cfg.Tabstop
+			b.WriteString(strings.Repeat(" ", n))
+			for ; n > 0; n-- {
+				hls = append(hls, h)
+			}
+			continue
+		}
+
+		b.WriteRune(r)
+		hls = append(hls, h)
+	}
+
+	line := e.width.Truncate(b.String(), width, "")
+	e.drawLine(w, line, hls)
+	w.Write([]byte(strings.Repeat(" ", width-e.width.StringWidth(line))))
+}
+
+// changedRunes returns the part of a and b that differs, between the common
+// prefix and suffix: a[start:aend] was replaced by b[start:bend].
+func changedRunes(a, b []rune) (start, aend, bend int) {
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+
+	aend, bend = len(a), len(b)
+	for aend > start && bend > start && a[aend-1] == b[bend-1] {
+		aend--
+		bend--
+	}
+
+	return start, aend, bend
+}
+
+// diffCommand shows the changes side by side: "diff" compares the text with
+// the file on disk and "diff <file>" with another file.
+func diffCommand(e SDK, r *Range, args string) error {
+	return e.showDiff(args)
+}
@@ -0,0 +1,127 @@
+package editor
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Characters that can't be typed on the keyboard can be entered as digraphs,
+// two keys typed after Ctrl-K in insert mode, mostly following RFC 1345 like
+// vim, or by their code point with ":insert-unicode".
+
+// digraphs are the characters typed by each pair of keys. A pair that isn't
+// in the table is also looked up the other way round.
+var digraphs = map[string]rune{
+	// Arrows and symbols
+	"<-": '←', "->": '→', "-!": '↑', "-v": '↓', "<>": '↔', "UD": '↕',
+	"<=": '⇐', "=>": '⇒', "==": '⇔',
+	"oo": '•', "Sb": '∙', ".M": '·', ",.": '…', "OK": '✓', "XX": '✗',
+	"+-": '±', "*X": '×', "-:": '÷', "!=": '≠', "=<": '≤', ">=": '≥',
+	"?2": '≈', "=3": '≡', "00": '∞', "RT": '√', "FA": '∀', "TE": '∃',
+	"(-": '∈', "AN": '∧', "OR": '∨', "dP": '∂', "DE": '∆', "NB": '∇',
+	"DG": '°', "My": 'µ', "SE": '§', "PI": '¶', "Co": '©', "Rg": '®',
+	"TM": '™', "Eu": '€', "Pd": '£', "Ye": '¥', "Ct": '¢', "NS": ' ',
+	"-N": '–', "-M": '—', "'6": '‘', "'9": '’', "\"6": '“', "\"9": '”',
+	"<<": '«', ">>": '»', "!I": '¡', "?I": '¿', "1S": '¹', "2S": '²',
+	"3S": '³', "12": '½', "14": '¼', "34": '¾',
+
+	// Greek
+	"a*": 'α', "b*": 'β', "g*": 'γ', "d*": 'δ', "e*": 'ε', "z*": 'ζ',
+	"y*": 'η', "h*": 'θ', "i*": 'ι', "k*": 'κ', "l*": 'λ', "m*": 'μ',
+	"n*": 'ν', "c*": 'ξ', "o*": 'ο', "p*": 'π', "r*": 'ρ', "s*": 'σ',
+	"*s": 'ς', "t*": 'τ', "u*": 'υ', "f*": 'φ', "x*": 'χ', "q*": 'ψ',
+	"w*": 'ω', "A*": 'Α', "B*": 'Β', "G*": 'Γ', "D*": 'Δ', "E*": 'Ε',
+	"Z*": 'Ζ', "Y*": 'Η', "H*": 'Θ', "I*": 'Ι', "K*": 'Κ', "L*": 'Λ',
+	"M*": 'Μ', "N*": 'Ν', "C*": 'Ξ', "O*": 'Ο', "P*": 'Π', "R*": 'Ρ',
+	"S*": 'Σ', "T*": 'Τ', "U*": 'Υ', "F*": 'Φ', "X*": 'Χ', "Q*": 'Ψ',
+	"W*": 'Ω',
+
+	// Latin letters with accents
+	"a'": 'á', "e'": 'é', "i'": 'í', "o'": 'ó', "u'": 'ú', "y'": 'ý',
+	"A'": 'Á', "E'": 'É', "I'": 'Í', "O'": 'Ó', "U'": 'Ú', "Y'": 'Ý',
+	"a!": 'à', "e!": 'è', "i!": 'ì', "o!": 'ò', "u!": 'ù',
+	"A!": 'À', "E!": 'È', "I!": 'Ì', "O!": 'Ò', "U!": 'Ù',
+	"a>": 'â', "e>": 'ê', "i>": 'î', "o>": 'ô', "u>": 'û',
+	"A>": 'Â', "E>": 'Ê', "I>": 'Î', "O>": 'Ô', "U>": 'Û',
+	"a:": 'ä', "e:": 'ë', "i:": 'ï', "o:": 'ö', "u:": 'ü', "y:": 'ÿ',
+	"A:": 'Ä', "E:": 'Ë', "I:": 'Ï', "O:": 'Ö', "U:": 'Ü',
+	"a?": 'ã', "n?": 'ñ', "o?": 'õ', "A?": 'Ã', "N?": 'Ñ', "O?": 'Õ',
+	"aa": 'å', "AA": 'Å', "ae": 'æ', "AE": 'Æ', "o/": 'ø', "O/": 'Ø',
+	"c,": 'ç', "C,": 'Ç', "ss": 'ß', "oe": 'œ', "OE": 'Œ',
+}
+
+// lookupDigraph returns the character of the digraph typed as a then b.
+func lookupDigraph(a, b rune) (rune, bool) {
+	if r, ok := digraphs[string([]rune{a, b})]; ok {
+		return r, true
+	}
+
+	r, ok := digraphs[string([]rune{b, a})]
+	return r, ok
+}
+
+// insertDigraph waits for the two keys of a digraph and types its
+// character.
+func insertDigraph(e SDK) error {
+	e.awaitKey(func(a Key) error {
+		if !isPrintable(a) {
+			return nil
+		}
+
+		e.SetMessage("Digraph: %c", rune(a))
+		e.awaitKey(func(b Key) error {
+			e.SetMessage("")
+			if !isPrintable(b) {
+				return nil
+			}
+
+			r, ok := lookupDigraph(rune(a), rune(b))
+			if !ok {
+				e.Bell()
+				return nil
+			}
+
+			typeChar(e, r)
+			return nil
+		})
+		return nil
+	})
+	return nil
+}
+
+// parseCodePoint parses a code point written as "U+2192", "0x2192" or just
+// "2192", in hexadecimal.
+func parseCodePoint(s string) (rune, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToUpper(s), "U+"), "0X")
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, errors.Errorf("invalid code point: %s", s)
+	}
+
+	return rune(n), nil
+}
+
+// insertUnicodeCommand inserts the characters with the given code points
+// before the cursor: "insert-unicode U+XXXX...".
+func insertUnicodeCommand(e SDK, r *Range, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return errors.New("usage: insert-unicode U+XXXX...")
+	}
+
+	chars := make([]rune, len(fields))
+	for i, f := range fields {
+		c, err := parseCodePoint(f)
+		if err != nil {
+			return err
+		}
+		chars[i] = c
+	}
+
+	e.InsertChars(e.Y(), e.X(), chars...)
+	e.SetX(e.X() + len(chars) - 1)
+	return nil
+}
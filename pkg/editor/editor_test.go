@@ -0,0 +1,42 @@
+package editor
+
+import (
+	"testing"
+)
+
+// newTestEditor returns an editor without a terminal holding lines, with the
+// config and state directories in a temporary directory.
+func newTestEditor(t *testing.T, lines ...string) *Editor {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("XDG_STATE_HOME", dir)
+
+	e, err := New(headlessTerminal{}, Options{CacheDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, line := range lines {
+		e.InsertRow(i, []rune(line))
+	}
+	return e
+}
+
+// pressKeys presses keys in key notation, e.g. "ihello<C-c>".
+func pressKeys(t *testing.T, e *Editor, keys string) {
+	t.Helper()
+
+	parsed, err := parseKeys(keys, ' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range parsed {
+		if err := e.ProcessKey(k); err != nil {
+			t.Fatalf("pressing %s: %s", keys, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Event is something that happens in the editor which hooks can run on.
+type Event string
+
+const (
+	// A file was opened
+	EventBufOpen Event = "BufOpen"
+	// The file is about to be written. An error from a hook stops the write.
+	EventBufWritePre Event = "BufWritePre"
+	// The file was written
+	EventBufWritePost Event = "BufWritePost"
+	// The editor mode changed
+	EventModeChanged Event = "ModeChanged"
+	// A key press moved the cursor
+	EventCursorMoved Event = "CursorMoved"
+	// The filetype of the file was set, when it's opened or with ":set ft"
+	EventFileTypeSet Event = "FileTypeSet"
+)
+
+// allEvents are all the events, in the order they are documented.
+var allEvents = []Event{
+	EventBufOpen,
+	EventBufWritePre,
+	EventBufWritePost,
+	EventModeChanged,
+	EventCursorMoved,
+	EventFileTypeSet,
+}
+
+// Hook runs when an event happens.
+type Hook func(e SDK) error
+
+// On runs hook whenever event happens, after the hooks added before it.
+// The hooks are removed when the config is loaded again.
+func (e *Editor) On(event Event, hook Hook) {
+	if e.hooks == nil {
+		e.hooks = make(map[Event][]Hook)
+	}
+
+	e.hooks[event] = append(e.hooks[event], hook)
+}
+
+// fire runs the hooks of the plugins and then those added with On, and
+// returns the first error. Hooks causing the event they run on don't
+// run again.
+func (e *Editor) fire(event Event) error {
+	if e.firing[event] {
+		return nil
+	}
+
+	if e.firing == nil {
+		e.firing = make(map[Event]bool)
+	}
+	e.firing[event] = true
+	defer delete(e.firing, event)
+
+	var hooks []Hook
+	for _, p := range e.plugins {
+		if hook := p.Hooks[event]; hook != nil {
+			hooks = append(hooks, hook)
+		}
+	}
+	hooks = append(hooks, e.hooks[event]...)
+
+	// Options set by hooks aren't remembered for the file
+	e.applyingOptions++
+	defer func() { e.applyingOptions-- }()
+
+	for _, hook := range hooks {
+		if err := hook(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify runs the hooks of an event, showing their errors as a message.
+func (e *Editor) notify(event Event) {
+	if err := e.fire(event); err != nil {
+		e.SetMessage("%s: %s", event, err)
+	}
+}
+
+// parseEvent returns the event with the given name, ignoring case.
+func parseEvent(name string) (Event, error) {
+	for _, event := range allEvents {
+		if strings.EqualFold(string(event), name) {
+			return event, nil
+		}
+	}
+
+	return "", errors.Errorf("unknown event: %s", name)
+}
+
+// eventNames returns the names of the events.
+func eventNames() []string {
+	names := make([]string, len(allEvents))
+	for i, event := range allEvents {
+		names[i] = string(event)
+	}
+
+	return names
+}
+
+// autocmdCommand runs an ex command whenever an event happens, e.g.
+// "autocmd BufWritePre retab".
+func autocmdCommand(e SDK, r *Range, args string) error {
+	name, cmd, _ := strings.Cut(args, " ")
+	cmd = strings.TrimSpace(cmd)
+	if len(name) == 0 || len(cmd) == 0 {
+		return errors.New("usage: autocmd <event> <command>")
+	}
+
+	event, err := parseEvent(name)
+	if err != nil {
+		return err
+	}
+
+	e.On(event, func(e SDK) error {
+		return e.ExecCommand(cmd)
+	})
+	return nil
+}
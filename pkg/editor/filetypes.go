@@ -0,0 +1,324 @@
+package editor
+
+const (
+	HL_HIGHLIGHT_NUMBERS = 1 << iota
+	HL_HIGHLIGHT_STRINGS
+)
+
+type EditorSyntax struct {
+	// Name of the filetype displayed in the status bar.
+	filetype string
+	// List of patterns to match a filename against.
+	filematch []string
+	// List of keywords to highlight.
+	keywords []string
+	// Second highlight group
+	keywords2 []string
+	// scs is a single-line comment start pattern (e.g. "//" for golang).
+	// set to an empty string if comment highlighting is not needed.
+	scs string
+	// mcs is a multi-line comment start pattern (e.g. "/*" for golang).
+	mcs string
+	// mce is a multi-line comment end pattern (e.g. "*/" for golang).
+	mce string
+
+	highlightStrings bool
+	highlightNumbers bool
+
+	// quotes are the characters that start and end a string. Both single
+	// and double quotes are used if it is empty.
+	quotes string
+	// rawQuotes start and end strings that have no escapes and can span
+	// several rows, like Go's backtick strings.
+	rawQuotes string
+
+	// markdown enables highlighting headings, emphasis and inline code.
+	markdown bool
+
+	// keySeparator separates keys from values in config formats, e.g. ':'
+	// in JSON. Keys are highlighted when it is set.
+	keySeparator rune
+	// tableHeaders enables highlighting lines like "[section]".
+	tableHeaders bool
+
+	// delimiter separates the cells of tabular data like CSV. The columns
+	// are lined up on the screen when it is set.
+	delimiter rune
+
+	// locations enables highlighting "file:line:col" at the start of lines,
+	// as in the output of compilers and grep.
+	locations bool
+
+	// interpreters are the programs named in the shebang of scripts of this
+	// filetype, without version numbers, e.g. "python".
+	interpreters []string
+	// signatures are prefixes of the first line that identify files of this
+	// filetype whatever their name, e.g. "<!DOCTYPE html".
+	signatures []string
+}
+
+func (s *EditorSyntax) stringQuotes() string {
+	if len(s.quotes) == 0 {
+		return `"'`
+	}
+
+	return s.quotes
+}
+
+// syntaxes are the filetypes the editor knows.
+var syntaxes = []*EditorSyntax{
+	{
+		filetype:  "c",
+		filematch: []string{".c", ".h", "cpp", ".cc"},
+		keywords: []string{
+			"switch", "if", "while", "for", "break", "continue", "return",
+			"else", "struct", "union", "typedef", "static", "enum", "class",
+			"case",
+		},
+		keywords2: []string{
+			"int", "long", "double", "float", "char", "unsigned",
+			"signed", "void",
+		},
+		scs:              "//",
+		mcs:              "/*",
+		mce:              "*/",
+		highlightStrings: true,
+		highlightNumbers: true,
+	},
+	{
+		filetype:  "go",
+		filematch: []string{".go"},
+		keywords: []string{
+			"break", "default", "func", "interface", "select", "case", "defer",
+			"go", "map", "struct", "chan", "else", "goto", "package", "switch",
+			"const", "fallthrough", "if", "range", "type", "continue", "for",
+			"import", "return", "var",
+		},
+
+		keywords2: []string{
+			"append", "bool", "byte", "cap", "close", "complex",
+			"complex64", "complex128", "error", "uint16", "copy", "false",
+			"float32", "float64", "imag", "int", "int8", "int16",
+			"uint32", "int32", "int64", "iota", "len", "make", "new",
+			"nil", "panic", "uint64", "print", "println", "real",
+			"recover", "rune", "string", "true", "uint", "uint8",
+			"uintptr",
+		},
+		scs:              "//",
+		mcs:              "/*",
+		mce:              "*/",
+		highlightStrings: true,
+		highlightNumbers: true,
+		rawQuotes:        "`",
+	},
+	{
+		filetype:  "javascript",
+		filematch: []string{".js"},
+		keywords: []string{
+			"abstract", "arguments", "await", "boolean", "break", "char",
+			"debugger", "do", "double", "export", "final", "finally",
+			"goto", "import", "in", "let", "null", "public",
+			"super", "throw", "try", "volatile", "byte", "class",
+			"else", "extends", "float", "if", "instance", "long",
+		},
+		keywords2: []string{
+			"package", "return", "switch", "throws", "typeof", "case",
+			"const", "default", "enum", "for", "implement", "of",
+			"native", "private", "short", "synchronized", "transien", "var",
+			"while", "catch", "continue", "delete", "eval", "false",
+			"function", "int", "this", "true", "yield", "interface",
+			"new", "protected", "static", "void", "with",
+		},
+		scs:              "//",
+		mcs:              "/*",
+		mce:              "*/",
+		highlightStrings: true,
+		highlightNumbers: true,
+		interpreters:     []string{"node", "deno"},
+	},
+	{
+		filetype:  "python",
+		filematch: []string{".py"},
+		keywords: []string{
+			"False", "None", "True", "and", "as", "assert",
+			"break", "class", "continuepass", "def", "yield", "del",
+			"elif", "else", "except", "finally", "for", "from",
+			"print",
+		},
+		keywords2: []string{
+			"if", "import", "in", "is", "lambda", "nonlocal",
+			"not", "or", "global", "raise", "return", "try",
+			"while", "with",
+		},
+		scs:              "#",
+		mcs:              `"""`,
+		mce:              `"""`,
+		highlightStrings: true,
+		highlightNumbers: true,
+		interpreters:     []string{"python", "pypy"},
+	},
+	{
+		filetype:  "html",
+		filematch: []string{".html", ".htm"},
+		keywords: []string{
+			"!DOCTYPE", "html", "head", "meta", "link", "r",
+			"title", "body", "script", "div", "a", "p", "span", "ul",
+			"ol", "li", "table", "thead", "tbody", "tr", "td", "th",
+			"form", "input", "button", "img", "h1", "h2", "h3", "h4",
+			"h5", "h6", "section", "article", "header", "footer", "nav",
+			"main", "style", "br", "hr", "pre", "code", "label",
+			"select", "option", "textarea", "iframe", "em", "strong",
+		},
+		keywords2: []string{
+			"rel", "name", "content", "href", "type", "id", "charset",
+			"class", "src", "alt", "style", "width", "height", "action",
+			"method", "value", "placeholder", "lang", "title", "target",
+			"for", "disabled", "checked",
+		},
+		scs:              "",
+		mcs:              "<!--",
+		mce:              "-->",
+		highlightStrings: true,
+		highlightNumbers: true,
+		signatures:       []string{"<!DOCTYPE html", "<!doctype html", "<html"},
+	},
+	{
+		filetype:  "rust",
+		filematch: []string{".rs"},
+		keywords: []string{
+			"as", "async", "await", "break", "const", "continue", "crate",
+			"dyn", "else", "enum", "extern", "fn", "for", "if", "impl",
+			"in", "let", "loop", "match", "mod", "move", "mut", "pub",
+			"ref", "return", "self", "Self", "static", "struct", "super",
+			"trait", "type", "unsafe", "use", "where", "while",
+		},
+		keywords2: []string{
+			"i8", "i16", "i32", "i64", "i128", "isize", "u8", "u16",
+			"u32", "u64", "u128", "usize", "f32", "f64", "bool", "char",
+			"str", "String", "Vec", "Option", "Result", "Box", "Some",
+			"None", "Ok", "Err", "true", "false",
+		},
+		scs:              "//",
+		mcs:              "/*",
+		mce:              "*/",
+		highlightStrings: true,
+		highlightNumbers: true,
+		// Single quotes are also used for lifetimes, e.g. 'a
+		quotes: `"`,
+	},
+	{
+		filetype:  "sh",
+		filematch: []string{".sh", ".bash", ".zsh", ".bashrc", ".zshrc", ".profile"},
+		keywords: []string{
+			"if", "then", "else", "elif", "fi", "case", "esac", "for",
+			"while", "until", "do", "done", "in", "function", "select",
+			"return", "exit", "break", "continue", "local", "export",
+			"readonly",
+		},
+		keywords2: []string{
+			"echo", "printf", "read", "cd", "test", "set", "unset",
+			"shift", "source", "eval", "exec", "trap", "true", "false",
+			"alias", "wait", "kill",
+		},
+		scs:              "#",
+		highlightStrings: true,
+		highlightNumbers: true,
+		interpreters:     []string{"sh", "bash", "zsh", "dash", "ksh"},
+	},
+	{
+		filetype:  "markdown",
+		filematch: []string{".md", ".markdown"},
+		// Code blocks are highlighted like multi-line comments
+		mcs:      "```",
+		mce:      "```",
+		markdown: true,
+	},
+	{
+		filetype:  "css",
+		filematch: []string{".css"},
+		keywords: []string{
+			"color", "background", "margin", "padding", "border",
+			"display", "position", "width", "height", "font", "top",
+			"left", "right", "bottom", "float", "overflow", "z-index",
+			"opacity", "transition", "transform", "content", "cursor",
+			"flex", "grid", "align", "justify", "text", "line",
+		},
+		keywords2: []string{
+			"@media", "@import", "@keyframes", "@font-face", "!important",
+			"none", "auto", "inherit", "initial", "block", "inline",
+			"absolute", "relative", "fixed", "solid", "bold", "hidden",
+		},
+		mcs:              "/*",
+		mce:              "*/",
+		highlightStrings: true,
+		highlightNumbers: true,
+	},
+	{
+		filetype:         "json",
+		filematch:        []string{".json"},
+		keywords2:        []string{"true", "false", "null"},
+		highlightStrings: true,
+		highlightNumbers: true,
+		quotes:           `"`,
+		keySeparator:     ':',
+	},
+	{
+		filetype:  "yaml",
+		filematch: []string{".yaml", ".yml"},
+		keywords2: []string{
+			"true", "false", "yes", "no", "on", "off", "null",
+			"True", "False", "Yes", "No", "Null", "~",
+		},
+		scs:              "#",
+		highlightStrings: true,
+		highlightNumbers: true,
+		keySeparator:     ':',
+		signatures:       []string{"---", "%YAML"},
+	},
+	{
+		filetype:         "toml",
+		filematch:        []string{".toml"},
+		keywords2:        []string{"true", "false", "inf", "nan"},
+		scs:              "#",
+		mcs:              `"""`,
+		mce:              `"""`,
+		highlightStrings: true,
+		highlightNumbers: true,
+		keySeparator:     '=',
+		tableHeaders:     true,
+	},
+	{
+		filetype:         "csv",
+		filematch:        []string{".csv"},
+		highlightNumbers: true,
+		delimiter:        ',',
+	},
+	{
+		filetype:         "tsv",
+		filematch:        []string{".tsv", ".tab"},
+		highlightNumbers: true,
+		delimiter:        '\t',
+	},
+	{
+		filetype:  "vue",
+		filematch: []string{".vue"},
+		keywords: []string{
+			"!DOCTYPE", "html", "head", "meta", "link", "r",
+			"title", "body", "script", "div",
+		},
+		keywords2: []string{
+			"rel", "name", "content", "href", "type", "id", "charset",
+			"import", "from", "template",
+		},
+		scs:              "",
+		mcs:              "<!--",
+		mce:              "-->",
+		highlightStrings: true,
+		highlightNumbers: true,
+	},
+	{
+		// The output buffer, which no file is detected as
+		filetype:  "output",
+		locations: true,
+	},
+}
@@ -0,0 +1,110 @@
+package editor
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long the cached git status is used before it is refreshed
+const gitRefreshInterval = 5 * time.Second
+
+// gitStatus caches the branch and dirty state of the repository containing
+// the current file. Running git is far too slow to do on every render, so the
+// cache is refreshed in the background and the status bar shows the last
+// known value.
+type gitStatus struct {
+	mu sync.Mutex
+
+	dir     string
+	branch  string
+	dirty   bool
+	updated time.Time
+	running bool
+
+	// redraw is called once a refresh has found the status, to show it
+	redraw func()
+}
+
+// String returns the status to show in the status bar, e.g. "[main*]", or an
+// empty string when filename isn't in a git repository.
+func (g *gitStatus) String(filename string) string {
+	dir := "."
+	if len(filename) != 0 {
+		dir = filepath.Dir(filename)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if dir != g.dir {
+		g.dir = dir
+		g.branch = ""
+		g.updated = time.Time{}
+	}
+
+	if !g.running && time.Since(g.updated) > gitRefreshInterval {
+		g.running = true
+		go g.refresh(dir)
+	}
+
+	if len(g.branch) == 0 {
+		return ""
+	}
+
+	if g.dirty {
+		return "[" + g.branch + "*]"
+	}
+
+	return "[" + g.branch + "]"
+}
+
+// Invalidate forces the status to be refreshed the next time it is shown.
+func (g *gitStatus) Invalidate() {
+	g.mu.Lock()
+	g.updated = time.Time{}
+	g.mu.Unlock()
+}
+
+func (g *gitStatus) refresh(dir string) {
+	branch, err := gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		// Most likely not a git repository
+		branch = ""
+	}
+
+	var dirty bool
+	if len(branch) != 0 {
+		changes, err := gitOutput(dir, "status", "--porcelain", "--untracked-files=no")
+		if err != nil {
+			logErrorf("git status: %s", err)
+		}
+		dirty = len(changes) != 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.running = false
+	g.updated = time.Now()
+
+	// The file may have changed while git was running
+	if dir == g.dir {
+		g.branch = branch
+		g.dirty = dirty
+
+		if g.redraw != nil {
+			g.redraw()
+		}
+	}
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,382 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type diffSign uint8
+
+const (
+	signNone diffSign = iota
+	signAdded
+	signChanged
+	// Lines were deleted after the row
+	signDeleted
+	// Lines were deleted before the first row
+	signDeletedAbove
+)
+
+// Width of the column of diff signs in the gutter
+const signColumnWidth = 2
+
+// diffGutter compares the text with the version of the file in the git
+// index, to mark the changed lines in the gutter. Running git and diffing
+// the file are done in the background, so the results are kept behind a
+// mutex.
+type diffGutter struct {
+	mu sync.Mutex
+
+	// lines of the file in the git index, nil if it isn't tracked
+	base  []string
+	hunks []DiffHunk
+	signs map[int]diffSign
+
+	// changeTick of the text the hunks were found for
+	tick int
+	// incremented for each update, so that only the latest one is kept
+	generation int
+}
+
+// updateDiff compares the text with the git index in the background,
+// reading the file from the index again if refetch is set.
+func (e *Editor) updateDiff(refetch bool) {
+	g := &e.diff
+	if !e.cfg.GitGutter || len(e.filename) == 0 {
+		return
+	}
+
+	lines := make([]string, len(e.rows))
+	for i, row := range e.rows {
+		lines[i] = string(row.chars)
+	}
+
+	g.mu.Lock()
+	g.generation++
+	generation := g.generation
+	base := g.base
+	g.mu.Unlock()
+
+	filename, tick := e.filename, e.changeTick
+	go func() {
+		if refetch {
+			base = gitIndexLines(filename)
+		}
+
+		var hunks []DiffHunk
+		if base != nil {
+			hunks = diffLines(base, lines)
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if generation != g.generation {
+			return
+		}
+
+		g.base = base
+		g.hunks = hunks
+		g.signs = diffSigns(hunks)
+		g.tick = tick
+
+		e.requestRedraw()
+	}()
+}
+
+// updateDiffIfChanged updates the diff if the text changed since the last
+// one.
+func (e *Editor) updateDiffIfChanged() {
+	e.diff.mu.Lock()
+	changed := e.diff.tick != e.changeTick
+	e.diff.mu.Unlock()
+
+	if changed {
+		e.updateDiff(false)
+	}
+}
+
+// diffSigns returns the sign of each row that has one.
+func diffSigns(hunks []DiffHunk) map[int]diffSign {
+	signs := make(map[int]diffSign)
+	for _, h := range hunks {
+		switch {
+		case h.OldLen == 0:
+			for y := h.NewStart; y < h.NewStart+h.NewLen; y++ {
+				signs[y] = signAdded
+			}
+		case h.NewLen == 0:
+			if h.NewStart == 0 {
+				signs[0] = signDeletedAbove
+			} else {
+				signs[h.NewStart-1] = signDeleted
+			}
+		default:
+			for y := h.NewStart; y < h.NewStart+h.NewLen; y++ {
+				signs[y] = signChanged
+			}
+		}
+	}
+
+	return signs
+}
+
+// tracked reports whether the file is in the git index.
+func (g *diffGutter) tracked() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.base != nil
+}
+
+func (g *diffGutter) sign(y int) diffSign {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.signs[y]
+}
+
+// diffHunks returns the differences between the git index and the text, as
+// of the last update.
+func (e *Editor) diffHunks() []DiffHunk {
+	e.diff.mu.Lock()
+	defer e.diff.mu.Unlock()
+
+	return append([]DiffHunk(nil), e.diff.hunks...)
+}
+
+// signWidth returns the width of the diff signs column, which is only shown
+// for files in a git repository.
+func (e *Editor) signWidth() int {
+	if !e.cfg.GitGutter || !e.diff.tracked() {
+		return 0
+	}
+
+	return signColumnWidth
+}
+
+// drawSign draws the diff sign of filerow, or blanks for a negative filerow.
+func (e *Editor) drawSign(w io.Writer, filerow int) {
+	if e.signWidth() == 0 {
+		return
+	}
+
+	var (
+		sign = signNone
+		text = " "
+		hl   SyntaxHL
+	)
+	if filerow >= 0 {
+		sign = e.diff.sign(filerow)
+	}
+
+	switch sign {
+	case signAdded:
+		text, hl = "+", hlDiffAdd
+	case signChanged:
+		text, hl = "~", hlDiffChange
+	case signDeleted:
+		text, hl = "_", hlDiffDelete
+	case signDeletedAbove:
+		text, hl = "‾", hlDiffDelete
+	}
+
+	if hl != 0 {
+		setStyle(w, e.color(hl))
+	}
+	w.Write([]byte(text + strings.Repeat(" ", signColumnWidth-1)))
+	clearFormatting(w)
+}
+
+// gitIndexLines returns the lines of filename as it is in the git index, or
+// nil if it isn't in a git repository or hasn't been added.
+func gitIndexLines(filename string) []string {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil
+	}
+
+	cmd := exec.Command("git", "show", ":./"+filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(string(out), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// moveToHunk moves to the start of the next hunk, or the previous one when
+// n is -1, like vim's ]c and [c.
+func moveToHunk(n int) func(e SDK) error {
+	return func(e SDK) error {
+		hunks := e.diffHunks()
+
+		if n < 0 {
+			for i := len(hunks) - 1; i >= 0; i-- {
+				if hunks[i].NewStart < e.Y() {
+					e.SetY(hunks[i].NewStart)
+					return nil
+				}
+			}
+		} else {
+			for _, h := range hunks {
+				if h.NewStart > e.Y() {
+					e.SetY(h.NewStart)
+					return nil
+				}
+			}
+		}
+
+		e.SetMessage("no more hunks")
+		return nil
+	}
+}
+
+// currentHunks returns the differences from the git index, diffing the text
+// again if it changed since the last update.
+func (e *Editor) currentHunks() ([]DiffHunk, []string, error) {
+	e.diff.mu.Lock()
+	base, hunks, tick := e.diff.base, e.diff.hunks, e.diff.tick
+	e.diff.mu.Unlock()
+
+	if base == nil {
+		return nil, nil, errors.New("the file isn't in the git index")
+	}
+
+	if tick != e.changeTick {
+		lines := make([]string, len(e.rows))
+		for i, row := range e.rows {
+			lines[i] = string(row.chars)
+		}
+		hunks = diffLines(base, lines)
+	}
+
+	return hunks, base, nil
+}
+
+// hunkAt returns the hunk that row y is part of. The hunk of deleted lines
+// is the one whose sign is on the row.
+func hunkAt(hunks []DiffHunk, y int) (DiffHunk, bool) {
+	for _, h := range hunks {
+		if y >= h.NewStart && y < h.NewStart+h.NewLen {
+			return h, true
+		}
+
+		if h.NewLen == 0 && (y == h.NewStart-1 || y == 0 && h.NewStart == 0) {
+			return h, true
+		}
+	}
+
+	return DiffHunk{}, false
+}
+
+// stageHunk adds the hunk at row y to the git index, leaving the other
+// changes unstaged.
+func (e *Editor) stageHunk(y int) error {
+	hunks, base, err := e.currentHunks()
+	if err != nil {
+		return err
+	}
+
+	h, ok := hunkAt(hunks, y)
+	if !ok {
+		return errors.New("no change on this line")
+	}
+
+	path, err := filepath.Abs(e.filename)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+
+	// Patches name files relative to the top of the repository
+	prefix, err := gitOutput(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return errors.Wrap(err, "git rev-parse")
+	}
+	name := prefix + filepath.Base(path)
+
+	// Without context lines the patch only applies to this hunk. The new
+	// range is where the lines end up in the index, which doesn't have the
+	// other changes.
+	var patch strings.Builder
+	fmt.Fprintf(&patch, "--- a/%s\n+++ b/%s\n", name, name)
+	fmt.Fprintf(&patch, "@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLen), hunkRange(h.OldStart, h.NewLen))
+	for _, line := range base[h.OldStart : h.OldStart+h.OldLen] {
+		patch.WriteString("-" + line + "\n")
+	}
+	for _, row := range e.rows[h.NewStart : h.NewStart+h.NewLen] {
+		patch.WriteString("+" + string(row.chars) + "\n")
+	}
+
+	cmd := exec.Command("git", "apply", "--cached", "--unidiff-zero", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Errorf("git apply: %s", strings.TrimSpace(string(out)))
+	}
+
+	e.git.Invalidate()
+	e.updateDiff(true)
+	return nil
+}
+
+// hunkRange formats the start and length of a hunk for a unified diff, where
+// lines are counted from 1 and an empty range starts at the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// revertHunk replaces the hunk at row y with the lines from the git index.
+func (e *Editor) revertHunk(y int) error {
+	hunks, base, err := e.currentHunks()
+	if err != nil {
+		return err
+	}
+
+	h, ok := hunkAt(hunks, y)
+	if !ok {
+		return errors.New("no change on this line")
+	}
+
+	for i := 0; i < h.NewLen; i++ {
+		e.DeleteRow(h.NewStart)
+	}
+	for i, line := range base[h.OldStart : h.OldStart+h.OldLen] {
+		e.InsertRow(h.NewStart+i, []rune(line))
+	}
+
+	e.SetY(h.NewStart)
+	e.updateDiff(false)
+	return nil
+}
+
+// hunkCommand stages or reverts the change under the cursor:
+// "hunk stage" or "hunk revert".
+func hunkCommand(e SDK, r *Range, args string) error {
+	switch args {
+	case "stage":
+		return e.stageHunk(e.Y())
+	case "revert":
+		return e.revertHunk(e.Y())
+	default:
+		return errors.New("usage: hunk stage|revert")
+	}
+}
@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// "gf" opens the file whose path is under the cursor, and "gF" also moves to
+// the line, and column, of a ":line:col" suffix as in compiler errors and
+// logs.
+
+// lineSuffix matches a path followed by a line number and maybe a column.
+var lineSuffix = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?:?$`)
+
+// pathUnderCursor returns the file path around the cursor, without the
+// punctuation that usually ends a sentence.
+func pathUnderCursor(e SDK) (string, bool) {
+	row, x := cursorRow(e), e.X()
+	if x >= len(row) || !isPathChar(row[x]) {
+		return "", false
+	}
+
+	start, end := x, x
+	for start > 0 && isPathChar(row[start-1]) {
+		start--
+	}
+	for end < len(row) && isPathChar(row[end]) {
+		end++
+	}
+
+	path := strings.TrimRight(string(row[start:end]), ".:")
+	return path, len(path) > 0
+}
+
+// findFile returns the file a path in the text refers to, looking for a
+// relative path in the directory of the current file, the project root and
+// the working directory, in that order.
+func findFile(e SDK, path string) (string, error) {
+	path = expandPath(path)
+
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		dir := filepath.Dir(e.Filename())
+		candidates = []string{filepath.Join(dir, path)}
+		if root := findProjectRoot(dir); len(root) > 0 {
+			candidates = append(candidates, filepath.Join(root, path))
+		}
+		candidates = append(candidates, path)
+	}
+
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && info.Mode().IsRegular() {
+			return c, nil
+		}
+	}
+
+	return "", errors.Errorf("can't find file %q", path)
+}
+
+// goToFile returns an action opening the file under the cursor, at the line
+// and column of its suffix when withLine is set.
+func goToFile(withLine bool) func(e SDK) error {
+	return func(e SDK) error {
+		path, ok := pathUnderCursor(e)
+		if !ok {
+			return errors.New("no file name under the cursor")
+		}
+
+		x, y := 0, 0
+		m := lineSuffix.FindStringSubmatch(path)
+		if withLine && m != nil {
+			path = m[1]
+			line, _ := strconv.Atoi(m[2])
+			y = line - 1
+			if len(m[3]) > 0 {
+				col, _ := strconv.Atoi(m[3])
+				x = col - 1
+			}
+		}
+
+		file, err := findFile(e, path)
+		if err != nil && m != nil && !withLine {
+			// "gf" on "file:12" still opens the file
+			file, err = findFile(e, m[1])
+		}
+		if err != nil {
+			return err
+		}
+
+		return e.openFileAt(file, x, y)
+	}
+}
@@ -0,0 +1,200 @@
+package editor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Files ending in .gpg, .pgp or .asc are encrypted with gpg. They are
+// decrypted into memory when opened and encrypted again when saved, so the
+// text never touches the disk, and they are secret files. A file is encrypted
+// again to the keys it was encrypted to, or with the passphrase it was
+// decrypted with. New files are encrypted to the keys of the gpgrecipients
+// option, or with a passphrase asked for on the first save.
+
+// errNoGPG is returned when gpg isn't installed.
+var errNoGPG = errors.New("gpg isn't installed")
+
+// errAskingPassphrase is returned when opening or saving an encrypted file
+// waits for a passphrase, and is done once it is entered.
+var errAskingPassphrase = errors.New("asking for the passphrase")
+
+// gpgState is what it takes to encrypt the file being edited again, kept
+// only in memory.
+type gpgState struct {
+	// absolute path of the file it is for
+	file       string
+	passphrase string
+	// long IDs of the keys the file is encrypted to, none when it is
+	// encrypted with the passphrase
+	recipients []string
+}
+
+// isEncrypted reports whether a file is encrypted with gpg, from its name.
+func isEncrypted(filename string) bool {
+	switch filepath.Ext(filename) {
+	case ".gpg", ".pgp", ".asc":
+		return true
+	}
+
+	return false
+}
+
+// absPath returns the absolute path of a file, or the path as it is if it
+// can't.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// runGPG runs gpg in batch mode with input on its standard input, passing it
+// the passphrase through a pipe rather than on the command line, where other
+// users could see it. It returns the output, and gpg's status lines without
+// their "[GNUPG:] " prefix.
+func runGPG(passphrase string, input []byte, args ...string) (out []byte, status []string, err error) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return nil, nil, errNoGPG
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	defer w.Close()
+
+	args = append([]string{
+		"--batch", "--quiet", "--yes", "--status-fd", "2",
+		"--pinentry-mode", "loopback", "--passphrase-fd", "3",
+	}, args...)
+	cmd := exec.Command("gpg", args...)
+	// The passphrase pipe is fd 3 in gpg
+	cmd.ExtraFiles = []*os.File{r}
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	io.WriteString(w, passphrase+"\n")
+	w.Close()
+	err = cmd.Wait()
+
+	var messages []string
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if strings.HasPrefix(line, "[GNUPG:] ") {
+			status = append(status, strings.TrimPrefix(line, "[GNUPG:] "))
+		} else if len(strings.TrimSpace(line)) > 0 {
+			messages = append(messages, line)
+		}
+	}
+
+	if err != nil {
+		// gpg's own messages start with "gpg: "
+		msg := firstLine([]byte(strings.Join(messages, "\n")), err)
+		if !strings.HasPrefix(msg, "gpg: ") {
+			msg = "gpg: " + msg
+		}
+		return nil, status, errors.New(msg)
+	}
+
+	return stdout.Bytes(), status, nil
+}
+
+// encryptedTo returns the long IDs of the keys a file was encrypted to, from
+// the status lines of gpg decrypting it.
+func encryptedTo(status []string) []string {
+	var keys []string
+	for _, line := range status {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "ENC_TO" {
+			keys = append(keys, fields[1])
+		}
+	}
+
+	return keys
+}
+
+// decryptFile returns the text of an encrypted file. Without a passphrase
+// for the file yet, a failure might be for the lack of one, so it is asked
+// for and the file is opened again, and errAskingPassphrase returned.
+func (e *Editor) decryptFile(filename string) ([]byte, error) {
+	if abs := absPath(filename); e.gpg.file != abs {
+		e.gpg = gpgState{file: abs}
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	out, status, err := runGPG(e.gpg.passphrase, data, "--decrypt")
+	if err != nil && len(e.gpg.passphrase) == 0 && err != errNoGPG {
+		e.passwordPrompt("Passphrase for "+filename+": ", func(passphrase string) error {
+			e.gpg.passphrase = passphrase
+			return e.OpenFile(filename)
+		})
+		return nil, errAskingPassphrase
+	}
+	if err != nil {
+		// Asked for again on the next try
+		e.gpg.passphrase = ""
+		return nil, err
+	}
+
+	e.gpg.recipients = encryptedTo(status)
+	return out, nil
+}
+
+// encrypt encrypts the text of the file being edited for saving, to the
+// keys it was encrypted to or with its passphrase. For a new file without
+// the gpgrecipients option, the passphrase is asked for, the file saved once
+// it is entered, and errAskingPassphrase returned.
+func (e *Editor) encrypt(text []byte) ([]byte, error) {
+	if abs := absPath(e.filename); e.gpg.file != abs {
+		e.gpg = gpgState{file: abs}
+		for _, key := range strings.Split(e.cfg.GPGRecipients, ",") {
+			if key = strings.TrimSpace(key); len(key) > 0 {
+				e.gpg.recipients = append(e.gpg.recipients, key)
+			}
+		}
+	}
+
+	args := []string{"--symmetric"}
+	if len(e.gpg.recipients) > 0 {
+		args = []string{"--encrypt"}
+		for _, key := range e.gpg.recipients {
+			args = append(args, "--recipient", key)
+		}
+	} else if len(e.gpg.passphrase) == 0 {
+		e.passwordPrompt("Passphrase to encrypt "+e.filename+" with: ", func(passphrase string) error {
+			if len(passphrase) == 0 {
+				return errors.New("no passphrase, not saved")
+			}
+
+			e.gpg.passphrase = passphrase
+			if err := e.saveFile(e.filename); err != nil {
+				return err
+			}
+			e.SetMessage("saved file: %s", e.filename)
+			return nil
+		})
+		return nil, errAskingPassphrase
+	}
+
+	if filepath.Ext(e.filename) == ".asc" {
+		args = append(args, "--armor")
+	}
+
+	out, _, err := runGPG(e.gpg.passphrase, text, args...)
+	return out, err
+}
@@ -0,0 +1,109 @@
+package editor
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// What the user sees as one character, a grapheme cluster, can be made of
+// several runes, e.g. a letter followed by combining accents, or emoji joined
+// with zero width joiners. The cursor moves over whole clusters, and each
+// cluster takes up the width of the character it draws as.
+
+// emojiPresentation is the variation selector asking for a character to be
+// drawn as a (double width) emoji.
+const emojiPresentation = '\ufe0f'
+
+// cursorRow returns the row the cursor is on, which is empty past the end of
+// the file.
+func cursorRow(e SDK) []rune {
+	if e.Y() >= e.NumRows() {
+		return nil
+	}
+
+	return e.Row(e.Y())
+}
+
+// clusterBounds returns the index in chars of the start of each grapheme
+// cluster, followed by len(chars).
+func clusterBounds(chars []rune) []int {
+	bounds := make([]int, 0, len(chars)+1)
+	i := 0
+	g := uniseg.NewGraphemes(string(chars))
+	for g.Next() {
+		bounds = append(bounds, i)
+		i += len(g.Runes())
+	}
+
+	return append(bounds, len(chars))
+}
+
+// clusterWidth returns the number of columns a grapheme cluster takes up,
+// which is the width of its widest rune, so that joined and combining runes
+// add nothing.
+func clusterWidth(cluster []rune, cond *runewidth.Condition) int {
+	width := 0
+	for _, r := range cluster {
+		if r == emojiPresentation {
+			return 2
+		}
+		if w := cond.RuneWidth(r); w > width {
+			width = w
+		}
+	}
+
+	return width
+}
+
+// charWidths returns the width of each rune of chars, giving the width of a
+// cluster to its first rune and 0 to the rest.
+func charWidths(chars []rune, cond *runewidth.Condition) []int {
+	widths := make([]int, len(chars))
+	bounds := clusterBounds(chars)
+	for i := 0; i < len(bounds)-1; i++ {
+		widths[bounds[i]] = clusterWidth(chars[bounds[i]:bounds[i+1]], cond)
+	}
+
+	return widths
+}
+
+// clusterStart returns the index of the start of the cluster containing the
+// rune at x. Indexes outside of chars are returned as is.
+func clusterStart(chars []rune, x int) int {
+	if x <= 0 || x >= len(chars) {
+		return x
+	}
+
+	bounds := clusterBounds(chars)
+	for i := len(bounds) - 1; i >= 0; i-- {
+		if bounds[i] <= x {
+			return bounds[i]
+		}
+	}
+
+	return 0
+}
+
+// nextCluster returns the index of the cluster after the one at x.
+func nextCluster(chars []rune, x int) int {
+	if x < 0 || x >= len(chars) {
+		return x + 1
+	}
+
+	for _, b := range clusterBounds(chars) {
+		if b > x {
+			return b
+		}
+	}
+
+	return len(chars)
+}
+
+// prevCluster returns the index of the cluster before the one at x.
+func prevCluster(chars []rune, x int) int {
+	if x <= 0 || x > len(chars) {
+		return x - 1
+	}
+
+	return clusterStart(chars, x-1)
+}
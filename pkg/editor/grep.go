@@ -0,0 +1,231 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// grepWorkers is how many files are searched at the same time.
+const grepWorkers = 8
+
+// grepMatch is a line of a file matching the pattern of a grep.
+type grepMatch struct {
+	file string
+	// row and rune index of the start of the match
+	x, y int
+	text string
+}
+
+func (m grepMatch) String() string {
+	return fmt.Sprintf("%s:%d: %s", m.file, m.y+1, strings.TrimSpace(m.text))
+}
+
+// grepFiles searches the files under dir for re, passing the matches of each
+// file to found as soon as the file has been searched. Hidden directories and
+// binary files are skipped. It stops early when ctx is cancelled.
+func grepFiles(ctx context.Context, dir string, re *regexp.Regexp, found func([]grepMatch)) error {
+	files := make(chan string)
+
+	var wg sync.WaitGroup
+	wg.Add(grepWorkers)
+	for i := 0; i < grepWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range files {
+				if matches := grepFile(path, re); len(matches) > 0 {
+					found(matches)
+				}
+			}
+		}()
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than ending the search
+			return nil
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		select {
+		case files <- path:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	close(files)
+	wg.Wait()
+	return err
+}
+
+// grepFile returns the lines of the file at path matching re.
+func grepFile(path string, re *regexp.Regexp) []grepMatch {
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) != -1 {
+		return nil
+	}
+
+	var matches []grepMatch
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, len(data)+1)
+	for y := 0; s.Scan(); y++ {
+		line := s.Bytes()
+		if loc := re.FindIndex(line); loc != nil {
+			matches = append(matches, grepMatch{
+				file: path,
+				x:    utf8.RuneCount(line[:loc[0]]),
+				y:    y,
+				text: string(line),
+			})
+		}
+	}
+
+	return matches
+}
+
+// grep searches the files under the working directory for a regular
+// expression in the background, listing the matching lines as they are
+// found. Picking one opens its file at the match, and Escape stops the
+// search.
+func (e *Editor) grep(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		matches []grepMatch
+		running = true
+	)
+
+	status := func() string {
+		if running {
+			return fmt.Sprintf("-- grep: %d matches so far, Esc to stop --", len(matches))
+		}
+		return fmt.Sprintf("-- grep: %d matches, Enter to open, q to quit --", len(matches))
+	}
+
+	e.pager = []string{}
+	e.pagerOffset = 0
+	e.pagerSelected = -1
+
+	e.Prompt("", func(k Key) (string, bool) {
+		switch k {
+		case Key('j'), keyArrowDown, Key(ctrl('n')):
+			e.selectMenuItem(e.pagerSelected + 1)
+		case Key('k'), keyArrowUp, Key(ctrl('p')):
+			e.selectMenuItem(e.pagerSelected - 1)
+		case keyPageDown, Key(ctrl('d')):
+			e.selectMenuItem(e.pagerSelected + e.screenRows)
+		case keyPageUp, Key(ctrl('u')):
+			e.selectMenuItem(e.pagerSelected - e.screenRows)
+		case keyEnter, keyCarriageReturn:
+			if len(matches) == 0 {
+				break
+			}
+
+			cancel()
+			m := matches[e.pagerSelected]
+			e.closePager()
+			if err := e.openFileAt(m.file, m.x, m.y); err != nil {
+				e.ErrChan() <- err
+			}
+			return "", true
+		case Key('q'), keyEscape, Key(ctrl('q')):
+			cancel()
+			e.closePager()
+			return "", true
+		}
+
+		return status(), false
+	})
+	e.setPromptText(status())
+
+	go func() {
+		err := grepFiles(ctx, ".", re, func(found []grepMatch) {
+			e.runOnMain(func() {
+				if ctx.Err() != nil {
+					return
+				}
+
+				matches = append(matches, found...)
+				for _, m := range found {
+					e.pager = append(e.pager, m.String())
+				}
+				if e.pagerSelected == -1 {
+					e.selectMenuItem(0)
+				}
+				e.setPromptText(status())
+			})
+		})
+
+		e.runOnMain(func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			running = false
+			if err != nil {
+				e.setPromptText(fmt.Sprintf("grep: %s", err))
+			} else {
+				e.setPromptText(status())
+			}
+		})
+	}()
+
+	return nil
+}
+
+// openFileAt moves the cursor to x on row y of a file, opening it if it isn't
+// the current one.
+func (e *Editor) openFileAt(file string, x, y int) error {
+	if filepath.Clean(file) != filepath.Clean(e.filename) {
+		if e.modified {
+			return fmt.Errorf("%s has unsaved changes", e.filename)
+		}
+
+		if err := e.OpenFile(file); err != nil {
+			return err
+		}
+	}
+
+	e.SetY(y)
+	e.WrapCursorY()
+	e.SetX(x)
+	e.WrapCursorX()
+	e.CenterCursor()
+	return nil
+}
+
+// grepCommand searches the files under the working directory for a regular
+// expression: "grep <pattern>".
+func grepCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: grep <pattern>")
+	}
+
+	return e.grep(args)
+}
@@ -0,0 +1,116 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// headlessTerminal is the terminal of an editor run without one. Nothing
+// is drawn and nothing can be typed.
+type headlessTerminal struct{}
+
+func (headlessTerminal) Read(p []byte) (int, error)        { return 0, io.EOF }
+func (headlessTerminal) Write(p []byte) (int, error)       { return len(p), nil }
+func (headlessTerminal) Size() (rows, cols int, err error) { return 24, 80, nil }
+func (headlessTerminal) MakeRaw() error                    { return nil }
+func (headlessTerminal) Restore() error                    { return nil }
+
+// Headless edits filename by running the script in the file named script,
+// without a terminal, and then saves the file if it was changed. The editor
+// is made with opts.
+//
+// Each line of the script is either an ex command starting with ':', or keys
+// in key notation to be pressed as if typed, e.g. "jD" or "ihello<C-c>".
+// Empty lines and lines starting with '#' are ignored. The script stops
+// early if a key quits the editor, in which case nothing is saved.
+func Headless(filename, script string, opts Options) error {
+	text, err := os.ReadFile(script)
+	if err != nil {
+		return err
+	}
+
+	closeLog, err := StartLogging("", opts.LogFile)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	if _, err := loadPluginDir(); err != nil {
+		return errors.Wrap(err, "plugins")
+	}
+
+	e, err := New(headlessTerminal{}, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := e.OpenFile(filename); err != nil {
+		return err
+	}
+
+	for i, line := range strings.Split(string(text), "\n") {
+		err := e.runScriptLine(strings.TrimSuffix(line, "\r"))
+		if err == ErrQuitEditor {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", script, i+1, err)
+		}
+	}
+
+	if !e.modified {
+		return nil
+	}
+
+	return e.saveFile(filename)
+}
+
+// runScriptLine runs a line of a headless script.
+func (e *Editor) runScriptLine(line string) error {
+	if len(strings.TrimSpace(line)) == 0 || line[0] == '#' {
+		return nil
+	}
+
+	if line[0] == ':' {
+		if err := e.ExecCommand(line[1:]); err != nil {
+			return err
+		}
+		return e.scriptError()
+	}
+
+	leader, err := e.leader()
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseKeys(line, leader)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := e.ProcessKey(k); err != nil {
+			return err
+		}
+		if err := e.scriptError(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scriptError returns an error sent by an action to the error channel, if
+// there is one.
+func (e *Editor) scriptError() error {
+	select {
+	case err := <-e.errChan:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// helpKeymaps are the keymaps listed by :help, in order.
+var helpKeymaps = []KeyMapName{BasicMapName, CommandModeName, InsertModeName}
+
+// helpLines lists the keys bound in each keymap, the available actions and
+// the ex commands.
+func helpLines(e SDK) []string {
+	var lines []string
+
+	for _, name := range helpKeymaps {
+		bindings := e.bindings(name)
+
+		keys := make([]string, 0, len(bindings))
+		for k := range bindings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		lines = append(lines, fmt.Sprintf("%s keys", name))
+		for _, k := range keys {
+			action := bindings[k]
+			a, _ := e.action(action)
+			lines = append(lines, fmt.Sprintf("    %-12s %-20s %s", k, action, a.Description))
+		}
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, "Actions (see :map)")
+	for _, name := range e.actionNames() {
+		a, _ := e.action(name)
+		lines = append(lines, fmt.Sprintf("    %-20s %s", name, a.Description))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, "Commands")
+	for _, cmd := range e.commandNames() {
+		lines = append(lines, "    :"+cmd)
+	}
+
+	return lines
+}
+
+func helpCommand(e SDK, r *Range, args string) error {
+	e.ShowLines(helpLines(e))
+	return nil
+}
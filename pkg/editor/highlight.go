@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// highlightRule colors every match of a regular expression, on top of the
+// syntax highlighting. The color is either that of a highlight group, so that
+// it follows the colorscheme, or given as SGR parameters.
+type highlightRule struct {
+	re    *regexp.Regexp
+	group SyntaxHL
+	color string
+}
+
+// The highlight groups from hlUser onwards color the matches of the
+// highlight rules, one group per rule.
+const hlUser SyntaxHL = 128
+
+// maxHighlightRules is the number of highlight groups left for the rules.
+const maxHighlightRules = 256 - int(hlUser)
+
+// applyHighlightRules colors the matches of the highlight rules in row.
+func (e *Editor) applyHighlightRules(row *Row) {
+	for i, rule := range e.highlightRules {
+		for _, m := range rule.re.FindAllStringIndex(row.render, -1) {
+			// hl is indexed by rune rather than byte
+			start := utf8.RuneCountInString(row.render[:m[0]])
+			end := start + utf8.RuneCountInString(row.render[m[0]:m[1]])
+			for j := start; j < end && j < len(row.hl); j++ {
+				row.hl[j] = hlUser + SyntaxHL(i)
+			}
+		}
+	}
+}
+
+// highlightCommand adds a highlight rule: "highlight <color> <regexp>",
+// where color is either the name of a highlight group such as "keyword1" or
+// SGR parameters such as "1;31". "highlight clear" removes all the rules.
+func highlightCommand(e SDK, r *Range, args string) error {
+	if args == "clear" {
+		e.clearHighlights()
+		return nil
+	}
+
+	color, expr, _ := strings.Cut(args, " ")
+	expr = strings.TrimSpace(expr)
+	if len(color) == 0 || len(expr) == 0 {
+		return errors.New("usage: highlight <color> <regexp>")
+	}
+
+	return e.addHighlight(color, expr)
+}
+
+// addHighlight adds a rule coloring the matches of expr with color, which
+// is either the name of a highlight group or SGR parameters.
+func (e *Editor) addHighlight(color, expr string) error {
+	rule := highlightRule{group: highlightNames[color], color: color}
+	if rule.group == 0 && !validSGR(color) {
+		return errors.Errorf("invalid color: %s", color)
+	}
+
+	var err error
+	if rule.re, err = regexp.Compile(expr); err != nil {
+		return err
+	}
+
+	if len(e.highlightRules) == maxHighlightRules {
+		return errors.Errorf("too many highlight rules, the limit is %d", maxHighlightRules)
+	}
+
+	e.highlightRules = append(e.highlightRules, rule)
+	e.rehighlight()
+	return nil
+}
+
+// clearHighlights removes all the highlight rules.
+func (e *Editor) clearHighlights() {
+	e.highlightRules = nil
+	e.rehighlight()
+}
+
+// rehighlight updates the highlighting of every row, e.g. after the
+// highlight rules change.
+func (e *Editor) rehighlight() {
+	for i := range e.rows {
+		e.updateHighlight(i)
+	}
+}
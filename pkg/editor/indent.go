@@ -0,0 +1,96 @@
+package editor
+
+import "fmt"
+
+// detectIndent guesses the indentation style of rows. It returns whether
+// lines are indented with spaces and, if so, the width of one level. ok is
+// false if there aren't enough indented lines to tell.
+func detectIndent(rows []*Row) (spaces bool, width int, ok bool) {
+	var (
+		tabLines, spaceLines int
+		// how often the indentation changes by each amount of spaces
+		deltas = make(map[int]int)
+		prev   int
+	)
+
+	for _, row := range rows {
+		if len(row.chars) == 0 {
+			continue
+		}
+
+		switch row.chars[0] {
+		case '\t':
+			tabLines++
+			prev = 0
+		case ' ':
+			n := len(leadingWhitespace(row.chars))
+			// skip lines that are only whitespace and the continuations
+			// of block comments, which are indented by one space
+			if n == len(row.chars) || row.chars[n] == '*' {
+				continue
+			}
+
+			spaceLines++
+			if d := n - prev; d > 0 {
+				deltas[d]++
+			}
+			prev = n
+		default:
+			prev = 0
+		}
+	}
+
+	if tabLines == 0 && spaceLines == 0 {
+		return false, 0, false
+	}
+
+	if tabLines >= spaceLines {
+		return false, 0, true
+	}
+
+	for d, n := range deltas {
+		if d > 8 {
+			continue
+		}
+
+		if n > deltas[width] || (n == deltas[width] && d < width) {
+			width = d
+		}
+	}
+
+	if width == 0 {
+		return false, 0, false
+	}
+
+	return true, width, true
+}
+
+// applyDetectedIndent changes the indentation options to match the style
+// used in the file. The tabstop is left alone, so tabs used for alignment in
+// a file indented with spaces look the same as before.
+func (e *Editor) applyDetectedIndent() {
+	if !e.cfg.DetectIndent {
+		return
+	}
+
+	spaces, width, ok := detectIndent(e.rows)
+	if !ok {
+		return
+	}
+
+	e.cfg.ExpandTab = spaces
+	if spaces {
+		e.cfg.ShiftWidth = width
+	} else {
+		e.cfg.ShiftWidth = e.cfg.Tabstop
+	}
+}
+
+// indentStyle describes the indentation options, e.g. "spaces:4" or "tabs:8".
+func (e *Editor) indentStyle() string {
+	if e.cfg.ExpandTab {
+		return fmt.Sprintf("spaces:%d", e.cfg.ShiftWidth)
+	}
+
+	return fmt.Sprintf("tabs:%d", e.cfg.Tabstop)
+}
@@ -0,0 +1,40 @@
+package editor
+
+import "testing"
+
+func TestApplyDetectedIndent(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []string
+		expandTab bool
+		sw        int
+		// the line after pressing Tab in insert mode at the start of the
+		// first line
+		tabbed string
+	}{
+		{"spaces", []string{"a", "    b", "        c", "    d"}, true, 4, "    a"},
+		{"two spaces", []string{"a", "  b", "    c", "  d"}, true, 2, "  a"},
+		{"tabs", []string{"a", "\tb", "\t\tc", "\td"}, false, 8, "\ta"},
+		{"not enough lines", []string{"a"}, false, 8, "\ta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.lines...)
+			e.cfg.Tabstop = 8
+			e.applyDetectedIndent()
+
+			if e.cfg.ExpandTab != tt.expandTab || e.cfg.ShiftWidth != tt.sw {
+				t.Errorf("expandtab = %t, shiftwidth = %d, want %t, %d", e.cfg.ExpandTab, e.cfg.ShiftWidth, tt.expandTab, tt.sw)
+			}
+			if e.cfg.Tabstop != 8 {
+				t.Errorf("tabstop = %d, want it kept at 8", e.cfg.Tabstop)
+			}
+
+			pressKeys(t, e, "i<Tab><C-c>")
+			if got := rowStrings(e.rows)[0]; got != tt.tabbed {
+				t.Errorf("after Tab: %q, want %q", got, tt.tabbed)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package editor
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Input is read from the terminal in chunks, each holding one key press, an
+// escape sequence, or many keys at once when text is pasted or comes from an
+// input method. The bytes are decoded as UTF-8, so each key is a whole rune.
+
+// maxInputRead is how many bytes are read from the terminal at once.
+const maxInputRead = 4096
+
+// inputPoll is how often a terminal that can wait for input is checked for it,
+// between which the editor may stop.
+const inputPoll = 100 * time.Millisecond
+
+// readKeys reads the keys that arrive together from the terminal. A character
+// or escape sequence cut off at the end of a read is kept for the next one.
+// Once the editor has stopped it reads nothing more and returns io.EOF, though
+// only between reads on terminals that can't wait for input.
+func (e *Editor) readKeys() ([]Key, error) {
+	buf := make([]byte, maxInputRead)
+	for {
+		if !e.awaitInput() {
+			return nil, io.EOF
+		}
+
+		n, err := e.term.Read(buf)
+		if n == 0 && err != nil {
+			return nil, err
+		}
+
+		e.input = append(e.input, buf[:n]...)
+		keys, rest := decodeKeys(e.input)
+		e.input = append(e.input[:0], rest...)
+		if len(keys) > 0 {
+			return keys, nil
+		}
+	}
+}
+
+// awaitInput waits until the terminal has input to read, reporting false if
+// the editor stops first. Terminals that can't wait are read straight away.
+func (e *Editor) awaitInput() bool {
+	w, ok := e.term.(inputWaiter)
+	for {
+		select {
+		case <-e.stopped:
+			return false
+		default:
+		}
+
+		if !ok || w.waitInput(inputPoll) {
+			return true
+		}
+	}
+}
+
+// decodeKeys turns input into keys, returning the bytes at the end that
+// don't make up a whole key yet. Escape sequences that aren't in
+// escapeCodeToKey are dropped, as are replies to the background color query
+// that come too late, and invalid UTF-8 becomes utf8.RuneError.
+func decodeKeys(b []byte) (keys []Key, rest []byte) {
+	for len(b) > 0 {
+		if b[0] == byte(keyEscape) && len(b) > 1 && b[1] == ']' {
+			n := backgroundReplyLength(b)
+			if n == 0 {
+				return keys, b
+			}
+			if n > 0 {
+				logDebugf("dropped a late background color reply")
+				b = b[n:]
+				continue
+			}
+		}
+
+		if b[0] == byte(keyEscape) && len(b) > 1 && (b[1] == '[' || b[1] == 'O') {
+			n := escapeSequenceLength(b)
+			if n == 0 {
+				return keys, b
+			}
+
+			if k, ok := escapeCodeToKey[string(b[:n])]; ok {
+				keys = append(keys, k)
+			} else if n == 1 {
+				keys = append(keys, keyEscape)
+			}
+			b = b[n:]
+			continue
+		}
+
+		if !utf8.FullRune(b) {
+			return keys, b
+		}
+
+		r, n := utf8.DecodeRune(b)
+		keys = append(keys, Key(r))
+		b = b[n:]
+	}
+
+	return keys, nil
+}
+
+// escapeSequenceLength returns the length of the CSI ("\x1b[") or SS3
+// ("\x1bO") sequence b starts with, or 0 if it isn't complete.
+func escapeSequenceLength(b []byte) int {
+	if b[1] == 'O' {
+		if len(b) < 3 {
+			return 0
+		}
+		return 3
+	}
+
+	// Parameter and intermediate bytes, then a final byte
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return i + 1
+		}
+		if b[i] < 0x20 || b[i] > 0x3f {
+			// Not a sequence after all, so only the escape is kept
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// isTextKey reports whether k is part of text that can be inserted as it
+// is: a printable character, a tab or a line break.
+func isTextKey(k Key) bool {
+	return isPrintable(k) || k == '\t' || k == keyEnter || k == keyCarriageReturn
+}
+
+// keysText returns the text of keys for which isTextKey is true. Terminals
+// send pasted line breaks as carriage returns, and "\r\n" is one line break.
+func keysText(keys []Key) string {
+	var b strings.Builder
+	for i, k := range keys {
+		switch {
+		case k == keyCarriageReturn && i+1 < len(keys) && keys[i+1] == keyEnter:
+		case k == keyCarriageReturn:
+			b.WriteRune('\n')
+		default:
+			b.WriteRune(rune(k))
+		}
+	}
+
+	return b.String()
+}
+
+// ProcessKeys processes keys that were read together. Several keys of text
+// typed at once in insert mode, as when pasting or from an input method, are
+// inserted as they are, without going through the insert mode bindings, so
+// that pasted code isn't indented again by autoindent.
+func (e *Editor) ProcessKeys(keys []Key) error {
+	if len(keys) > 1 && e.Mode == InsertMode && e.prompt == nil && len(e.pendingKeys) == 0 {
+		text := true
+		for _, k := range keys {
+			text = text && isTextKey(k)
+		}
+
+		if text {
+			if e.logsKeys() {
+				logDebugf("received keys: %s", keysNotation(keys))
+			}
+
+			err := e.Transaction(func() error {
+				e.InsertText(keysText(keys))
+				return nil
+			})
+			e.notify(EventCursorMoved)
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		// Checked for each key, as one can open a password prompt
+		if e.logsKeys() {
+			logDebugf("received key: %s", keysNotation([]Key{k}))
+		}
+
+		if err := e.ProcessKey(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InsertText inserts text at the cursor, splitting the row at each line
+// break, and leaves the cursor just after it.
+func (e *Editor) InsertText(text string) {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	if e.cy == len(e.rows) {
+		e.InsertRow(len(e.rows), []rune(""))
+	}
+
+	row := e.rows[e.cy].chars
+	lines := strings.Split(text, "\n")
+	last := len(lines) - 1
+
+	x := utf8.RuneCountInString(lines[last])
+	if last == 0 {
+		x += e.cx
+	}
+
+	lines[0] = string(row[:e.cx]) + lines[0]
+	lines[last] += string(row[e.cx:])
+
+	e.SetRow(e.cy, []rune(lines[0]))
+	for i, line := range lines[1:] {
+		e.InsertRow(e.cy+1+i, []rune(line))
+	}
+
+	e.SetY(e.cy + last)
+	e.SetX(x)
+}
@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	const reply = "\x1b]11;rgb:ffff/ffff/dddd"
+
+	tests := []struct {
+		name  string
+		input string
+		keys  []Key
+		rest  string
+	}{
+		{"text", "ab", []Key{'a', 'b'}, ""},
+		{"escape sequence", "a\x1b[Ab", []Key{'a', keyArrowUp, 'b'}, ""},
+		{"cut off sequence", "a\x1b[", []Key{'a'}, "\x1b["},
+		{"cut off rune", "a\xc3", []Key{'a'}, "\xc3"},
+		{"background reply with BEL", "a" + reply + "\x07b", []Key{'a', 'b'}, ""},
+		{"background reply with ST", reply + "\x1b\\b", []Key{'b'}, ""},
+		{"cut off background reply", "a" + reply[:8], []Key{'a'}, reply[:8]},
+		{"background reply cut off in ST", reply + "\x1b", nil, reply + "\x1b"},
+		{"cut off reply prefix", "a\x1b]1", []Key{'a'}, "\x1b]1"},
+		{"other OSC", "\x1b]2;x", []Key{keyEscape, ']', '2', ';', 'x'}, ""},
+		{"reply without an end", "\x1b]11;x\x1by", []Key{keyEscape, ']', '1', '1', ';', 'x', keyEscape, 'y'}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, rest := decodeKeys([]byte(tt.input))
+			if !reflect.DeepEqual(keys, tt.keys) || string(rest) != tt.rest {
+				t.Errorf("got %v, %q, want %v, %q", keys, rest, tt.keys, tt.rest)
+			}
+		})
+	}
+}
+
+// waitingTerminal has input to read once ready is closed.
+type waitingTerminal struct {
+	headlessTerminal
+	ready chan struct{}
+	reads int
+}
+
+func (t *waitingTerminal) Read(p []byte) (int, error) {
+	t.reads++
+	return copy(p, "x"), nil
+}
+
+func (t *waitingTerminal) waitInput(timeout time.Duration) bool {
+	select {
+	case <-t.ready:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestReadKeysStopsWithEditor(t *testing.T) {
+	term := &waitingTerminal{ready: make(chan struct{})}
+	e := &Editor{term: term, stopped: make(chan struct{})}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.readKeys()
+		done <- err
+	}()
+
+	close(e.stopped)
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("got %v, want io.EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("kept waiting for input after the editor stopped")
+	}
+
+	close(term.ready)
+	if term.reads != 0 {
+		t.Errorf("read %d times after the editor stopped", term.reads)
+	}
+}
@@ -0,0 +1,276 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Job is an external process running in the background.
+type Job struct {
+	ID      int
+	Command string
+	Started time.Time
+	// Done is set once the process has exited, with its error in Err
+	Done bool
+	Err  error
+
+	cmd *exec.Cmd
+}
+
+// JobCallbacks are called on the main loop with the output of a job, one
+// line at a time, and once it has exited. Any of them can be nil.
+type JobCallbacks struct {
+	Stdout func(line string)
+	Stderr func(line string)
+	Exit   func(err error)
+}
+
+// StartJob runs a command in the background, calling the callbacks with its
+// output as it comes.
+func (e *Editor) StartJob(name string, args []string, cb JobCallbacks) (*Job, error) {
+	cmd := exec.Command(name, args...)
+	// A process group of its own lets the job be killed along with its
+	// children, e.g. those of a shell
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e.lastJobID++
+	job := &Job{
+		ID:      e.lastJobID,
+		Command: strings.Join(append([]string{name}, args...), " "),
+		Started: time.Now(),
+		cmd:     cmd,
+	}
+	e.jobs = append(e.jobs, job)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go e.readJobOutput(stdout, cb.Stdout, &wg)
+	go e.readJobOutput(stderr, cb.Stderr, &wg)
+
+	go func() {
+		// The output has to be read before waiting
+		wg.Wait()
+		err := cmd.Wait()
+
+		e.runOnMain(func() {
+			job.Done = true
+			job.Err = err
+			if cb.Exit != nil {
+				cb.Exit(err)
+			}
+		})
+	}()
+
+	return job, nil
+}
+
+const (
+	// The longest line of output passed to the callbacks; longer lines are
+	// replaced with a note
+	maxJobLine = 1024 * 1024
+	// How many lines are passed to the main loop at once
+	maxJobBatch = 256
+)
+
+// readJobOutput passes each line of r to fn on the main loop. Lines that
+// come faster than the main loop takes them are passed in batches. All of r
+// is read, even without fn or once the editor has exited, so that the job
+// never blocks on writing its output.
+func (e *Editor) readJobOutput(r io.Reader, fn func(string), wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if fn == nil {
+		io.Copy(io.Discard, r)
+		return
+	}
+
+	lines := make(chan string, maxJobBatch)
+	go scanJobOutput(r, lines)
+
+	stopped := false
+	for line := range lines {
+		if stopped {
+			continue
+		}
+
+		batch := []string{line}
+	more:
+		for len(batch) < maxJobBatch {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					break more
+				}
+				batch = append(batch, line)
+			default:
+				break more
+			}
+		}
+
+		stopped = !e.runOnMain(func() {
+			for _, line := range batch {
+				fn(line)
+			}
+		})
+	}
+}
+
+// scanJobOutput sends the lines of r to lines, closing it at the end.
+func scanJobOutput(r io.Reader, lines chan<- string) {
+	defer close(lines)
+
+	br := bufio.NewReaderSize(r, 4096)
+	for {
+		s := bufio.NewScanner(br)
+		s.Buffer(make([]byte, 4096), maxJobLine)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+
+		if s.Err() != bufio.ErrTooLong {
+			// Nothing more to read, or a read error
+			if s.Err() != nil {
+				logErrorf("job output: %s", s.Err())
+				io.Copy(io.Discard, r)
+			}
+			return
+		}
+
+		// Skip the rest of the long line and carry on with the next
+		logDebugf("job output: line longer than %d bytes", maxJobLine)
+		lines <- "[line too long]"
+		if _, err := br.ReadBytes('\n'); err != nil {
+			io.Copy(io.Discard, r)
+			return
+		}
+	}
+}
+
+// jobList returns the jobs started since the editor started, oldest first.
+func (e *Editor) jobList() []*Job {
+	return e.jobs
+}
+
+// killJob kills the job with the given ID.
+func (e *Editor) killJob(id int) error {
+	for _, job := range e.jobs {
+		if job.ID != id {
+			continue
+		}
+
+		if job.Done {
+			return errors.Errorf("job %d has already exited", id)
+		}
+
+		return job.kill()
+	}
+
+	return errors.Errorf("no such job: %d", id)
+}
+
+// kill kills the process group of the job.
+func (job *Job) kill() error {
+	return syscall.Kill(-job.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// killJobs kills the jobs that are still running.
+func (e *Editor) killJobs() {
+	for _, job := range e.jobs {
+		if !job.Done {
+			job.kill()
+		}
+	}
+}
+
+// jobStatus describes the state of a job, e.g. "running 3s" or "exit 1".
+func jobStatus(job *Job) string {
+	if !job.Done {
+		return "running " + time.Since(job.Started).Round(time.Second).String()
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(job.Err, &exitErr) {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return fmt.Sprintf("exit %d", code)
+		}
+		return "killed"
+	}
+
+	if job.Err != nil {
+		return job.Err.Error()
+	}
+
+	return "done"
+}
+
+// jobCommand runs a shell command in the background, adding its output to
+// the messages: "job <command>".
+func jobCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: job <command>")
+	}
+
+	// The callbacks run on the main loop, so not before job is set
+	var job *Job
+	job, err := e.StartJob("sh", []string{"-c", args}, JobCallbacks{
+		Stdout: func(line string) { e.SetMessage("%s", line) },
+		Stderr: func(line string) { e.SetMessage("%s", line) },
+		Exit: func(err error) {
+			e.SetMessage("job %d (%s): %s", job.ID, args, jobStatus(job))
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	e.SetMessage("job %d: %s", job.ID, args)
+	return nil
+}
+
+// jobsCommand lists the jobs, or kills one: "jobs [kill <id>]".
+func jobsCommand(e SDK, r *Range, args string) error {
+	if len(args) > 0 {
+		sub, arg, _ := strings.Cut(args, " ")
+		id, err := strconv.Atoi(strings.TrimSpace(arg))
+		if sub != "kill" || err != nil {
+			return errors.New("usage: jobs [kill <id>]")
+		}
+
+		return e.killJob(id)
+	}
+
+	jobs := e.jobList()
+	if len(jobs) == 0 {
+		e.SetMessage("no jobs")
+		return nil
+	}
+
+	lines := make([]string, len(jobs))
+	for i, job := range jobs {
+		lines[i] = fmt.Sprintf("%3d  %-12s  %s", job.ID, jobStatus(job), job.Command)
+	}
+
+	e.ShowLines(lines)
+	return nil
+}
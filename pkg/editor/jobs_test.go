@@ -0,0 +1,105 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// runJob runs a shell command as a job, running the main loop until it
+// exits, and returns the lines of its output.
+func runJob(t *testing.T, e *Editor, command string) []string {
+	t.Helper()
+
+	var lines []string
+	done := false
+	_, err := e.StartJob("sh", []string{"-c", command}, JobCallbacks{
+		Stdout: func(line string) { lines = append(lines, line) },
+		Exit:   func(error) { done = true },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for !done {
+		select {
+		case fn := <-e.mainChan:
+			fn()
+		case <-timeout:
+			t.Fatalf("%s: still running", command)
+		}
+	}
+
+	return lines
+}
+
+func TestJobOutput(t *testing.T) {
+	long := fmt.Sprintf("head -c %d /dev/zero | tr '\\0' x", maxJobLine+1)
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"lines", "printf 'a\\nb\\n\\nc'", []string{"a", "b", "", "c"}},
+		{"no output", "true", nil},
+		{"a line too long", "echo a; " + long + "; printf '\\nb\\n'", []string{"a", "[line too long]", "b"}},
+		{"ends with a line too long", "echo a; " + long, []string{"a", "[line too long]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t)
+			if got := runJob(t, e, tt.command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %.40q, want %.40q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobOutputInOrder(t *testing.T) {
+	e := newTestEditor(t)
+
+	lines := runJob(t, e, "seq 1 20000")
+	if len(lines) != 20000 {
+		t.Fatalf("got %d lines, want 20000", len(lines))
+	}
+	for i, line := range lines {
+		if line != strconv.Itoa(i+1) {
+			t.Fatalf("line %d is %q", i+1, line)
+		}
+	}
+}
+
+func TestJobAfterEditorExits(t *testing.T) {
+	e := newTestEditor(t)
+
+	// Nothing reads mainChan any more
+	close(e.stopped)
+
+	done := filepath.Join(t.TempDir(), "done")
+	_, err := e.StartJob("sh", []string{"-c", "seq 1 100000; touch " + done}, JobCallbacks{
+		Stdout: func(string) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(done); err == nil {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("the job is blocked on writing its output")
+		}
+	}
+
+	if e.runOnMain(func() {}) {
+		t.Error("runOnMain ran after the editor exited")
+	}
+}
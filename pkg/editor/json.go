@@ -0,0 +1,65 @@
+package editor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// jsonCommand rewrites the JSON in the range, or the whole file, indented
+// with the current indentation settings or with all the whitespace
+// removed: "json fmt|min". The line of a syntax error is highlighted.
+func jsonCommand(e SDK, r *Range, args string) error {
+	if args != "fmt" && args != "min" {
+		return errors.New("usage: json fmt|min")
+	}
+
+	if e.NumRows() == 0 {
+		return errors.New("no JSON to format")
+	}
+
+	rng := r.orAll(e)
+	var lines []string
+	for y := rng.Start; y <= rng.End; y++ {
+		lines = append(lines, string(e.Row(y)))
+	}
+	src := []byte(strings.Join(lines, "\n"))
+
+	// A selection keeps the indentation of its first line
+	prefix := string(leadingWhitespace(e.Row(rng.Start)))
+
+	var (
+		b   bytes.Buffer
+		err error
+	)
+	if args == "fmt" {
+		indent := "\t"
+		if opts := e.Options(); opts.ExpandTab {
+			indent = strings.Repeat(" ", opts.ShiftWidth)
+		}
+		err = json.Indent(&b, src, prefix, indent)
+	} else {
+		err = json.Compact(&b, src)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		y := rng.Start + bytes.Count(src[:syntaxErr.Offset], []byte("\n"))
+		if syntaxErr.Offset > 0 && src[syntaxErr.Offset-1] == '\n' {
+			// the error is about the newline that ends the line before
+			y--
+		}
+
+		e.SetY(y)
+		e.SetX(0)
+		e.highlightError(y)
+		return fmt.Errorf("json: line %d: %s", y+1, err)
+	} else if err != nil {
+		return fmt.Errorf("json: %s", err)
+	}
+
+	replaceRows(e, rng, strings.Split(prefix+b.String(), "\n"))
+	return nil
+}
@@ -0,0 +1,120 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// keyNames are the names used in key notation for keys that aren't written
+// as themselves, e.g. "<Enter>". Control keys without a name here are
+// written as "<C-x>".
+var keyNames = map[Key]string{
+	keyEnter:          "Enter",
+	keyCarriageReturn: "CR",
+	keyBackspace:      "BS",
+	keyEscape:         "Esc",
+	keyArrowLeft:      "Left",
+	keyArrowRight:     "Right",
+	keyArrowUp:        "Up",
+	keyArrowDown:      "Down",
+	keyDelete:         "Del",
+	keyPageUp:         "PageUp",
+	keyPageDown:       "PageDown",
+	keyHome:           "Home",
+	keyEnd:            "End",
+	keyF1:             "F1",
+	Key('\t'):         "Tab",
+	keyShiftTab:       "S-Tab",
+	Key(' '):          "Space",
+	Key('<'):          "lt",
+}
+
+// keyNotation returns the notation of a single key as used in bindings and
+// in the config file, e.g. "j", "<C-s>" or "<Enter>".
+func keyNotation(k Key) string {
+	if name, ok := keyNames[k]; ok {
+		return "<" + name + ">"
+	}
+
+	if k > 0 && k <= 26 {
+		return fmt.Sprintf("<C-%c>", 'a'+k-1)
+	}
+
+	return string(rune(k))
+}
+
+// keysNotation returns the notation of a sequence of keys.
+func keysNotation(keys []Key) string {
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(keyNotation(k))
+	}
+
+	return b.String()
+}
+
+// parseKeys parses a sequence of keys written in key notation. Names are
+// case insensitive, so "<c-S>" is the same as "<C-s>". "<leader>" stands for
+// the given leader key.
+func parseKeys(s string, leader Key) ([]Key, error) {
+	var keys []Key
+
+	for len(s) > 0 {
+		if s[0] == '<' {
+			if end := strings.IndexByte(s, '>'); end > 1 {
+				name := s[1:end]
+				if strings.EqualFold(name, "leader") {
+					keys = append(keys, leader)
+					s = s[end+1:]
+					continue
+				}
+
+				k, err := parseKeyName(name)
+				if err != nil {
+					return nil, err
+				}
+
+				keys = append(keys, k)
+				s = s[end+1:]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s)
+		keys = append(keys, Key(r))
+		s = s[size:]
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys given")
+	}
+
+	return keys, nil
+}
+
+func parseKeyName(name string) (Key, error) {
+	for k, n := range keyNames {
+		if strings.EqualFold(n, name) {
+			return k, nil
+		}
+	}
+
+	if len(name) == 3 && strings.EqualFold(name[:2], "C-") {
+		if c := name[2] | 0x20; c >= 'a' && c <= 'z' {
+			return Key(ctrl(c)), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown key: <%s>", name)
+}
+
+// normalizeKeys rewrites keys in the canonical key notation.
+func normalizeKeys(s string, leader Key) (string, error) {
+	keys, err := parseKeys(s, leader)
+	if err != nil {
+		return "", err
+	}
+
+	return keysNotation(keys), nil
+}
@@ -0,0 +1,228 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Number of yanks and deletions kept in the kill ring
+const killRingSize = 30
+
+// Yank is text that was yanked or deleted. Linewise text is made of whole
+// lines and is pasted as new lines, rather than within a line. Blockwise
+// text is a rectangle, one line per row, and is pasted as a rectangle again
+// at the cursor's column of the rows from the cursor's down.
+type Yank struct {
+	Text      string
+	Linewise  bool
+	Blockwise bool
+}
+
+// AddYank puts text at the front of the kill ring, dropping the oldest
+// entry once the ring is full.
+func (e *Editor) AddYank(text string, linewise bool) {
+	if len(text) == 0 && !linewise {
+		return
+	}
+
+	e.addToKillRing(Yank{Text: text, Linewise: linewise})
+}
+
+// addBlockYank puts a rectangle of text, with its rows separated by
+// newlines, at the front of the kill ring.
+func (e *Editor) addBlockYank(text string) {
+	e.addToKillRing(Yank{Text: text, Blockwise: true})
+}
+
+func (e *Editor) addToKillRing(y Yank) {
+	e.killRing = append([]Yank{y}, e.killRing...)
+	if len(e.killRing) > killRingSize {
+		e.killRing = e.killRing[:killRingSize]
+	}
+}
+
+// Yanks returns the entries of the kill ring, most recent first.
+func (e *Editor) Yanks() []Yank {
+	return e.killRing
+}
+
+var errEmptyKillRing = errors.New("nothing yanked or deleted yet")
+
+func yankLine(e SDK) error {
+	if e.Y() >= e.NumRows() {
+		return nil
+	}
+
+	e.AddYank(string(e.Row(e.Y())), true)
+	return nil
+}
+
+func deleteLine(e SDK) error {
+	if e.Y() >= e.NumRows() {
+		return nil
+	}
+
+	e.AddYank(string(e.Row(e.Y())), true)
+	e.DeleteRow(e.Y())
+	return nil
+}
+
+func clearLine(e SDK) error {
+	if e.Y() >= e.NumRows() {
+		return nil
+	}
+
+	e.AddYank(string(e.Row(e.Y())), false)
+	e.SetRow(e.Y(), []rune(""))
+	return nil
+}
+
+func deleteWordBefore(e SDK) error {
+	start := e.BackWord()
+	if start >= e.X() {
+		return nil
+	}
+
+	e.AddYank(string(e.Row(e.Y())[start:e.X()]), false)
+	e.Delete(e.Y(), start, e.X()-1)
+	e.SetX(start)
+	return nil
+}
+
+// paste returns an action pasting the most recent entry of the kill ring
+// after the cursor, or before it.
+func paste(after bool) func(e SDK) error {
+	return func(e SDK) error {
+		yanks := e.Yanks()
+		if len(yanks) == 0 {
+			return errEmptyKillRing
+		}
+
+		pasteYank(e, yanks[0], after)
+		return nil
+	}
+}
+
+// pasteYank pastes linewise text below or above the current line, and
+// other text after or at the cursor.
+func pasteYank(e SDK, y Yank, after bool) {
+	lines := strings.Split(y.Text, "\n")
+
+	if y.Blockwise {
+		pasteBlock(e, lines, after)
+		return
+	}
+
+	if y.Linewise || e.NumRows() == 0 {
+		at := e.Y()
+		if after && e.NumRows() > 0 {
+			at++
+		}
+		for i, line := range lines {
+			e.InsertRow(at+i, []rune(line))
+		}
+
+		e.SetY(at)
+		e.SetX(len(leadingWhitespace(e.Row(at))))
+		return
+	}
+
+	row := e.Row(e.Y())
+	x := e.X()
+	if after && x < len(row) {
+		x++
+	}
+
+	before, rest := string(row[:x]), string(row[x:])
+	lines[0] = before + lines[0]
+	last := len(lines) - 1
+	end := len([]rune(lines[last]))
+	lines[last] += rest
+
+	e.SetRow(e.Y(), []rune(lines[0]))
+	for i, line := range lines[1:] {
+		e.InsertRow(e.Y()+1+i, []rune(line))
+	}
+
+	// leave the cursor on the last pasted character
+	e.SetY(e.Y() + last)
+	if end > 0 {
+		e.SetX(end - 1)
+	}
+}
+
+// pasteBlock pastes the lines of a rectangle after or at the cursor's column
+// of the rows from the cursor's down, adding rows past the end of the
+// buffer. Rows too short to reach the column are padded with spaces, and so
+// are the lines of the rectangle when there is text after them, keeping the
+// rectangle's right edge straight.
+func pasteBlock(e SDK, lines []string, after bool) {
+	x := e.X()
+	if e.Y() < e.NumRows() && after && x < len(e.Row(e.Y())) {
+		x++
+	}
+
+	width := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > width {
+			width = n
+		}
+	}
+
+	for i, line := range lines {
+		y := e.Y() + i
+		if y >= e.NumRows() {
+			e.InsertRow(y, []rune(""))
+		}
+
+		row := e.Row(y)
+		chars := append([]rune{}, row...)
+		for len(chars) < x {
+			chars = append(chars, ' ')
+		}
+
+		text := []rune(line)
+		if len(row) > x {
+			for len(text) < width {
+				text = append(text, ' ')
+			}
+		}
+
+		chars = append(chars[:x], append(text, chars[x:]...)...)
+		e.SetRow(y, chars)
+	}
+
+	// leave the cursor on the first pasted character
+	e.SetX(x)
+}
+
+// pasteFromHistory picks an entry of the kill ring to paste after the
+// cursor.
+func pasteFromHistory(e SDK) error {
+	yanks := e.Yanks()
+	if len(yanks) == 0 {
+		return errEmptyKillRing
+	}
+
+	items := make([]string, len(yanks))
+	for i, y := range yanks {
+		lines := strings.Split(y.Text, "\n")
+		items[i] = lines[0]
+		if len(lines) > 1 {
+			items[i] += fmt.Sprintf(" (+%d lines)", len(lines)-1)
+		}
+		if y.Linewise {
+			items[i] = "line: " + items[i]
+		} else if y.Blockwise {
+			items[i] = "block: " + items[i]
+		}
+	}
+
+	e.ShowMenu(items, 0, func(i int) error {
+		pasteYank(e, yanks[i], true)
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,52 @@
+package editor
+
+import "errors"
+
+// uniqCommand removes the lines of the range, or the whole file, that are
+// the same as the line before them. With "!" every repeat of a line is
+// removed, wherever it is in the range.
+func uniqCommand(e SDK, r *Range, args string) error {
+	if args != "" && args != "!" {
+		return errors.New("usage: uniq[!]")
+	}
+
+	rng := r.orAll(e)
+	seen := make(map[string]bool)
+	var (
+		prev    string
+		removed int
+	)
+	for y := rng.Start; y <= rng.End-removed; {
+		line := string(e.Row(y))
+		dup := y > rng.Start && line == prev
+		if args == "!" {
+			dup = seen[line]
+			seen[line] = true
+		}
+		prev = line
+
+		if dup {
+			e.DeleteRow(y)
+			removed++
+			continue
+		}
+		y++
+	}
+
+	e.WrapCursorY()
+	e.SetMessage("uniq: %d lines removed", removed)
+	return nil
+}
+
+// reverseCommand reverses the order of the lines in the range, or the
+// whole file.
+func reverseCommand(e SDK, r *Range, args string) error {
+	rng := r.orAll(e)
+	for i, j := rng.Start, rng.End; i < j; i, j = i+1, j-1 {
+		a, b := e.Row(i), e.Row(j)
+		e.SetRow(i, b)
+		e.SetRow(j, a)
+	}
+
+	return nil
+}
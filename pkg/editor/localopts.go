@@ -0,0 +1,172 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The options set with ":set" while editing a file, like its filetype or
+// wrap, are remembered for that file in the state directory, and set again
+// whenever it is opened. Only the options in localOptions are remembered, and
+// not when the config file, a filetype command or a modeline sets them, nor
+// for secret files. ":forget-options" forgets them.
+
+// optionsState is the kind of data in the state directory holding the
+// options set for a file.
+const optionsState = "options"
+
+// localOptions maps the options remembered for each file, and their short
+// names, to their long names.
+var localOptions = map[string]string{
+	"filetype":       "filetype",
+	"ft":             "filetype",
+	"wrap":           "wrap",
+	"expandtab":      "expandtab",
+	"et":             "expandtab",
+	"tabstop":        "tabstop",
+	"ts":             "tabstop",
+	"shiftwidth":     "shiftwidth",
+	"sw":             "shiftwidth",
+	"autoindent":     "autoindent",
+	"ai":             "autoindent",
+	"number":         "number",
+	"nu":             "number",
+	"trimwhitespace": "trimwhitespace",
+}
+
+// fileOptions holds the ":set" arguments remembered for a file, by the long
+// name of their option.
+type fileOptions struct {
+	Options map[string]string `json:"options"`
+}
+
+// localOptionName returns the long name of the option a ":set" argument
+// changes, if it is remembered for each file.
+func localOptionName(arg string) (string, bool) {
+	name, _, hasValue := strings.Cut(arg, "=")
+	name = strings.TrimSuffix(name, "!")
+
+	if long, ok := localOptions[name]; ok {
+		// ":set filetype" only shows it
+		return long, hasValue || long != "filetype"
+	}
+	if strings.HasPrefix(name, "no") && !hasValue {
+		long, ok := localOptions[name[2:]]
+		return long, ok
+	}
+
+	return "", false
+}
+
+// optionArg returns the ":set" argument giving an option its current value,
+// e.g. "nowrap" or "tabstop=4".
+func (e *Editor) optionArg(name string) string {
+	if name == "filetype" {
+		return "filetype=" + e.Filetype()
+	}
+
+	if opt, ok := boolOptions[name]; ok {
+		if *opt(&e.cfg) {
+			return name
+		}
+		return "no" + name
+	}
+
+	if opt, ok := intOptions[name]; ok {
+		return fmt.Sprintf("%s=%d", name, *opt(&e.cfg))
+	}
+
+	return name
+}
+
+// rememberOption remembers the option set by a ":set" argument for the file
+// being edited.
+func (e *Editor) rememberOption(arg string) {
+	if e.applyingOptions > 0 || len(e.filename) == 0 || e.isSecret() {
+		return
+	}
+
+	name, ok := localOptionName(arg)
+	if !ok {
+		return
+	}
+
+	opts := fileOptions{Options: map[string]string{}}
+	path, err := statePath(optionsState, e.filename)
+	if err != nil {
+		return
+	}
+	readJSON(path+".json", &opts)
+	if opts.Options == nil {
+		opts.Options = map[string]string{}
+	}
+
+	opts.Options[name] = e.optionArg(name)
+	if err := writeJSON(path+".json", opts); err != nil {
+		logErrorf("saving the options of %s: %s", e.filename, err)
+	}
+}
+
+// restoreOptions sets the options remembered for the file being edited, the
+// filetype first since setting it resets the others.
+func (e *Editor) restoreOptions() error {
+	if len(e.filename) == 0 {
+		return nil
+	}
+
+	path, err := statePath(optionsState, e.filename)
+	if err != nil {
+		return nil
+	}
+
+	var opts fileOptions
+	if err := readJSON(path+".json", &opts); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(opts.Options))
+	for name := range opts.Options {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "filetype") != (names[j] == "filetype") {
+			return names[i] == "filetype"
+		}
+		return names[i] < names[j]
+	})
+
+	e.applyingOptions++
+	defer func() { e.applyingOptions-- }()
+
+	for _, name := range names {
+		if err := e.SetOption(opts.Options[name]); err != nil {
+			return errors.Wrap(err, "remembered options")
+		}
+	}
+
+	return nil
+}
+
+// forgetOptionsCommand forgets the options remembered for the file, which
+// stay set until it is opened again: "forget-options".
+func forgetOptionsCommand(e SDK, r *Range, args string) error {
+	if len(e.Filename()) == 0 {
+		return errors.New("no file name")
+	}
+
+	path, err := statePath(optionsState, e.Filename())
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		e.SetMessage("no options remembered for %s", e.Filename())
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package editor
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// LogLevel is how much the editor writes to its log file.
+type LogLevel int
+
+const (
+	LogOff LogLevel = iota
+	LogError
+	LogInfo
+	// Everything, including every key press
+	LogDebug
+)
+
+var logLevelNames = []string{
+	LogOff:   "off",
+	LogError: "error",
+	LogInfo:  "info",
+	LogDebug: "debug",
+}
+
+// ParseLogLevel returns the log level with the given name, e.g. "debug".
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, n := range logLevelNames {
+		if strings.EqualFold(n, name) {
+			return LogLevel(level), nil
+		}
+	}
+
+	return LogOff, errors.Errorf("unknown log level: %s (one of %s)",
+		name, strings.Join(logLevelNames, ", "))
+}
+
+func (l LogLevel) String() string {
+	return logLevelNames[l]
+}
+
+// logLevel is the LogLevel set by StartLogging. Logs are off by default.
+// It is shared by every editor of the process, like the log file, and read
+// and written atomically.
+var logLevel int32
+
+// logger writes to the log file. It is the package's own, leaving the
+// standard logger to the program using the editor.
+var logger = log.New(io.Discard, "", log.LstdFlags)
+
+// logPath returns the path of the log file, file unless it is empty.
+func logPath(file string) string {
+	if len(file) != 0 {
+		return file
+	}
+
+	if path := os.Getenv("JK_LOG_FILE"); len(path) != 0 {
+		return path
+	}
+
+	dir := stateDir()
+	if len(dir) == 0 {
+		return ""
+	}
+
+	return filepath.Join(dir, "jk.log")
+}
+
+// StartLogging sets the log level, from name or else from $JK_LOG, and
+// opens the log file unless logging is off. The log file is file, or the
+// default one if it is empty. The returned function closes the file.
+func StartLogging(name, file string) (func(), error) {
+	if len(name) == 0 {
+		name = os.Getenv("JK_LOG")
+	}
+
+	// Nothing is logged to stderr, as that is the screen
+	logger.SetOutput(io.Discard)
+	atomic.StoreInt32(&logLevel, int32(LogOff))
+
+	if len(name) == 0 {
+		return func() {}, nil
+	}
+
+	level, err := ParseLogLevel(name)
+	if err != nil || level == LogOff {
+		return func() {}, err
+	}
+
+	path := logPath(file)
+	if len(path) == 0 {
+		return nil, errors.New("no log file: set JK_LOG_FILE")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	// The log can hold text from the file being edited
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening log file %s", path)
+	}
+
+	logger.SetOutput(f)
+	atomic.StoreInt32(&logLevel, int32(level))
+	logInfof("logging at level %s", level)
+
+	return func() { f.Close() }, nil
+}
+
+func logf(level LogLevel, format string, args ...interface{}) {
+	if level <= LogLevel(atomic.LoadInt32(&logLevel)) {
+		logger.Printf(strings.ToUpper(level.String())+" "+format, args...)
+	}
+}
+
+func logErrorf(format string, args ...interface{}) { logf(LogError, format, args...) }
+func logInfof(format string, args ...interface{})  { logf(LogInfo, format, args...) }
+func logDebugf(format string, args ...interface{}) { logf(LogDebug, format, args...) }
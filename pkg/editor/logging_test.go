@@ -0,0 +1,46 @@
+package editor
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartLogging(t *testing.T) {
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+
+	path := filepath.Join(t.TempDir(), "jk.log")
+	closeLog, err := StartLogging("info", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StartLogging("", "")
+	defer closeLog()
+
+	logInfof("from the editor")
+	log.Print("from the program")
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "from the editor") || strings.Contains(string(out), "from the program") {
+		t.Errorf("log file:\n%s", out)
+	}
+	if !strings.Contains(std.String(), "from the program") {
+		t.Errorf("the standard logger was changed, it got %q", std.String())
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("log file permissions are %o, want 600", perm)
+	}
+}
@@ -0,0 +1,279 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Lua scripts can use the editor through the "jk" table. Lines and columns
+// count from 1, as is usual in Lua:
+//
+//	jk.cursor()                    line and column of the cursor
+//	jk.set_cursor(line, col)       move the cursor
+//	jk.line([line])                text of a line, the cursor's by default
+//	jk.set_line(line, text)        replace the text of a line
+//	jk.insert_line(line, text)     insert a line before line
+//	jk.delete_line(line)           delete a line
+//	jk.line_count()                number of lines
+//	jk.filename(), jk.filetype()
+//	jk.message(text)               show a message
+//	jk.exec(command)               run an ex command
+//	jk.map(keymap, keys, action)   bind keys to an action
+//	jk.action(name, desc, fn)      add an action calling fn()
+//	jk.command(name, fn)           add an ex command calling fn(args, first, last),
+//	                               where first and last are the range, or nil
+//	jk.prompt(text, fn)            ask for input and call fn(input)
+//	jk.on(event, fn)               call fn() whenever event happens, e.g. "BufWritePre"
+//	jk.var(name)                   value of a variable, e.g. "b:name", or nil
+//	jk.set_var(name, value)        set a variable, or unset it when value is nil
+
+// luaRuntime runs the Lua scripts of an editor.
+type luaRuntime struct {
+	L *lua.LState
+	// The actions and commands defined by scripts, which are only this
+	// editor's. Unlike the built-in ones they can be defined again, e.g.
+	// when the config is reloaded.
+	actions  map[string]Action
+	commands map[string]ExCommand
+}
+
+// initScriptPath returns the path of the Lua script run after the config
+// file.
+func initScriptPath() string {
+	path := configPath()
+	if len(path) == 0 {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(path), "init.lua")
+}
+
+// runInitScript runs the init script, if there is one.
+func (e *Editor) runInitScript() error {
+	path := initScriptPath()
+	if len(path) == 0 {
+		return nil
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return e.luaState().DoFile(path)
+}
+
+// luaState returns the Lua state of the editor, creating it the first time.
+func (e *Editor) luaState() *lua.LState {
+	if e.lua != nil {
+		return e.lua.L
+	}
+
+	e.lua = &luaRuntime{
+		L:        lua.NewState(),
+		actions:  map[string]Action{},
+		commands: map[string]ExCommand{},
+	}
+
+	L := e.lua.L
+	L.SetGlobal("jk", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"cursor":      e.luaCursor,
+		"set_cursor":  e.luaSetCursor,
+		"line":        e.luaLine,
+		"set_line":    e.luaSetLine,
+		"insert_line": e.luaInsertLine,
+		"delete_line": e.luaDeleteLine,
+		"line_count":  e.luaLineCount,
+		"filename":    e.luaFilename,
+		"filetype":    e.luaFiletype,
+		"message":     e.luaMessage,
+		"exec":        e.luaExec,
+		"map":         e.luaMap,
+		"action":      e.luaDefineAction,
+		"command":     e.luaDefineCommand,
+		"prompt":      e.luaPrompt,
+		"on":          e.luaOn,
+		"var":         e.luaVar,
+		"set_var":     e.luaSetVar,
+	}))
+
+	return L
+}
+
+// checkLine returns the row of the line given as argument n, which must
+// exist. Lines up to max, counting from 1, are allowed.
+func checkLine(L *lua.LState, n, max int) int {
+	line := L.CheckInt(n)
+	if line < 1 || line > max {
+		L.ArgError(n, "line out of range")
+	}
+
+	return line - 1
+}
+
+// luaCall calls a Lua function, returning its errors.
+func (e *Editor) luaCall(fn *lua.LFunction, args ...lua.LValue) error {
+	return e.lua.L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, args...)
+}
+
+func (e *Editor) luaCursor(L *lua.LState) int {
+	L.Push(lua.LNumber(e.cy + 1))
+	L.Push(lua.LNumber(e.cx + 1))
+	return 2
+}
+
+func (e *Editor) luaSetCursor(L *lua.LState) int {
+	e.SetY(checkLine(L, 1, e.NumRows()))
+	e.SetX(L.OptInt(2, 1) - 1)
+	e.WrapCursorX()
+	return 0
+}
+
+func (e *Editor) luaLine(L *lua.LState) int {
+	y := e.cy
+	if L.GetTop() > 0 {
+		y = checkLine(L, 1, e.NumRows())
+	}
+
+	if y < e.NumRows() {
+		L.Push(lua.LString(string(e.Row(y))))
+	} else {
+		L.Push(lua.LString(""))
+	}
+	return 1
+}
+
+func (e *Editor) luaSetLine(L *lua.LState) int {
+	e.SetRow(checkLine(L, 1, e.NumRows()), []rune(L.CheckString(2)))
+	return 0
+}
+
+func (e *Editor) luaInsertLine(L *lua.LState) int {
+	e.InsertRow(checkLine(L, 1, e.NumRows()+1), []rune(L.CheckString(2)))
+	return 0
+}
+
+func (e *Editor) luaDeleteLine(L *lua.LState) int {
+	e.DeleteRow(checkLine(L, 1, e.NumRows()))
+	e.WrapCursorY()
+	return 0
+}
+
+func (e *Editor) luaLineCount(L *lua.LState) int {
+	L.Push(lua.LNumber(e.NumRows()))
+	return 1
+}
+
+func (e *Editor) luaFilename(L *lua.LState) int {
+	L.Push(lua.LString(e.Filename()))
+	return 1
+}
+
+func (e *Editor) luaFiletype(L *lua.LState) int {
+	L.Push(lua.LString(e.Filetype()))
+	return 1
+}
+
+func (e *Editor) luaMessage(L *lua.LState) int {
+	e.SetMessage("%s", L.CheckString(1))
+	return 0
+}
+
+func (e *Editor) luaExec(L *lua.LState) int {
+	if err := e.ExecCommand(L.CheckString(1)); err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+func (e *Editor) luaMap(L *lua.LState) int {
+	if err := e.Map(L.CheckString(1), L.CheckString(2), L.CheckString(3)); err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+func (e *Editor) luaDefineAction(L *lua.LState) int {
+	name, desc, fn := L.CheckString(1), L.CheckString(2), L.CheckFunction(3)
+	if _, ok := e.actions[name]; ok {
+		L.ArgError(1, "action "+name+" already exists")
+	}
+
+	e.lua.actions[name] = Action{desc, func(SDK) error { return e.luaCall(fn) }}
+	return 0
+}
+
+func (e *Editor) luaDefineCommand(L *lua.LState) int {
+	name, fn := L.CheckString(1), L.CheckFunction(2)
+	if _, ok := e.commands[name]; ok {
+		L.ArgError(1, "command "+name+" already exists")
+	}
+
+	e.lua.commands[name] = func(_ SDK, r *Range, args string) error {
+		if r == nil {
+			return e.luaCall(fn, lua.LString(args), lua.LNil, lua.LNil)
+		}
+
+		return e.luaCall(fn, lua.LString(args), lua.LNumber(r.Start+1), lua.LNumber(r.End+1))
+	}
+	return 0
+}
+
+func (e *Editor) luaPrompt(L *lua.LState) int {
+	text, fn := L.CheckString(1), L.CheckFunction(2)
+	e.StaticPrompt(text, func(input string) error {
+		return e.luaCall(fn, lua.LString(input))
+	}, nil)
+	return 0
+}
+
+func (e *Editor) luaOn(L *lua.LState) int {
+	event, err := parseEvent(L.CheckString(1))
+	if err != nil {
+		L.ArgError(1, err.Error())
+	}
+
+	fn := L.CheckFunction(2)
+	e.On(event, func(SDK) error { return e.luaCall(fn) })
+	return 0
+}
+
+func (e *Editor) luaVar(L *lua.LState) int {
+	if v, ok := e.Var(L.CheckString(1)); ok {
+		L.Push(lua.LString(v))
+	} else {
+		L.Push(lua.LNil)
+	}
+	return 1
+}
+
+func (e *Editor) luaSetVar(L *lua.LState) int {
+	name := L.CheckString(1)
+
+	var err error
+	if L.Get(2) == lua.LNil {
+		err = e.UnsetVar(name)
+	} else {
+		err = e.SetVar(name, L.CheckString(2))
+	}
+	if err != nil {
+		L.ArgError(1, err.Error())
+	}
+	return 0
+}
+
+// luaCommand runs Lua code: "lua <code>".
+func luaCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: lua <code>")
+	}
+
+	return e.runLua(args)
+}
+
+// runLua runs Lua code with the "jk" table available.
+func (e *Editor) runLua(code string) error {
+	return e.luaState().DoString(code)
+}
@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"testing"
+)
+
+// The actions and commands a script defines belong to its editor only.
+func TestLuaDefinitionsArePerEditor(t *testing.T) {
+	e1, e2 := newTestEditor(t, "one"), newTestEditor(t, "two")
+
+	script := `
+		jk.action("say-line", "show the line", function() jk.message(jk.line()) end)
+		jk.command("say", function(args) jk.message(args .. " " .. jk.line()) end)
+	`
+	if err := e1.luaState().DoString(script); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e1.RunAction("say-line"); err != nil || e1.statusmsg != "one" {
+		t.Errorf("say-line: %q, %v", e1.statusmsg, err)
+	}
+	if err := e1.ExecCommand("say hi"); err != nil || e1.statusmsg != "hi one" {
+		t.Errorf("say: %q, %v", e1.statusmsg, err)
+	}
+
+	if _, ok := e2.action("say-line"); ok {
+		t.Error("the other editor has the action")
+	}
+	if err := e2.ExecCommand("say hi"); err == nil {
+		t.Error("the other editor has the command")
+	}
+
+	// Scripts can define their own again, but not the built-in ones
+	if err := e1.luaState().DoString(script); err != nil {
+		t.Errorf("defining again: %s", err)
+	}
+	if err := e1.luaState().DoString(`jk.command("set", function() end)`); err == nil {
+		t.Error("a script replaced a built-in command")
+	}
+}
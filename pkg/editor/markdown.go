@@ -0,0 +1,174 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// highlightMarkdown highlights the markdown headings, emphasis and inline
+// code of a row. Text that already has a highlight, like code blocks, is
+// left alone.
+func highlightMarkdown(row *Row, runes []rune) {
+	if len(runes) == 0 || row.hl[0] != hlNormal {
+		return
+	}
+
+	if markdownHeadingLevel(string(runes)) > 0 {
+		for i := range row.hl {
+			row.hl[i] = hlKeyword1
+		}
+		return
+	}
+
+	for i := 0; i < len(runes); i++ {
+		var (
+			delim []rune
+			hl    SyntaxHL
+		)
+
+		switch {
+		case runes[i] == '`':
+			delim, hl = []rune("`"), hlString
+		case strings.HasPrefix(string(runes[i:]), "**"), strings.HasPrefix(string(runes[i:]), "__"):
+			delim, hl = runes[i:i+2], hlKeyword2
+		case runes[i] == '*', runes[i] == '_' && (i == 0 || isSeparator(runes[i-1])):
+			delim, hl = runes[i:i+1], hlKeyword2
+		default:
+			continue
+		}
+
+		end := indexRunes(runes[i+len(delim):], delim)
+		if end <= 0 {
+			// Unclosed or empty, e.g. a "*" used as a bullet point
+			i += len(delim) - 1
+			continue
+		}
+
+		end += i + 2*len(delim)
+		for j := i; j < end; j++ {
+			row.hl[j] = hl
+		}
+		i = end - 1
+	}
+}
+
+// markdownHeadingLevel returns the level of the heading on line, or 0 if it
+// isn't a heading. Headings start with one to six '#' followed by a space.
+func markdownHeadingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return 0
+	}
+
+	if rest := line[level:]; len(rest) != 0 && rest[0] != ' ' {
+		return 0
+	}
+
+	return level
+}
+
+// markdownHeadings returns the rows of the headings in a markdown file,
+// skipping the lines of code blocks.
+func markdownHeadings(e SDK) []int {
+	var (
+		rows   []int
+		inCode bool
+	)
+
+	for y := 0; y < e.NumRows(); y++ {
+		line := string(e.Row(y))
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+		} else if !inCode && markdownHeadingLevel(line) > 0 {
+			rows = append(rows, y)
+		}
+	}
+
+	return rows
+}
+
+// headingsOf returns the rows of the headings of the current file, which
+// must be markdown.
+func headingsOf(e SDK) ([]int, error) {
+	if e.Filetype() != "markdown" {
+		return nil, fmt.Errorf("headings are only supported in markdown files")
+	}
+
+	return markdownHeadings(e), nil
+}
+
+// nextHeading moves to the next heading, or the previous one when n is -1.
+func nextHeading(n int) func(e SDK) error {
+	return func(e SDK) error {
+		headings, err := headingsOf(e)
+		if err != nil {
+			return err
+		}
+
+		if n < 0 {
+			for i := len(headings) - 1; i >= 0; i-- {
+				if headings[i] < e.Y() {
+					e.SetY(headings[i])
+					e.SetX(0)
+					return nil
+				}
+			}
+		} else {
+			for _, y := range headings {
+				if y > e.Y() {
+					e.SetY(y)
+					e.SetX(0)
+					return nil
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// outline shows a menu of the headings to jump to one, indented by level.
+func outline(e SDK) error {
+	headings, err := headingsOf(e)
+	if err != nil {
+		return err
+	}
+
+	if len(headings) == 0 {
+		e.SetMessage("no headings")
+		return nil
+	}
+
+	items := make([]string, len(headings))
+	selected := 0
+	for i, y := range headings {
+		line := string(e.Row(y))
+		level := markdownHeadingLevel(line)
+		items[i] = strings.Repeat("  ", level-1) + strings.TrimSpace(line[level:])
+
+		// Start from the section the cursor is in
+		if y <= e.Y() {
+			selected = i
+		}
+	}
+
+	e.ShowMenu(items, selected, func(i int) error {
+		e.SetY(headings[i])
+		e.SetX(0)
+		e.CenterCursor()
+		return nil
+	})
+
+	return nil
+}
+
+// indexRunes returns the index of the first occurrence of sub in s, or -1.
+func indexRunes(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if string(s[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,170 @@
+package editor
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	// How long a message stays in the message bar
+	messageTimeout = 5 * time.Second
+
+	// Number of messages kept for :messages
+	messageHistorySize = 200
+)
+
+// addMessage records msg in the message history, dropping the oldest
+// messages once the history is full.
+func (e *Editor) addMessage(msg string) {
+	if len(msg) == 0 {
+		return
+	}
+
+	e.messages = append(e.messages, msg)
+	if len(e.messages) > messageHistorySize {
+		e.messages = e.messages[len(e.messages)-messageHistorySize:]
+	}
+}
+
+func (e *Editor) messageHistory() []string {
+	return e.messages
+}
+
+// ShowLines displays lines over the text area until it is dismissed with q
+// or Escape. Lines that don't fit on the screen can be scrolled through like
+// in a pager.
+func (e *Editor) ShowLines(lines []string) {
+	e.pager = lines
+	e.pagerOffset = 0
+	e.pagerSelected = -1
+
+	e.Prompt("-- j/k to scroll, q to quit --", func(k Key) (string, bool) {
+		switch k {
+		case Key('j'), keyArrowDown, keyEnter, keyCarriageReturn:
+			e.scrollPager(1)
+		case Key('k'), keyArrowUp:
+			e.scrollPager(-1)
+		case Key(' '), keyPageDown, Key(ctrl('d')):
+			e.scrollPager(e.screenRows)
+		case Key('b'), keyPageUp, Key(ctrl('u')):
+			e.scrollPager(-e.screenRows)
+		case Key('q'), keyEscape, Key(ctrl('q')):
+			e.closePager()
+			return "", true
+		}
+
+		return "", false
+	})
+}
+
+// ShowMenu displays items over the text area like ShowLines, with one of
+// them selected. j and k move the selection, Enter picks the selected item
+// and q or Escape dismisses the menu without picking anything.
+func (e *Editor) ShowMenu(items []string, selected int, pick func(i int) error) {
+	if len(items) == 0 {
+		return
+	}
+
+	e.pager = items
+	e.pagerOffset = 0
+	e.selectMenuItem(selected)
+
+	e.Prompt("-- j/k to move, Enter to pick, q to quit --", func(k Key) (string, bool) {
+		switch k {
+		case Key('j'), keyArrowDown, Key(ctrl('n')):
+			e.selectMenuItem(e.pagerSelected + 1)
+		case Key('k'), keyArrowUp, Key(ctrl('p')):
+			e.selectMenuItem(e.pagerSelected - 1)
+		case keyPageDown, Key(ctrl('d')):
+			e.selectMenuItem(e.pagerSelected + e.screenRows)
+		case keyPageUp, Key(ctrl('u')):
+			e.selectMenuItem(e.pagerSelected - e.screenRows)
+		case keyEnter, keyCarriageReturn:
+			i := e.pagerSelected
+			e.closePager()
+			if err := pick(i); err != nil {
+				e.ErrChan() <- err
+			}
+			return "", true
+		case Key('q'), keyEscape, Key(ctrl('q')):
+			e.closePager()
+			return "", true
+		}
+
+		return "", false
+	})
+}
+
+// selectMenuItem selects the menu item i, scrolling to keep it on the
+// screen.
+func (e *Editor) selectMenuItem(i int) {
+	if i >= len(e.pager) {
+		i = len(e.pager) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	e.pagerSelected = i
+
+	if i < e.pagerOffset {
+		e.pagerOffset = i
+	} else if i >= e.pagerOffset+e.screenRows {
+		e.pagerOffset = i - e.screenRows + 1
+	}
+}
+
+func (e *Editor) closePager() {
+	e.pager = nil
+	e.pagerSelected = -1
+	e.SetMessage("")
+}
+
+// scrollPager scrolls the lines shown by ShowLines by n lines, without
+// scrolling past either end.
+func (e *Editor) scrollPager(n int) {
+	last := len(e.pager) - e.screenRows
+	if last < 0 {
+		last = 0
+	}
+
+	e.pagerOffset += n
+	if e.pagerOffset > last {
+		e.pagerOffset = last
+	}
+	if e.pagerOffset < 0 {
+		e.pagerOffset = 0
+	}
+}
+
+func (e *Editor) drawPager(w io.Writer) {
+	for y := 0; y < e.screenRows; y++ {
+		if i := y + e.pagerOffset; i < len(e.pager) {
+			line := e.width.Truncate(e.pager[i], e.screenCols, "")
+			if i == e.pagerSelected {
+				// Fill the whole line so the selection is easy to see
+				line = e.width.FillRight(line, e.screenCols)
+				setStyle(w, e.color(hlSelection))
+			}
+
+			w.Write([]byte(line))
+			clearFormatting(w)
+		} else {
+			w.Write([]byte("~"))
+		}
+
+		w.Write([]byte(ClearLineCode))
+		w.Write([]byte("\r\n"))
+	}
+}
+
+// errorMark is a row highlighted as the place of an error until the text
+// changes from its tick.
+type errorMark struct {
+	y, tick int
+}
+
+// highlightError highlights row y as the place of an error until the text
+// is changed.
+func (e *Editor) highlightError(y int) {
+	e.errorMark = &errorMark{y: y, tick: e.changeTick}
+}
@@ -0,0 +1,1722 @@
+// Package editor is a small modal text editor for the terminal. Run starts it
+// on the current terminal. Keys are bound to named actions, and both actions
+// and ex commands work on the editor through the SDK interface. Programs add
+// their own to an editor with the plugins of its Options.
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/pkg/errors"
+)
+
+var ErrQuitEditor = errors.New("quit editor")
+
+type EditorMode int8
+
+const (
+	InsertMode EditorMode = iota + 1
+	CommandMode
+	PromptMode
+	// Typing over the text instead of inserting it
+	ReplaceMode
+	// Waiting for the motion or text object of an operator like "d"
+	OperatorPendingMode
+)
+
+type Editor struct {
+	Mode EditorMode
+
+	// where keys are read from and the screen is drawn to
+	term Terminal
+	// input read from the terminal that doesn't make up a whole key yet,
+	// only used by the goroutine reading keys
+	input []byte
+
+	errChan chan error
+	// wakes up the main loop to render changes made in the background
+	redrawChan chan struct{}
+	// functions from other goroutines to run on the main loop
+	mainChan chan func()
+	// closed once the main loop has exited, so that nothing waits for it
+	stopped chan struct{}
+
+	// cursor coordinates
+	cx, cy int // cx is an index into Row.chars
+	rx     int // rx is an index into []rune(Row.render)
+
+	// offsets. Offset is calculated in the number of runes
+	rowOffset int
+	colOffset int
+
+	// screen size
+	screenRows int
+	screenCols int
+
+	showWelcomeScreen bool
+
+	// file content
+	rows []*Row
+
+	// whether or not the file has been modified
+	modified bool
+	// incremented on every change to the text
+	changeTick int
+
+	filename string
+
+	// the text as it is in the file, to find the unsaved changes
+	changes unsavedChanges
+
+	// changes that can be undone and redone
+	history undoHistory
+
+	// the title the terminal window was last given
+	lastTitle string
+
+	// when the bell last rang, and whether the screen is inverted for it
+	bellTime time.Time
+	flashing bool
+
+	// status message and time the message was set
+	statusmsg     string
+	statusmsgTime time.Time
+	// redraws the screen once the message has timed out
+	statusmsgTimer *time.Timer
+
+	// previous status messages, oldest first
+	messages []string
+
+	// recently yanked and deleted text, most recent first
+	killRing []Yank
+
+	// the prompt taking the keys typed, or nil
+	prompt *promptOverlay
+	// candidates shown while completing the input of a prompt, or nil
+	completion *completionMenu
+	// column of the cursor in the message bar, counting from 1, while
+	// editing the input of a prompt, or 0 to leave it in the text
+	promptCursor int
+
+	// lines shown over the text area by ShowLines
+	pager       []string
+	pagerOffset int
+	// index of the selected line when the lines are a menu, or -1
+	pagerSelected int
+
+	// General settings like tabstop
+	cfg DisplayConfig
+	// How many columns runes take up on the screen, following the
+	// ambiwidth option
+	width *runewidth.Condition
+	// Settings from the config file, before applying the filetype settings
+	globalCfg DisplayConfig
+	// ":set" arguments given by the user, in order, applied again on top of
+	// globalCfg whenever the filetype changes
+	userOptions []string
+	// commandNames from the config file to run for each filetype
+	filetypeCommands map[string][]string
+	// Whether every file is secret, from "--secret", and whether the
+	// current one is
+	secret struct{ all, file bool }
+	// What it takes to encrypt the file again, if it is encrypted
+	gpg gpgState
+	// Set while the options come from somewhere other than the user, like
+	// the config file, for them not to be remembered for the file
+	applyingOptions int
+	// The colorscheme in use, and its name
+	colorscheme     Colorscheme
+	colorschemeName string
+	// Whether the terminal reported a light background color
+	lightTerminal bool
+
+	// specify which syntax highlight to use.
+	syntax *EditorSyntax
+
+	// Variables set with ":let" and by scripts
+	vars variables
+
+	// The last search, repeated by n and N
+	search SearchState
+	// The query being typed in the search prompt, or nil
+	incsearch []rune
+
+	// keys typed so far of a binding made of several keys
+	pendingKeys []Key
+	// Count typed before a command in command mode, or 0
+	count int
+	// The keymaps of each mode
+	keymaps map[KeyMapName]KeyMap
+	// The keymaps that keys are looked up in. Keymaps at the beginning have
+	// higher priority.
+	keymapping []KeyMap
+
+	// Rules added with the highlight command, in the order they were added.
+	// Later rules win where matches overlap.
+	highlightRules []highlightRule
+
+	// What the editor was made with
+	opts Options
+
+	// The actions, ex commands and completions of their arguments, built in
+	// or added by plugins, and the plugins
+	actions     map[string]Action
+	commands    map[string]ExCommand
+	completions map[string]CompletionFunc
+	plugins     []*Plugin
+
+	// runs Lua scripts, created when first needed
+	lua *luaRuntime
+
+	// hooks run on events, and the events whose hooks are running
+	hooks  map[Event][]Hook
+	firing map[Event]bool
+
+	// processes started in the background
+	jobs      []*Job
+	lastJobID int
+
+	// Branch and dirty state of the git repository holding the file
+	git gitStatus
+
+	// The scratch buffer being edited instead of a file, if any
+	scratch scratchState
+
+	// The project root of the file, or "" if it isn't in a project
+	root string
+	// The working directory to go back to when another file is opened,
+	// while the one set with ":lcd" is in use
+	globalDir string
+
+	// The bracket matching the one under the cursor, if it is on the screen
+	matchedBracket *position
+
+	// Lines that differ from the git index
+	diff diffGutter
+
+	// The side by side diff shown over the text area
+	diffView *diffView
+
+	// Merge conflicts, as of changeTick conflictsTick
+	conflicts     []conflict
+	conflictsTick int
+
+	// How the columns of delimited files are lined up, or nil for other
+	// files
+	columns *columnLayout
+
+	// The row where a command found an error, if there is one
+	errorMark *errorMark
+}
+
+type DisplayConfig struct {
+	Tabstop int
+	// Wrap long lines onto multiple screen lines instead of scrolling horizontally
+	Wrap bool
+	// Minimum number of lines to keep above and below the cursor
+	ScrollOff int
+	// Rows longer than this are only syntax highlighted up to this column,
+	// or all the way with 0
+	SynMaxCol int
+	// Show line numbers in a gutter to the left of the text
+	Number bool
+	// Save the file when leaving insert mode
+	Autosave bool
+	// The key that "<leader>" stands for in mappings, in key notation
+	Leader string
+	// Copy the indentation of the current line when starting a new line
+	Autoindent bool
+	// Text that starts a line comment, used when toggling comments
+	CommentString string
+	// Number of columns of each level of indentation
+	ShiftWidth int
+	// Indent with spaces instead of tabs
+	ExpandTab bool
+	// Apply the settings of vim modelines in opened files
+	Modeline bool
+	// Guess the indentation style of opened files
+	DetectIndent bool
+	// Remove whitespace from the end of every line when saving
+	TrimWhitespace bool
+	// Mark the lines that differ from the git index in the gutter
+	GitGutter bool
+	// Show where the screen is in the file in the last column
+	Scrollbar bool
+	// Mark the lines changed since the file was saved in the gutter
+	ChangeMarks bool
+	// Show the name of the file in the title of the terminal window
+	Title bool
+	// Highlight every match of the last search
+	HLSearch bool
+	// Change the working directory to the project root of each file opened
+	AutoCD bool
+	// How to signal keys that do nothing: "none", "audible" or "visual"
+	Bell string
+	// Whether the terminal background is "light" or "dark", to choose the
+	// colorscheme variant. "auto" asks the terminal.
+	Background string
+	// Templates for the left and right side of the status bar. See
+	// statusSegments for the available "{name}" segments.
+	StatusLeft  string
+	StatusRight string
+	// Shell command building the editor before the reload action restarts it
+	ReloadCommand string
+	// Comma-separated IDs of the keys new encrypted files are encrypted
+	// to, or empty to encrypt them with a passphrase
+	GPGRecipients string
+	// Comma-separated patterns of the names of secret files, see
+	// matchesSecret
+	SecretFiles string
+	// Program run by ":make", with its arguments
+	MakeProgram string
+	// strftime format of the date inserted by ":date" and templates
+	DateFormat string
+	// Whether East Asian characters of ambiguous width are "single" or
+	// "double" width. "auto" goes by the locale.
+	AmbiWidth string
+}
+
+var defaultDisplayConfig = DisplayConfig{
+	Tabstop:      8,
+	SynMaxCol:    3000,
+	ShiftWidth:   8,
+	Modeline:     true,
+	DetectIndent: true,
+	Background:   BackgroundAuto,
+	AmbiWidth:    AmbiWidthAuto,
+	GitGutter:    true,
+	Title:        true,
+	Bell:         BellNone,
+	Leader:       "\\",
+	MakeProgram:  "make",
+	SecretFiles:  ".env,.env.*,*_secret*,*.pem,*.key",
+	DateFormat:   "%Y-%m-%d",
+	StatusLeft:   "{mode} {filename} - {lines} lines {modified} {secret} {git}",
+	StatusRight:  "{filetype} {indent} | {line}/{lines}:{col} {percent}",
+}
+
+type Key int32
+
+// The following special keys are given codes past the last rune, to avoid
+// conflicts with the characters typed.
+const (
+	keyEnter          Key = 10
+	keyCarriageReturn Key = 13
+	keyBackspace      Key = 127
+	keyEscape         Key = '\x1b'
+
+	keyArrowLeft Key = iota + utf8.MaxRune + 1
+	keyArrowRight
+	keyArrowUp
+	keyArrowDown
+	keyDelete
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyF1
+	keyShiftTab
+)
+
+type Row struct {
+	// Raw character data for the row as an array of runes.
+	chars []rune
+	// Actual chracters to draw on the screen.
+	render string
+	// Syntax highlight value for each rune in the render string.
+	hl []SyntaxHL
+	// Indicates whether this row has unclosed multiline comment.
+	hasUnclosedComment bool
+	// The quote of a string that continues onto the next row, or zero.
+	unclosedString rune
+}
+
+// ctrl returns a byte resulting from pressing the given ASCII character with the ctrl-key.
+func ctrl(char byte) byte {
+	return char & 0x1f
+}
+
+var escapeCodeToKey = map[string]Key{
+	"\x1b[A":   keyArrowUp,
+	"\x1b[B":   keyArrowDown,
+	"\x1b[C":   keyArrowRight,
+	"\x1b[D":   keyArrowLeft,
+	"\x1b[1~":  keyHome,
+	"\x1b[7~":  keyHome,
+	"\x1b[H":   keyHome,
+	"\x1bOH":   keyHome,
+	"\x1b[4~":  keyEnd,
+	"\x1b[8~":  keyEnd,
+	"\x1b[F":   keyEnd,
+	"\x1bOF":   keyEnd,
+	"\x1b[3~":  keyDelete,
+	"\x1b[5~":  keyPageUp,
+	"\x1b[6~":  keyPageDown,
+	"\x1bOP":   keyF1,
+	"\x1b[11~": keyF1,
+	"\x1b[Z":   keyShiftTab,
+}
+
+type Direction int8
+
+const (
+	DirectionUp Direction = iota + 1
+	DirectionDown
+	DirectionLeft
+	DirectionRight
+)
+
+type EscapeCodes string
+
+const (
+	RepositionCursorCode = "\x1b[H"
+	ResetColorCode       = "\x1b[39m"
+	ClearLineCode        = "\x1b[K"
+	ClearScreenCode      = "\x1b[2J"
+
+	// Cursor shapes
+	CursorDefaultCode   = "\x1b[0 q"
+	CursorBlockCode     = "\x1b[2 q"
+	CursorBarCode       = "\x1b[6 q"
+	CursorUnderlineCode = "\x1b[4 q"
+)
+
+// ProcessKey processes a key read from stdin.
+// Returns errQuitEditor when user requests to quit.
+func (e *Editor) ProcessKey(k Key) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Wrap(e.(error), "panicked")
+		}
+	}()
+
+	x, y := e.cx, e.cy
+	defer func() {
+		if e.cx != x || e.cy != y {
+			e.notify(EventCursorMoved)
+		}
+	}()
+
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	if e.prompt != nil {
+		e.promptKey(k)
+		return nil
+	}
+
+	// Digits before a command are its count. A leading 0 is the
+	// line-start command instead
+	counting := e.Mode == CommandMode || e.Mode == OperatorPendingMode
+	if counting && len(e.pendingKeys) == 0 && k >= '0' && k <= '9' && (k != '0' || e.count > 0) {
+		e.count = e.count*10 + int(k-'0')
+		return nil
+	}
+	defer func() {
+		if len(e.pendingKeys) == 0 && e.Mode != OperatorPendingMode {
+			e.count = 0
+		}
+	}()
+
+	pending := append(e.pendingKeys, k)
+	keys := keysNotation(pending)
+	e.pendingKeys = nil
+
+	for _, keymap := range e.keymapping {
+		if e.logsKeys() {
+			logDebugf("processing keys: %s, with keymap: %s", keys, keymap.Name)
+		}
+
+		if action, ok := keymap.Bindings[keys]; ok {
+			return e.runMotion(action)
+		}
+
+		// Wait for the rest of a multi-key binding
+		if keymap.hasPrefix(keys) {
+			e.pendingKeys = pending
+			return nil
+		}
+
+		if keymap.Handler == nil {
+			continue
+		}
+
+		handled, err := keymap.Handler(e, k)
+		if err != nil {
+			return err
+		}
+
+		if handled {
+			return nil
+		}
+	}
+
+	e.Bell()
+	return nil
+}
+
+func (e *Editor) displayWelcomeMessage(w io.Writer) {
+	welcomeMsg := fmt.Sprintf("Mini editor -- version %s", Version)
+	if e.width.StringWidth(welcomeMsg) > e.screenCols {
+		welcomeMsg = utf8Slice(welcomeMsg, 0, e.screenCols)
+	}
+	padding := (e.screenCols - e.width.StringWidth(welcomeMsg)) / 2
+	if padding > 0 {
+		w.Write([]byte("~"))
+		padding--
+	}
+	for ; padding > 0; padding-- {
+		w.Write([]byte(" "))
+	}
+
+	w.Write([]byte(welcomeMsg))
+}
+
+func (e *Editor) drawRows(w io.Writer) {
+	if e.cfg.Wrap {
+		e.drawWrappedRows(w)
+		return
+	}
+
+	for y := 0; y < e.screenRows; y++ {
+		e.drawRow(w, y)
+
+		w.Write([]byte(ClearLineCode))
+		w.Write([]byte("\r\n"))
+	}
+}
+
+func (e *Editor) drawRow(w io.Writer, y int) {
+	filerow := y + e.rowOffset
+	if filerow >= len(e.rows) {
+		// The display message should not be here, you should not be
+		// able to get back to it once passed
+		if e.showWelcomeScreen && len(e.rows) == 0 && y == e.screenRows/3 {
+			e.displayWelcomeMessage(w)
+			e.showWelcomeScreen = false
+		} else {
+			w.Write([]byte("~"))
+		}
+
+		return
+	}
+
+	var (
+		line string
+		hl   []SyntaxHL
+	)
+
+	e.drawLineNumber(w, filerow)
+
+	// Only the part of the render string on the screen is looked at, so
+	// that very long lines are as quick to draw as short ones
+	row := e.rows[filerow]
+	line, n := visibleSlice(row.render, e.colOffset, e.textCols(), e.width)
+	if n > 0 {
+		hl = e.rowHighlight(filerow)[e.colOffset : e.colOffset+n]
+	}
+
+	e.drawLine(w, line, hl)
+}
+
+// drawLine writes line to w, coloring each rune with the matching entry in hl.
+func (e *Editor) drawLine(w io.Writer, line string, hl []SyntaxHL) {
+	currentColor := "" // keep track of color to detect color change
+
+	i := 0
+	for _, r := range line {
+		if unicode.IsControl(r) {
+			// deal with non-printable characters (e.g. Ctrl-A)
+			sym := '?'
+			if r < 26 {
+				sym = '@' + r
+			}
+
+			setColor(w, InvertedColor)
+			w.Write(rToB(sym))
+			clearFormatting(w)
+
+			// restore the current color
+			if currentColor != "" {
+				setStyle(w, currentColor)
+			}
+		} else {
+			if color := e.color(hl[i]); color != currentColor {
+				currentColor = color
+				setStyle(w, color)
+			}
+
+			w.Write(rToB(r))
+		}
+		i++
+	}
+
+	clearFormatting(w)
+}
+
+const (
+	InvertedColor = 7
+)
+
+// gutterWidth returns the width of the line number gutter, including the
+// space separating it from the text.
+func (e *Editor) gutterWidth() int {
+	return e.changeWidth() + e.signWidth() + e.numberWidth()
+}
+
+// numberWidth returns the width of the line numbers in the gutter.
+func (e *Editor) numberWidth() int {
+	if !e.cfg.Number {
+		return 0
+	}
+
+	// leave room for at least 3 digits so the text doesn't jump around
+	// while lines are added
+	digits := len(strconv.Itoa(len(e.rows)))
+	if digits < 3 {
+		digits = 3
+	}
+
+	return digits + 1
+}
+
+// textCols returns the number of columns available for the file content.
+func (e *Editor) textCols() int {
+	return e.screenCols - e.gutterWidth() - e.scrollbarWidth()
+}
+
+// drawLineNumber draws the gutter for filerow. A negative filerow draws an
+// empty gutter, used for the continuation of wrapped lines.
+func (e *Editor) drawLineNumber(w io.Writer, filerow int) {
+	e.drawChangeMark(w, filerow)
+	e.drawSign(w, filerow)
+
+	width := e.numberWidth()
+	if width == 0 {
+		return
+	}
+
+	if filerow < 0 {
+		w.Write([]byte(strings.Repeat(" ", width)))
+		return
+	}
+
+	setStyle(w, e.color(hlLineNumber))
+	fmt.Fprintf(w, "%*d ", width-1, filerow+1)
+	clearFormatting(w)
+}
+
+func setColor(b io.Writer, c int) {
+	b.Write([]byte("\x1b[" + strconv.Itoa(c) + "m"))
+}
+
+// setStyle replaces the current formatting with the given SGR parameters.
+func setStyle(b io.Writer, sgr string) {
+	b.Write([]byte("\x1b[0;" + sgr + "m"))
+}
+
+func clearFormatting(b io.Writer) {
+	b.Write([]byte("\x1b[m"))
+}
+
+// visibleSlice returns the part of s starting at rune start that fits in
+// cols columns, and its number of runes.
+func visibleSlice(s string, start, cols int, cond *runewidth.Condition) (string, int) {
+	from, n, width := -1, 0, 0
+	i := 0
+	for pos, r := range s {
+		if i < start {
+			i++
+			continue
+		}
+		if from == -1 {
+			from = pos
+		}
+
+		if width += cond.RuneWidth(r); width > cols {
+			return s[from:pos], n
+		}
+		n++
+	}
+
+	if from == -1 {
+		return "", 0
+	}
+	return s[from:], n
+}
+
+// utf8Slice slice the given string by utf8 character.
+func utf8Slice(s string, start, end int) string {
+	return string([]rune(s)[start:end])
+}
+
+var ClearFromCusorToEndOfLine = []byte("\x1b[K")
+
+func (e *Editor) drawMessageBar(b *strings.Builder) {
+	b.Write(ClearFromCusorToEndOfLine)
+	if e.prompt != nil {
+		line, _ := e.promptLine()
+		b.WriteString(line)
+		return
+	}
+
+	msg := e.statusmsg
+	if e.Mode != PromptMode && time.Since(e.statusmsgTime) > messageTimeout {
+		msg = ""
+	}
+	if e.width.StringWidth(msg) > e.screenCols {
+		msg = e.width.Truncate(msg, e.screenCols, "...")
+	}
+
+	b.Write([]byte(msg))
+}
+
+// Cursor position (which is calculated in runes) to the visual position
+func (e *Editor) rowCxToRx(row *Row, cx int) int {
+	if e.columns != nil {
+		return e.columns.visualCols(row.chars)[cx]
+	}
+
+	return visualWidth(row.chars[:cx], e.cfg.Tabstop, e.width)
+}
+
+func (e *Editor) rowRxToCx(row *Row, rx int) int {
+	if len(row.chars) == 0 {
+		return 0
+	}
+
+	if e.columns != nil {
+		cols := e.columns.visualCols(row.chars)
+		for i := range row.chars {
+			if cols[i+1] > rx {
+				return i
+			}
+		}
+
+		return len(row.chars)
+	}
+
+	curRx := 0
+	widths := charWidths(row.chars, e.width)
+	for i, r := range row.chars {
+		if r == '\t' {
+			curRx += (e.cfg.Tabstop) - (curRx % e.cfg.Tabstop)
+		} else {
+			curRx += widths[i]
+		}
+
+		if curRx > rx {
+			return clusterStart(row.chars, i)
+		}
+	}
+
+	return len(row.chars)
+}
+
+func (e *Editor) scroll() {
+	e.rx = 0
+	if e.cy < len(e.rows) {
+		e.rx = e.rowCxToRx(e.rows[e.cy], e.cx)
+	}
+
+	if e.cfg.Wrap {
+		e.scrollWrapped()
+		return
+	}
+
+	so := e.scrollOff()
+	// scroll up if the cursor is above the visible window.
+	if e.cy < e.rowOffset+so {
+		e.rowOffset = e.cy - so
+		if e.rowOffset < 0 {
+			e.rowOffset = 0
+		}
+	}
+	// scroll down if the cursor is below the visible window.
+	if e.cy >= e.rowOffset+e.screenRows-so {
+		e.rowOffset = e.cy - e.screenRows + 1 + so
+
+		// The margin shouldn't scroll past the end of the file
+		if last := len(e.rows) - e.screenRows; e.rowOffset > last {
+			e.rowOffset = last
+		}
+		if min := e.cy - e.screenRows + 1; e.rowOffset < min {
+			e.rowOffset = min
+		}
+	}
+	// scroll left if the cursor is left of the visible window.
+	if e.rx < e.colOffset {
+		e.colOffset = e.rx
+	}
+	// scroll right if the cursor is right of the visible window.
+	if e.rx >= e.colOffset+e.textCols() {
+		e.colOffset = e.rx - e.textCols() + 1
+	}
+}
+
+// scrollOff returns the scrolloff margin, limited so that it can always be
+// satisfied on the current screen.
+func (e *Editor) scrollOff() int {
+	so := e.cfg.ScrollOff
+	if max := (e.screenRows - 1) / 2; so > max {
+		so = max
+	}
+
+	return so
+}
+
+// Render refreshes the screen.
+func (e *Editor) Render() {
+	e.WrapCursorY()
+	e.WrapCursorX()
+	e.updateColumns()
+	e.scroll()
+
+	var b strings.Builder
+
+	b.Write([]byte("\x1b[?25l")) // hide the cursor
+	e.updateTitle(&b)
+	e.drawBell(&b)
+	b.Write([]byte("\x1b[H")) // reposition the cursor at the top left.
+
+	if e.pager != nil {
+		e.drawPager(&b)
+	} else if e.diffView != nil {
+		e.drawDiffView(&b)
+	} else {
+		e.updateMatchedBracket()
+		e.updateConflicts()
+		e.drawRows(&b)
+	}
+	e.drawStatusBar(&b)
+	e.drawMessageBar(&b)
+	if e.pager == nil && e.diffView == nil {
+		e.drawScrollbar(&b)
+	}
+	e.drawCompletionMenu(&b)
+
+	// position the cursor
+	y, x := e.cy-e.rowOffset, e.rx-e.colOffset
+	if e.cfg.Wrap {
+		y, x = e.wrappedCursorPosition()
+	}
+	if _, col := e.promptLine(); col > 0 {
+		b.WriteString(fmt.Sprintf("\x1b[%d;%dH", e.screenRows+2, col))
+	} else {
+		b.WriteString(fmt.Sprintf("\x1b[%d;%dH", y+1, x+e.gutterWidth()+1))
+	}
+
+	// show the cursor, as a bar when inserting text and an underline
+	// when replacing it
+	switch e.Mode {
+	case InsertMode:
+		b.WriteString(CursorBarCode)
+	case ReplaceMode:
+		b.WriteString(CursorUnderlineCode)
+	default:
+		b.WriteString(CursorBlockCode)
+	}
+	b.Write([]byte("\x1b[?25h"))
+	io.WriteString(e.term, b.String())
+}
+
+func (e *Editor) SetMessage(format string, a ...interface{}) {
+	e.statusmsg = fmt.Sprintf(format, a...)
+	e.statusmsgTime = time.Now()
+
+	// Clear the message when it times out, even if nothing is typed
+	if e.statusmsgTimer == nil {
+		e.statusmsgTimer = time.AfterFunc(messageTimeout, e.requestRedraw)
+	} else {
+		e.statusmsgTimer.Reset(messageTimeout)
+	}
+
+	// Prompts use the message bar for their input, which isn't worth keeping
+	if e.Mode != PromptMode {
+		e.addMessage(e.statusmsg)
+	}
+}
+
+func (e *Editor) getCursorPosition() (row, col int, err error) {
+	if _, err = io.WriteString(e.term, "\x1b[6n"); err != nil {
+		return
+	}
+	if _, err = fmt.Fscanf(e.term, "\x1b[%d;%d", &row, &col); err != nil {
+		return
+	}
+	return
+}
+
+var ErrPromptCanceled = fmt.Errorf("user canceled the input prompt")
+
+func isPrintable(k Key) bool {
+	return !unicode.IsControl(rune(k)) && unicode.IsPrint(rune(k)) && !isSpecialKey(k)
+}
+
+// isSpecialKey reports whether k is one of the keys that are given codes
+// beyond the ASCII ones, like the arrow keys.
+func isSpecialKey(k Key) bool {
+	return k >= keyArrowLeft && k <= keyShiftTab
+}
+
+func isArrowKey(k Key) bool {
+	return k == keyArrowUp || k == keyArrowRight || k == keyArrowDown || k == keyArrowLeft
+}
+
+func (e *Editor) Save() error {
+	if len(e.filename) != 0 {
+		return e.saveFile(e.filename)
+	}
+
+	e.StaticPrompt("Save as: ", func(filename string) error {
+		e.filename = filename
+		return e.saveFile(filename)
+	}, nil)
+
+	return nil
+}
+
+func (e *Editor) saveFile(filename string) error {
+	if err := e.fire(EventBufWritePre); err != nil {
+		return err
+	}
+
+	if e.cfg.TrimWhitespace {
+		e.trimTrailingWhitespace()
+	}
+
+	var text bytes.Buffer
+	for _, row := range e.rows {
+		text.WriteString(string(row.chars))
+		text.WriteByte('\n')
+	}
+
+	// Encrypted files are encrypted in memory, never written as they are
+	data := text.Bytes()
+	if isEncrypted(e.filename) {
+		var err error
+		if data, err = e.encrypt(data); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(e.filename, data, 0o644); err != nil {
+		return err
+	}
+
+	e.markSaved()
+	e.notify(EventBufWritePost)
+	return nil
+}
+
+// markSaved records that the text was written to the file.
+func (e *Editor) markSaved() {
+	e.modified = false
+	e.scratch.open, e.scratch.name = false, ""
+	e.markSavedText()
+	e.git.Invalidate()
+	e.updateDiff(true)
+}
+
+// trimTrailingWhitespace removes the whitespace at the end of every row. The
+// cursor stays where it is, unless the end of its row was removed.
+func (e *Editor) trimTrailingWhitespace() {
+	for y, row := range e.rows {
+		trimmed := []rune(strings.TrimRightFunc(string(row.chars), unicode.IsSpace))
+		if len(trimmed) == len(row.chars) {
+			continue
+		}
+
+		e.SetRow(y, trimmed)
+		if y == e.cy && e.cx > len(trimmed) {
+			e.cx = len(trimmed)
+		}
+	}
+}
+
+// Fairly basic version. Probably can make it faster *if need be*
+func rToB(r rune) []byte {
+	return []byte(string(r))
+}
+
+func (e *Editor) detectSyntax() {
+	if len(e.filename) == 0 {
+		e.setSyntax(nil)
+		return
+	}
+
+	ext := filepath.Ext(e.filename)
+
+	for _, syntax := range syntaxes {
+		for _, pattern := range syntax.filematch {
+			isExt := strings.HasPrefix(pattern, ".")
+			if (isExt && pattern == ext) ||
+				(!isExt && strings.Index(e.filename, pattern) != -1) {
+				e.setSyntax(syntax)
+				return
+			}
+		}
+	}
+
+	e.setSyntax(nil)
+}
+
+// detectSyntaxFromContent picks the syntax from the first line of the file,
+// for files whose name doesn't give it away. It looks at the interpreter of
+// a shebang, e.g. "#!/usr/bin/env python3", and at the signatures of each
+// syntax.
+func (e *Editor) detectSyntaxFromContent() {
+	if len(e.rows) == 0 {
+		return
+	}
+	first := string(e.rows[0].chars)
+
+	if interpreter := shebangInterpreter(first); len(interpreter) != 0 {
+		for _, syntax := range syntaxes {
+			for _, name := range syntax.interpreters {
+				if name == interpreter {
+					e.setSyntax(syntax)
+					return
+				}
+			}
+		}
+	}
+
+	for _, syntax := range syntaxes {
+		for _, sig := range syntax.signatures {
+			if strings.HasPrefix(first, sig) {
+				e.setSyntax(syntax)
+				return
+			}
+		}
+	}
+}
+
+// shebangInterpreter returns the name of the program that runs a script
+// from its shebang line, without any version number, e.g. "python" for
+// "#!/usr/bin/env python3.11". It returns an empty string if line isn't a
+// shebang.
+func shebangInterpreter(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+
+	prog := filepath.Base(fields[0])
+	if prog == "env" {
+		// Skip options like "env -S"
+		prog = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				prog = filepath.Base(f)
+				break
+			}
+		}
+	}
+
+	return strings.TrimRight(prog, "0123456789.")
+}
+
+// maxLineLength is the length in bytes of the longest line a file can have to
+// be opened, far more than one-line files like minified code need.
+const maxLineLength = 1 << 30
+
+// OpenFile opens a file with the given filename.
+// If a file does not exist, it returns os.ErrNotExist.
+func (e *Editor) OpenFile(filename string) error {
+	// Encrypted files are decrypted first, for a wrong passphrase not to
+	// leave the editor without a file
+	var plain []byte
+	if _, err := os.Stat(filename); err == nil && isEncrypted(filename) {
+		if plain, err = e.decryptFile(filename); err == errAskingPassphrase {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	e.stashScratch()
+	e.filename = e.leaveLocalDir(filename)
+	e.enterProject()
+	e.detectSyntax()
+	e.detectSecret()
+
+	f, err := os.Open(e.filename)
+	created := errors.Is(err, os.ErrNotExist)
+	if created {
+		f, err = os.Create(e.filename)
+		e.modified = true
+	} else {
+		e.modified = false
+	}
+
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	e.rows = make([]*Row, 0)
+
+	var r io.Reader = f
+	if plain != nil {
+		r = bytes.NewReader(plain)
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxLineLength)
+	for i := 0; s.Scan(); i++ {
+		line := s.Bytes()
+		// strip off newline or cariage return
+		bytes.TrimRightFunc(line, func(r rune) bool { return r == '\n' || r == '\r' })
+		e.rows = append(e.rows, &Row{
+			chars: []rune(string(line)),
+		})
+
+		e.updateRow(i)
+	}
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	if e.syntax == nil {
+		e.detectSyntaxFromContent()
+	}
+
+	e.updateDiff(true)
+	e.markSavedText()
+	e.resetHistory()
+	e.vars.buffer = nil
+
+	if created {
+		e.applyTemplate()
+	}
+
+	e.applyDetectedIndent()
+	err = e.applyModeline()
+	if optErr := e.restoreOptions(); optErr != nil && err == nil {
+		err = optErr
+	}
+
+	e.notify(EventBufOpen)
+	return err
+}
+
+func (e *Editor) updateRow(y int) {
+	var b strings.Builder
+	row := e.rows[y]
+	if e.columns != nil {
+		row.render, _ = e.columns.layout(row.chars)
+		e.updateHighlight(y)
+		return
+	}
+
+	cols := 0
+	widths := charWidths(row.chars, e.width)
+	for i, r := range row.chars {
+		if r != '\t' {
+			b.WriteRune(r)
+			cols += widths[i]
+			continue
+		}
+
+		// each tab must advance the cursor forward at least one column
+		b.WriteRune(' ')
+		cols++
+
+		// append spaces until we get to a tab stop
+		for cols%e.cfg.Tabstop != 0 {
+			b.WriteRune(' ')
+			cols++
+		}
+
+	}
+
+	row.render = b.String()
+	e.updateHighlight(y)
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.IndexRune(",.()+-/*=~%<>[]{}:;", r) != -1
+}
+
+func (e *Editor) updateHighlight(y int) {
+	row := e.rows[y]
+
+	// TODO why can't we just use len(row.chars)? for some reason this panics
+	row.hl = make([]SyntaxHL, utf8.RuneCountInString(row.render))
+	for i := range row.hl {
+		row.hl[i] = hlNormal
+	}
+
+	// The highlight rules go on top of the syntax highlighting, and of what
+	// the plugins color
+	defer e.applyHighlightRules(row)
+	defer e.applyPluginHighlights(row)
+
+	if e.syntax == nil {
+		return
+	}
+
+	// whether the previous rune was a separator
+	prevSep := true
+
+	// zero when outside a string, set to the quote character ( ' or ")  in the string
+	var strQuote rune
+	if y > 0 {
+		strQuote = e.rows[y-1].unclosedString
+	}
+
+	// indicates whether we are inside a multi-line comment.
+	inComment := y > 0 && e.rows[y-1].hasUnclosedComment
+
+	// Past synmaxcol the rest of the row is left as it is
+	runes := []rune(row.render)
+	if max := e.cfg.SynMaxCol; max > 0 && len(runes) > max {
+		runes = runes[:max]
+	}
+
+	idx := 0
+	for idx < len(runes) {
+		r := runes[idx]
+		prevHl := hlNormal
+		if idx > 0 {
+			prevHl = row.hl[idx-1]
+		}
+
+		// Single line comments
+		if e.syntax.scs != "" && strQuote == 0 && !inComment {
+			if hasRunePrefix(runes[idx:], e.syntax.scs) {
+				for idx < len(runes) {
+					row.hl[idx] = hlComment
+					idx++
+				}
+				break
+			}
+		}
+
+		// Multiline comments
+		if e.syntax.mcs != "" && e.syntax.mce != "" && strQuote == 0 {
+			if inComment {
+				row.hl[idx] = hlMlComment
+				if hasRunePrefix(runes[idx:], e.syntax.mce) {
+					for j := 0; j < len(e.syntax.mce); j++ {
+						row.hl[idx] = hlMlComment
+						idx++
+					}
+					inComment = false
+					prevSep = true
+				} else {
+					idx++
+				}
+				continue
+			} else if hasRunePrefix(runes[idx:], e.syntax.mcs) {
+				for j := 0; j < len(e.syntax.mcs); j++ {
+					row.hl[idx] = hlMlComment
+					idx++
+				}
+				inComment = true
+				continue
+			}
+		}
+
+		if e.syntax.highlightStrings {
+			if strQuote != 0 {
+				row.hl[idx] = hlString
+				// deal with escape quote when inside a string
+				raw := strings.ContainsRune(e.syntax.rawQuotes, strQuote)
+				if r == '\\' && !raw && idx+1 < len(runes) {
+					row.hl[idx+1] = hlString
+					idx += 2
+					continue
+				}
+
+				if r == strQuote {
+					strQuote = 0
+				}
+
+				idx++
+				prevSep = true
+				continue
+			} else {
+				if strings.ContainsRune(e.syntax.stringQuotes(), r) ||
+					strings.ContainsRune(e.syntax.rawQuotes, r) {
+					strQuote = r
+					row.hl[idx] = hlString
+					idx++
+					continue
+				}
+			}
+		}
+
+		if e.syntax.highlightNumbers {
+			if unicode.IsDigit(r) && (prevSep || prevHl == hlNumber) ||
+				r == '.' && prevHl == hlNumber {
+				row.hl[idx] = hlNumber
+				idx++
+				prevSep = false
+				continue
+			}
+		}
+
+		if prevSep {
+			if kw, hl := e.checkIfKeyword(runes[idx:]); kw != "" {
+				end := idx + len(kw)
+				for idx < end {
+					row.hl[idx] = hl
+					idx++
+				}
+			}
+		}
+
+		prevSep = isSeparator(r)
+		idx++
+	}
+
+	highlightTodos(row, runes)
+	highlightURLs(row, runes, e.syntax.markdown)
+	if e.syntax.locations {
+		highlightLocation(row, runes)
+	}
+	if e.syntax.markdown {
+		highlightMarkdown(row, runes)
+	}
+	if e.syntax.keySeparator != 0 {
+		highlightKeys(row, runes, e.syntax.keySeparator, e.syntax.tableHeaders)
+	}
+	if e.columns != nil {
+		e.columns.highlight(row)
+	}
+
+	// Only raw strings continue onto the next row
+	if !strings.ContainsRune(e.syntax.rawQuotes, strQuote) {
+		strQuote = 0
+	}
+
+	changed := row.hasUnclosedComment != inComment || row.unclosedString != strQuote
+	row.hasUnclosedComment = inComment
+	row.unclosedString = strQuote
+	if changed && y+1 < len(e.rows) {
+		e.updateHighlight(y + 1)
+	}
+}
+
+// hasRunePrefix reports whether runes start with prefix, without turning all
+// of runes into a string.
+func hasRunePrefix(runes []rune, prefix string) bool {
+	i := 0
+	for _, r := range prefix {
+		if i >= len(runes) || runes[i] != r {
+			return false
+		}
+		i++
+	}
+
+	return true
+}
+
+// todoWords are the words that stand out when they appear in comments.
+var todoWords = []string{"TODO", "FIXME", "XXX", "NOTE"}
+
+// highlightTodos marks the todoWords that are within comments.
+func highlightTodos(row *Row, runes []rune) {
+	for i := range runes {
+		if hl := row.hl[i]; hl != hlComment && hl != hlMlComment {
+			continue
+		}
+		if i > 0 && !isSeparator(runes[i-1]) {
+			continue
+		}
+
+		if kw := checkKeywordMatch(todoWords, runes[i:]); kw != "" {
+			for j := i; j < i+len(kw); j++ {
+				row.hl[j] = hlTodo
+			}
+		}
+	}
+}
+
+func (e *Editor) checkIfKeyword(text []rune) (string, SyntaxHL) {
+	kw := checkKeywordMatch(e.syntax.keywords, text)
+	if len(kw) != 0 {
+		return kw, hlKeyword1
+	}
+
+	kw = checkKeywordMatch(e.syntax.keywords2, text)
+	if len(kw) != 0 {
+		return kw, hlKeyword2
+	}
+
+	return "", 0
+}
+
+// Check if any of the keywords are a prefix of text, and also that it isn't
+// just a substring of the a bigger word in text
+func checkKeywordMatch(keywords []string, text []rune) string {
+	for _, kw := range keywords {
+		length := utf8.RuneCountInString(kw)
+		if length > len(text) {
+			continue
+		}
+
+		// check if we have a match
+		if kw != string(text[:length]) {
+			continue
+		}
+
+		// check that this is the entire word, either
+		// there are no characters after this, or the
+		// next character is a separator
+		if length != len(text) && !isSeparator(text[length]) {
+			continue
+		}
+
+		return kw
+	}
+
+	return ""
+}
+
+// DisplaySettings is the state of the editor kept when it reloads.
+type DisplaySettings struct {
+	X         int `json:"x"`
+	Y         int `json:"y"`
+	RowOffset int `json:"row_offset"`
+	ColOffset int `json:"col_offset"`
+	// Whether the text had unsaved changes, which are in the recovery file
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Run runs the editor in the terminal until the user quits, opening the file
+// named in args if there is one. "-z" before the file restores the session
+// saved by the reload action, "--listen <socket>" lets other
+// programs control the editor through a unix socket, and "--log <level>"
+// sets the log level, overriding $JK_LOG.
+//
+// When the editor reloads, Run starts the executable again in place of the
+// current process. It only returns true if that fails, with the error, for a
+// wrapper script to start it instead.
+func Run(args []string, opts Options) (bool, error) {
+	restart, err := RunTerminal(StdTerminal(), args, opts)
+	if !restart || err != nil {
+		return false, err
+	}
+
+	return true, errors.Wrap(reexec(args), "reload")
+}
+
+// RunTerminal is like Run, but reads keys from and draws to t instead of
+// the process's terminal. It reports whether the editor should be started
+// again to reload. A panic while editing is returned as an error, once the
+// unsaved changes are saved to a crash snapshot if they can be.
+//
+// Keys are read from t on another goroutine, which stops with the editor.
+// If t can't be waited on for input, as the process's terminal can, a read
+// may still be blocked when RunTerminal returns. Close t or interrupt its
+// Read to end it; whatever it reads is dropped.
+func RunTerminal(t Terminal, args []string, opts Options) (restart bool, err error) {
+	var (
+		cfg DisplaySettings
+		// Whether the program has been restarted. This is used prevent the screen from unecessarily redrawing
+		restartMode bool
+	)
+
+	var filename, socket, logLevelName string
+	var secret bool
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-z":
+			restartMode = true
+		case "--secret":
+			secret = true
+		case "--listen":
+			if i++; i < len(args) {
+				socket = args[i]
+			}
+		case "--log":
+			if i++; i < len(args) {
+				logLevelName = args[i]
+			}
+		default:
+			filename = args[i]
+		}
+	}
+
+	editor := &Editor{term: t, opts: opts}
+	editor.secret.all = secret
+
+	if restartMode {
+		// Nothing is kept about secret files
+		if err := editor.readCache(sessionCache, filename, &cfg); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+
+	closeLog, err := StartLogging(logLevelName, opts.LogFile)
+	if err != nil {
+		return false, err
+	}
+	defer closeLog()
+
+	// This ensures that when the user exits the program, the previous
+	// terminal content will be restored. Otherwise the screen will be
+	// cleared as if you executed the `clear` command.
+	//
+	// I am not using a terminfo here to decide how to switch to the
+	// alternate screen so this will probably break on interesting terminal
+	// types.
+	if !restartMode {
+		SwitchToAlternateScreen(t)
+		io.WriteString(t, pushTitleCode)
+	}
+
+	defer func() {
+		if restart && err == nil {
+			return
+		}
+
+		io.WriteString(t, CursorDefaultCode)
+		io.WriteString(t, popTitleCode)
+		SwitchBackFromAlternateScreen(t)
+
+		io.WriteString(t, ClearScreenCode)
+		io.WriteString(t, RepositionCursorCode)
+		if r := recover(); r != nil {
+			restart, err = false, editor.crashError(r, debug.Stack())
+		}
+	}()
+
+	// Set the terminal to raw mode
+	if err := t.MakeRaw(); err != nil {
+		return false, err
+	}
+
+	defer t.Restore()
+
+	if err := editor.Init(); err != nil {
+		return false, err
+	}
+	defer close(editor.stopped)
+	defer editor.killJobs()
+
+	editor.cx = cfg.X
+	editor.cy = cfg.Y
+	editor.rowOffset = cfg.RowOffset
+	editor.colOffset = cfg.ColOffset
+
+	if socket != "" {
+		ln, err := editor.listen(socket)
+		if err != nil {
+			return false, err
+		}
+		defer ln.Close()
+	}
+
+	if len(filename) > 0 {
+		err := editor.OpenFile(filename)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+
+	if restartMode && cfg.Modified {
+		if err := editor.recoverText(); err != nil {
+			editor.SetMessage("recovery: %s", err)
+		}
+	} else if !restartMode && len(filename) > 0 {
+		editor.offerCrashRecovery()
+	}
+
+	// Yes 10 is a random number. I'm first seeing if it has any problems
+	keyChan := make(chan []Key, 1)
+	// A failed read is likely to fail again, so reading stops at the first
+	// error and the editor exits with it
+	readErrChan := make(chan error, 1)
+
+	go func() {
+		for {
+			keys, err := editor.readKeys()
+			if err != nil {
+				readErrChan <- err
+				return
+			}
+
+			select {
+			case keyChan <- keys:
+			case <-editor.stopped:
+				return
+			}
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+
+	signal.Notify(sigChan, syscall.SIGWINCH)
+
+	if restartMode {
+		editor.SetMessage("Restarted")
+	}
+
+	idle := time.NewTimer(idleDelay)
+
+	for {
+		editor.Render()
+
+		var err error
+		select {
+		case <-editor.redrawChan:
+		case fn := <-editor.mainChan:
+			editor.Transaction(func() error { fn(); return nil })
+		case <-idle.C:
+			editor.onIdle()
+		case keys := <-keyChan:
+			idle.Reset(idleDelay)
+
+			err = editor.ProcessKeys(keys)
+		case sig := <-sigChan:
+			logDebugf("received signal: %s", sig)
+
+			switch sig {
+			case syscall.SIGWINCH:
+				err = editor.setWindowSize()
+			}
+		case err = <-editor.errChan:
+		case err := <-readErrChan:
+			if err == io.EOF {
+				// Nothing more will be typed
+				return false, nil
+			}
+
+			return false, errors.Wrap(err, "reading keys")
+		}
+
+		// Errors aren't sent to errChan from here, as nothing would be
+		// reading it while the loop is blocked on sending
+		if err == nil {
+			continue
+		}
+
+		logErrorf("%+v", err)
+
+		switch err {
+		case ErrQuitEditor:
+			return false, nil
+		case RestartEditor:
+			if err = editor.saveSession(); err != nil {
+				break
+			}
+			if err = editor.rebuild(); err != nil {
+				break
+			}
+
+			return true, nil
+		}
+
+		editor.SetMessage("err: %s", err)
+	}
+}
+
+// errChanSize is how many errors can be sent to the error channel before
+// the main loop reads them. Actions run on the main loop, so an action
+// sending more than this would block forever.
+const errChanSize = 16
+
+// How long the editor waits after a key press before doing background work
+const idleDelay = 500 * time.Millisecond
+
+// onIdle is called once the user stops typing for a moment.
+func (e *Editor) onIdle() {
+	e.updateDiffIfChanged()
+}
+
+// requestRedraw renders the screen again, for changes made in the
+// background. It is safe to call from any goroutine.
+func (e *Editor) requestRedraw() {
+	select {
+	case e.redrawChan <- struct{}{}:
+	default:
+	}
+}
+
+// runOnMain runs fn on the main loop, where it can safely change the
+// editor, and renders the screen afterwards. It is called from other
+// goroutines and blocks until the main loop is ready for fn. It returns
+// false, without running fn, if the main loop has exited.
+func (e *Editor) runOnMain(fn func()) bool {
+	select {
+	case e.mainChan <- fn:
+		return true
+	case <-e.stopped:
+		return false
+	}
+}
+
+func (e *Editor) setWindowSize() error {
+	rows, cols, err := e.term.Size()
+	if err != nil {
+		return err
+	}
+
+	// make room for status-bar and message-bar
+	e.screenRows = rows - 2
+	e.screenCols = cols
+
+	return nil
+}
+
+func (e *Editor) Init() error {
+	if e.term == nil {
+		e.term = StdTerminal()
+	}
+	e.setWindowSize()
+
+	e.redrawChan = make(chan struct{}, 1)
+	e.errChan = make(chan error, errChanSize)
+	e.mainChan = make(chan func())
+	e.stopped = make(chan struct{})
+	e.width = runewidth.NewCondition()
+	e.git.redraw = e.requestRedraw
+
+	e.Mode = CommandMode
+	e.colorscheme = defaultColorscheme
+	e.keymaps = newKeymaps()
+	e.keymapping = []KeyMap{e.keymaps[BasicMapName], e.keymaps[CommandModeName]}
+
+	e.lightTerminal, _ = queryBackground(e.term)
+
+	// Nor should a broken plugin or config file
+	pluginErr := e.initRegistry()
+	if err := e.loadConfig(); err != nil {
+		e.SetMessage("config: %s", err)
+	} else if pluginErr != nil {
+		e.SetMessage("plugins: %s", pluginErr)
+	}
+
+	return nil
+}
+
+func SwitchToAlternateScreen(w io.Writer) {
+	w.Write([]byte("\033[?1049h"))
+}
+
+func SwitchBackFromAlternateScreen(w io.Writer) {
+	w.Write([]byte("\033[?1049l"))
+}
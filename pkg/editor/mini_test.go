@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// failingTerminal fails every read with err.
+type failingTerminal struct {
+	headlessTerminal
+	err   error
+	reads int32
+}
+
+func (t *failingTerminal) Read(p []byte) (int, error) {
+	atomic.AddInt32(&t.reads, 1)
+	return 0, t.err
+}
+
+func TestRunTerminalStopsOnReadError(t *testing.T) {
+	for _, readErr := range []error{io.EOF, errors.New("input/output error")} {
+		newTestEditor(t)
+		term := &failingTerminal{err: readErr}
+
+		restart, err := RunTerminal(term, nil, Options{CacheDir: t.TempDir()})
+		if restart {
+			t.Errorf("%v: asked to restart", readErr)
+		}
+		if readErr == io.EOF && err != nil {
+			t.Errorf("EOF: got %v, want nil", err)
+		}
+		if readErr != io.EOF && !errors.Is(err, readErr) {
+			t.Errorf("%v: got %v", readErr, err)
+		}
+		if n := atomic.LoadInt32(&term.reads); n != 1 {
+			t.Errorf("%v: read %d times, want once", readErr, n)
+		}
+	}
+}
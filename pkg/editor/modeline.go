@@ -0,0 +1,127 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of lines at the start and end of a file searched for modelines
+const modelineLines = 5
+
+// modelineOptions maps the options a modeline may set to the options they
+// change. Anything else is ignored so that opening a file can't change
+// arbitrary settings.
+var modelineOptions = map[string]string{
+	"ts":         "tabstop",
+	"tabstop":    "tabstop",
+	"sw":         "shiftwidth",
+	"shiftwidth": "shiftwidth",
+	"et":         "expandtab",
+	"expandtab":  "expandtab",
+	"ft":         "filetype",
+	"filetype":   "filetype",
+}
+
+// parseModeline returns the options of a vi modeline, e.g. for
+// "// vim: set ts=4 et:" it returns ["ts=4", "et"]. ok is false if line
+// doesn't contain a modeline.
+func parseModeline(line string) (opts []string, ok bool) {
+	var rest string
+	for _, marker := range []string{"vim:", "Vim:", "vi:", "ex:"} {
+		i := strings.Index(line, marker)
+		// The marker must be at the start of the line or after whitespace
+		if i == -1 || (i > 0 && line[i-1] != ' ' && line[i-1] != '\t') {
+			continue
+		}
+
+		rest, ok = strings.TrimSpace(line[i+len(marker):]), true
+		break
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	// In the "set" form the options end at the next ':'
+	if strings.HasPrefix(rest, "set ") || strings.HasPrefix(rest, "se ") {
+		_, rest, _ = strings.Cut(rest, " ")
+		rest, _, _ = strings.Cut(rest, ":")
+		return strings.Fields(rest), true
+	}
+
+	return strings.FieldsFunc(rest, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t'
+	}), true
+}
+
+// modelineOption translates an option from a modeline to a ":set" argument.
+// ok is false if the option isn't allowed in modelines.
+func modelineOption(opt string) (arg string, ok bool) {
+	name, value, hasValue := strings.Cut(opt, "=")
+
+	prefix := ""
+	if !hasValue && strings.HasPrefix(name, "no") {
+		prefix, name = "no", name[2:]
+	}
+
+	long, ok := modelineOptions[name]
+	if !ok {
+		return "", false
+	}
+
+	if hasValue {
+		return long + "=" + value, true
+	}
+
+	return prefix + long, true
+}
+
+// applyModeline applies the first modeline found in the first or last lines
+// of the file.
+func (e *Editor) applyModeline() error {
+	if !e.cfg.Modeline {
+		return nil
+	}
+
+	e.applyingOptions++
+	defer func() { e.applyingOptions-- }()
+
+	for y := range e.rows {
+		if y >= modelineLines && y < len(e.rows)-modelineLines {
+			continue
+		}
+
+		opts, ok := parseModeline(string(e.rows[y].chars))
+		if !ok {
+			continue
+		}
+
+		var args []string
+		for _, opt := range opts {
+			arg, ok := modelineOption(opt)
+			if !ok {
+				continue
+			}
+
+			// Set the filetype first since it resets the other options
+			if strings.HasPrefix(arg, "filetype=") {
+				if err := e.SetFiletype(strings.TrimPrefix(arg, "filetype=")); err != nil {
+					return fmt.Errorf("modeline on line %d: %s", y+1, err)
+				}
+				continue
+			}
+
+			args = append(args, arg)
+		}
+
+		for _, arg := range args {
+			if err := e.SetOption(arg); err != nil {
+				return fmt.Errorf("modeline on line %d: %s", y+1, err)
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
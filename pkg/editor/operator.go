@@ -0,0 +1,735 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Operators like "d" wait for a motion or a text object and apply to the
+// text it covers, e.g. "dw" deletes to the next word and "ci(" changes what
+// is between parentheses. Typing the operator's keys again applies it to
+// the whole line, e.g. "dd" or "gUgU", as does typing just the last key of
+// an operator of several keys again, e.g. "gUU". Ctrl-V between the operator and the
+// motion applies it to the rectangle with the cursor and where the motion
+// moves it at its corners instead, e.g. "d<C-v>G" deletes a column.
+
+// textRange is the text an operator applies to. end is just after the last
+// character, or the last row for a linewise range, which covers whole rows.
+// A blockwise range covers the columns from start.x up to end.x of every row
+// from start.y to end.y.
+type textRange struct {
+	start, end position
+	linewise   bool
+	blockwise  bool
+}
+
+// operator changes the text of a range.
+type operator struct {
+	description string
+	run         func(e SDK, r textRange) error
+}
+
+var operators = map[string]operator{
+	"delete":     {"delete text", deleteOperator},
+	"change":     {"delete text and enter insert mode", changeOperator},
+	"yank":       {"copy text to the kill ring", yankOperator},
+	"lowercase":  {"make text lowercase", caseOperator(unicode.ToLower)},
+	"uppercase":  {"make text uppercase", caseOperator(unicode.ToUpper)},
+	"togglecase": {"switch the case of text", caseOperator(toggleCase)},
+	"indent":     {"shift lines right by shiftwidth", shiftOperator(1)},
+	"dedent":     {"shift lines left by shiftwidth", shiftOperator(-1)},
+}
+
+// motionKind is how the text between the cursor and where a motion moves it
+// is covered.
+type motionKind int8
+
+const (
+	// Up to but not including the character moved to
+	exclusiveMotion motionKind = iota
+	// Including the character moved to
+	inclusiveMotion
+	// Every row from the cursor's to the one moved to
+	linewiseMotion
+)
+
+// motions are the actions an operator can be followed by.
+var motions = map[string]motionKind{
+	"move-left":            exclusiveMotion,
+	"move-right":           exclusiveMotion,
+	"line-start":           exclusiveMotion,
+	"first-non-blank":      exclusiveMotion,
+	"word":                 exclusiveMotion,
+	"back-word":            exclusiveMotion,
+	"find-next":            exclusiveMotion,
+	"find-prev":            exclusiveMotion,
+	"search-word":          exclusiveMotion,
+	"search-word-back":     exclusiveMotion,
+	"next-cell":            exclusiveMotion,
+	"prev-cell":            exclusiveMotion,
+	"next-paragraph":       exclusiveMotion,
+	"prev-paragraph":       exclusiveMotion,
+	"next-sentence":        exclusiveMotion,
+	"prev-sentence":        exclusiveMotion,
+	"line-end":             inclusiveMotion,
+	"match-bracket":        inclusiveMotion,
+	"move-up":              linewiseMotion,
+	"move-down":            linewiseMotion,
+	"last-line":            linewiseMotion,
+	"first-line":           linewiseMotion,
+	"first-non-blank-line": linewiseMotion,
+	"screen-top":           linewiseMotion,
+	"screen-bottom":        linewiseMotion,
+	"half-page-up":         linewiseMotion,
+	"half-page-down":       linewiseMotion,
+	"next-heading":         linewiseMotion,
+	"prev-heading":         linewiseMotion,
+	"next-hunk":            linewiseMotion,
+	"prev-hunk":            linewiseMotion,
+	"next-change":          linewiseMotion,
+	"prev-change":          linewiseMotion,
+}
+
+// countMotions are the motions that use the count themselves, going to the
+// line of the count, rather than being repeated that many times.
+var countMotions = map[string]bool{
+	"first-line":           true,
+	"last-line":            true,
+	"first-non-blank-line": true,
+}
+
+// repeatsOperatorKey reports whether keys is the last key of a binding of
+// several keys for the named operator, like the second "U" of "gUU", which
+// applies the operator to the whole line. That key may be bound to something
+// else on its own.
+func repeatsOperatorKey(keymaps []KeyMap, name string, keys []Key) bool {
+	if len(keys) != 1 {
+		return false
+	}
+
+	for _, keymap := range keymaps {
+		for seq, action := range keymap.Bindings {
+			if action != name {
+				continue
+			}
+
+			if bound, err := parseKeys(seq, 0); err == nil && len(bound) > 1 && bound[len(bound)-1] == keys[0] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// absoluteMotions are the linewise motions going to a line of their own
+// rather than one relative to the cursor, which cover the cursor's row even
+// when they don't move, as "dG" on the last row deletes it.
+var absoluteMotions = map[string]bool{
+	"first-line":           true,
+	"last-line":            true,
+	"first-non-blank-line": true,
+	"screen-top":           true,
+	"screen-bottom":        true,
+}
+
+// runMotion runs an action, as many times as the count for a motion that is
+// repeated, e.g. "3w" moves 3 words. It stops early once the motion no
+// longer moves the cursor.
+func (e *Editor) runMotion(action string) error {
+	n := 1
+	if _, ok := motions[action]; ok && !countMotions[action] && e.count > 1 {
+		n = e.count
+	}
+
+	for i := 0; i < n; i++ {
+		x, y := e.cx, e.cy
+		if err := e.RunAction(action); err != nil {
+			return err
+		}
+
+		e.WrapCursorY()
+		if e.cx == x && e.cy == y {
+			break
+		}
+	}
+
+	return nil
+}
+
+// textObjects select text around the cursor for an operator. They report
+// false when there is nothing to select.
+var textObjects = map[string]func(e SDK) (textRange, bool){
+	"iw": wordObject(false),
+	"aw": wordObject(true),
+	"i(": bracketObject('(', false),
+	"a(": bracketObject('(', true),
+	"i)": bracketObject('(', false),
+	"a)": bracketObject('(', true),
+	"ib": bracketObject('(', false),
+	"ab": bracketObject('(', true),
+	"i[": bracketObject('[', false),
+	"a[": bracketObject('[', true),
+	"i]": bracketObject('[', false),
+	"a]": bracketObject('[', true),
+	"i{": bracketObject('{', false),
+	"a{": bracketObject('{', true),
+	"i}": bracketObject('{', false),
+	"a}": bracketObject('{', true),
+	"iB": bracketObject('{', false),
+	"aB": bracketObject('{', true),
+	`i"`: quoteObject('"', false),
+	`a"`: quoteObject('"', true),
+	"i'": quoteObject('\'', false),
+	"a'": quoteObject('\'', true),
+	"i`": quoteObject('`', false),
+	"a`": quoteObject('`', true),
+}
+
+// startOperator returns an action waiting for the motion or text object to
+// apply the named operator to.
+func startOperator(name string) func(e SDK) error {
+	return func(e SDK) error { return e.StartOperator(name) }
+}
+
+// StartOperator waits for the keys of a motion or text object and applies
+// the named operator to the text it covers. Escape cancels it.
+func (e *Editor) StartOperator(name string) error {
+	op, ok := operators[name]
+	if !ok {
+		return fmt.Errorf("unknown operator: %s", name)
+	}
+
+	backup := e.keymapping
+	mode := e.Mode
+	restore := func() {
+		e.keymapping = backup
+		e.SetMode(mode)
+	}
+
+	// A count before the operator multiplies the one before the motion,
+	// e.g. "2d3w" deletes 6 words
+	count := e.count
+	e.count = 0
+	applyCount := func() {
+		if count > 0 && e.count > 0 {
+			e.count *= count
+		} else if count > 0 {
+			e.count = count
+		}
+	}
+
+	var pending []Key
+	blockwise := false
+	e.keymapping = []KeyMap{{
+		Name: OperatorPendingName,
+		Handler: func(_ SDK, k Key) (bool, error) {
+			if k == keyEscape || k == Key(ctrl('c')) {
+				restore()
+				return true, nil
+			}
+			if k == Key(ctrl('v')) && len(pending) == 0 {
+				blockwise = true
+				return true, nil
+			}
+
+			pending = append(pending, k)
+			keys := keysNotation(pending)
+
+			if object, ok := textObjects[keys]; ok {
+				restore()
+				r, ok := object(e)
+				if !ok {
+					e.Bell()
+					return true, nil
+				}
+				return true, op.run(e, r)
+			}
+
+			// Text objects come before bindings starting the same
+			// way, like "i" for insert-mode
+			for obj := range textObjects {
+				if len(obj) > len(keys) && strings.HasPrefix(obj, keys) {
+					return true, nil
+				}
+			}
+
+			if repeatsOperatorKey(backup, name, pending) {
+				restore()
+				applyCount()
+				return true, e.applyOperator(name, op, name, blockwise)
+			}
+
+			for _, keymap := range backup {
+				if action, ok := keymap.Bindings[keys]; ok {
+					restore()
+					applyCount()
+					return true, e.applyOperator(name, op, action, blockwise)
+				}
+				if keymap.hasPrefix(keys) {
+					return true, nil
+				}
+			}
+
+			restore()
+			e.Bell()
+			return true, nil
+		},
+	}}
+
+	e.SetMode(OperatorPendingMode)
+	return nil
+}
+
+// applyOperator applies the named operator to the text covered by running
+// the action, or to the line when the action is the operator's own. A
+// blockwise operator applies to the rectangle between the cursor and where
+// the action moves it, both included.
+func (e *Editor) applyOperator(name string, op operator, action string, blockwise bool) error {
+	if len(e.rows) == 0 {
+		e.Bell()
+		return nil
+	}
+
+	start := position{e.cx, e.cy}
+	if action == name {
+		end := start
+		if e.count > 1 {
+			end.y += e.count - 1
+		}
+		if end.y >= len(e.rows) {
+			end.y = len(e.rows) - 1
+		}
+		return op.run(e, textRange{start: start, end: end, linewise: true})
+	}
+
+	kind, ok := motions[action]
+	if !ok {
+		e.Bell()
+		return nil
+	}
+
+	if err := e.runMotion(action); err != nil {
+		return err
+	}
+	e.WrapCursorY()
+	// The column the motion went for, before being kept within a shorter
+	// row, which is still the edge of a block
+	blockX := e.cx
+	e.WrapCursorX()
+	end := position{e.cx, e.cy}
+	e.cx, e.cy = start.x, start.y
+
+	// As in vi, "cw" on a word changes up to its end, leaving the spaces
+	// after it
+	if name == "change" && action == "word" && end.y == start.y {
+		row := e.rows[start.y].chars
+		if start.x < len(row) && !unicode.IsSpace(row[start.x]) {
+			for end.x > start.x && unicode.IsSpace(row[end.x-1]) {
+				end.x--
+			}
+		}
+	}
+
+	// A motion that didn't move, like "%" away from brackets, covers
+	// nothing
+	if end == start && kind != linewiseMotion && !blockwise {
+		return nil
+	}
+	// Going a line up or down without moving, like "dj" on the last row,
+	// fails
+	if end.y == start.y && kind == linewiseMotion && !absoluteMotions[action] && !blockwise {
+		e.Bell()
+		return nil
+	}
+
+	if blockwise {
+		end.x = blockX
+		r := textRange{start: start, end: end, blockwise: true}
+		if end.x < start.x {
+			r.start.x, r.end.x = end.x, start.x
+		}
+		if end.y < start.y {
+			r.start.y, r.end.y = end.y, start.y
+		}
+		r.end.x++
+		return op.run(e, r)
+	}
+
+	if end.y < start.y || end.y == start.y && end.x < start.x {
+		start, end = end, start
+	}
+
+	r := textRange{start: start, end: end, linewise: kind == linewiseMotion}
+	if kind == inclusiveMotion {
+		r.end.x = nextCluster(e.rows[end.y].chars, end.x)
+	}
+	return op.run(e, r)
+}
+
+// rangeText returns the text of a range, with its rows joined by newlines.
+func rangeText(e SDK, r textRange) string {
+	if r.blockwise {
+		lines := make([]string, 0, r.end.y-r.start.y+1)
+		for y := r.start.y; y <= r.end.y; y++ {
+			x1, x2 := blockColumns(e, r, y)
+			lines = append(lines, string(e.Row(y)[x1:x2]))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if r.linewise {
+		lines := make([]string, 0, r.end.y-r.start.y+1)
+		for y := r.start.y; y <= r.end.y; y++ {
+			lines = append(lines, string(e.Row(y)))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	start, end := rangeEnds(e, r)
+	if start.y == end.y {
+		return string(e.Row(start.y)[start.x:end.x])
+	}
+
+	lines := []string{string(e.Row(start.y)[start.x:])}
+	for y := start.y + 1; y < end.y; y++ {
+		lines = append(lines, string(e.Row(y)))
+	}
+	lines = append(lines, string(e.Row(end.y)[:end.x]))
+	return strings.Join(lines, "\n")
+}
+
+// rangeEnds returns the ends of a range that isn't linewise, kept within
+// their rows.
+func rangeEnds(e SDK, r textRange) (start, end position) {
+	start, end = r.start, r.end
+	if n := len(e.Row(start.y)); start.x > n {
+		start.x = n
+	}
+	if n := len(e.Row(end.y)); end.x > n {
+		end.x = n
+	}
+
+	return start, end
+}
+
+// blockColumns returns the columns of row y a blockwise range covers, kept
+// within the row.
+func blockColumns(e SDK, r textRange, y int) (x1, x2 int) {
+	n := len(e.Row(y))
+	x1, x2 = r.start.x, r.end.x
+	if x1 > n {
+		x1 = n
+	}
+	if x2 > n {
+		x2 = n
+	}
+
+	return x1, x2
+}
+
+// yankRange puts the text of a range in the kill ring.
+func yankRange(e SDK, r textRange) {
+	if r.blockwise {
+		e.addBlockYank(rangeText(e, r))
+		return
+	}
+
+	e.AddYank(rangeText(e, r), r.linewise)
+}
+
+// deleteRange deletes the text of a range, leaving the cursor where it
+// started.
+func deleteRange(e SDK, r textRange) {
+	if r.blockwise {
+		for y := r.start.y; y <= r.end.y; y++ {
+			x1, x2 := blockColumns(e, r, y)
+			chars := append([]rune{}, e.Row(y)[:x1]...)
+			e.SetRow(y, append(chars, e.Row(y)[x2:]...))
+		}
+
+		e.SetY(r.start.y)
+		e.SetX(r.start.x)
+		return
+	}
+
+	if r.linewise {
+		for y := r.end.y; y >= r.start.y; y-- {
+			e.DeleteRow(y)
+		}
+
+		e.SetY(r.start.y)
+		e.WrapCursorY()
+		e.SetX(len(leadingWhitespace(cursorRow(e))))
+		return
+	}
+
+	start, end := rangeEnds(e, r)
+	chars := append([]rune{}, e.Row(start.y)[:start.x]...)
+	chars = append(chars, e.Row(end.y)[end.x:]...)
+	for y := end.y; y > start.y; y-- {
+		e.DeleteRow(y)
+	}
+	e.SetRow(start.y, chars)
+
+	e.SetY(start.y)
+	e.SetX(start.x)
+}
+
+func deleteOperator(e SDK, r textRange) error {
+	yankRange(e, r)
+	deleteRange(e, r)
+	e.WrapCursorX()
+	return nil
+}
+
+// changeOperator deletes the text and enters insert mode. Lines are
+// replaced with an empty one, keeping the indent with autoindent.
+func changeOperator(e SDK, r textRange) error {
+	yankRange(e, r)
+
+	if r.linewise {
+		var indent []rune
+		if e.Options().Autoindent {
+			indent = leadingWhitespace(e.Row(r.start.y))
+		}
+
+		for y := r.end.y; y > r.start.y; y-- {
+			e.DeleteRow(y)
+		}
+		e.SetRow(r.start.y, indent)
+		e.SetY(r.start.y)
+		e.SetX(len(indent))
+	} else {
+		deleteRange(e, r)
+	}
+
+	e.SetMode(InsertMode)
+	return nil
+}
+
+func yankOperator(e SDK, r textRange) error {
+	yankRange(e, r)
+
+	e.SetY(r.start.y)
+	if !r.linewise {
+		e.SetX(r.start.x)
+	}
+	return nil
+}
+
+// caseOperator returns an operator mapping each character of the text with
+// f.
+func caseOperator(f func(rune) rune) func(e SDK, r textRange) error {
+	return func(e SDK, r textRange) error {
+		start, end := rangeEnds(e, r)
+		for y := start.y; y <= end.y; y++ {
+			chars := append([]rune{}, e.Row(y)...)
+
+			x1, x2 := 0, len(chars)
+			if r.blockwise {
+				x1, x2 = blockColumns(e, r, y)
+			}
+			if !r.linewise && !r.blockwise && y == start.y {
+				x1 = start.x
+			}
+			if !r.linewise && !r.blockwise && y == end.y {
+				x2 = end.x
+			}
+
+			for x := x1; x < x2; x++ {
+				chars[x] = f(chars[x])
+			}
+			e.SetRow(y, chars)
+		}
+
+		e.SetY(start.y)
+		if !r.linewise {
+			e.SetX(start.x)
+		}
+		return nil
+	}
+}
+
+// toggleCase makes an uppercase letter lowercase and any other letter
+// uppercase.
+func toggleCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// shiftOperator returns an operator shifting every line of the text by n
+// levels of indentation. Empty lines are left alone.
+func shiftOperator(n int) func(e SDK, r textRange) error {
+	return func(e SDK, r textRange) error {
+		for y := r.start.y; y <= r.end.y; y++ {
+			if row := e.Row(y); len(row) > 0 {
+				e.SetRow(y, shiftLine(e, row, n))
+			}
+		}
+
+		e.SetY(r.start.y)
+		e.SetX(len(leadingWhitespace(e.Row(r.start.y))))
+		return nil
+	}
+}
+
+// shiftLine returns row with its indentation made n shiftwidths wider, or
+// narrower for a negative n, using spaces with expandtab and tabs otherwise.
+func shiftLine(e SDK, row []rune, n int) []rune {
+	opts := e.Options()
+	sw := opts.ShiftWidth
+	if sw <= 0 {
+		sw = opts.Tabstop
+	}
+
+	i := Find(row, func(c rune) bool { return c != ' ' && c != '\t' })
+	if i == -1 {
+		i = len(row)
+	}
+
+	width := e.VisualWidth(row[:i]) + n*sw
+	if width < 0 {
+		width = 0
+	}
+
+	var indent string
+	if opts.ExpandTab {
+		indent = strings.Repeat(" ", width)
+	} else {
+		indent = strings.Repeat("\t", width/opts.Tabstop) + strings.Repeat(" ", width%opts.Tabstop)
+	}
+
+	return append([]rune(indent), row[i:]...)
+}
+
+// charClass groups the characters making up words: spaces, word characters
+// and punctuation.
+func charClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// wordObject returns a text object selecting the word under the cursor, or
+// the run of spaces. With around, the spaces after the word are selected
+// too, or the ones before it if there are none after.
+func wordObject(around bool) func(e SDK) (textRange, bool) {
+	return func(e SDK) (textRange, bool) {
+		row := cursorRow(e)
+		x := e.X()
+		if x >= len(row) {
+			return textRange{}, false
+		}
+
+		class := charClass(row[x])
+		x1, x2 := x, x+1
+		for x1 > 0 && charClass(row[x1-1]) == class {
+			x1--
+		}
+		for x2 < len(row) && charClass(row[x2]) == class {
+			x2++
+		}
+
+		if around && class != 0 {
+			end := x2
+			for end < len(row) && unicode.IsSpace(row[end]) {
+				end++
+			}
+			if end > x2 {
+				x2 = end
+			} else {
+				for x1 > 0 && unicode.IsSpace(row[x1-1]) {
+					x1--
+				}
+			}
+		}
+
+		return textRange{start: position{x1, e.Y()}, end: position{x2, e.Y()}}, true
+	}
+}
+
+// bracketObject returns a text object selecting the text between the
+// innermost pair of open brackets around the cursor, or with around the
+// text and the brackets.
+func bracketObject(open rune, around bool) func(e SDK) (textRange, bool) {
+	return func(e SDK) (textRange, bool) {
+		if e.Y() >= e.NumRows() {
+			return textRange{}, false
+		}
+
+		// Look back for an opening bracket matched after the cursor
+		cx, cy := e.X(), e.Y()
+		x := cx
+		for y := cy; y >= 0; y-- {
+			row := e.Row(y)
+			if y != cy {
+				x = len(row) - 1
+			}
+			if x >= len(row) {
+				x = len(row) - 1
+			}
+
+			for ; x >= 0; x-- {
+				if row[x] != open {
+					continue
+				}
+
+				mx, my := e.matchingBracketAt(x, y)
+				if my == -1 || my < cy || my == cy && mx < cx {
+					continue
+				}
+
+				if around {
+					return textRange{start: position{x, y}, end: position{mx + 1, my}}, true
+				}
+				return textRange{start: position{x + 1, y}, end: position{mx, my}}, true
+			}
+		}
+
+		return textRange{}, false
+	}
+}
+
+// quoteObject returns a text object selecting the text between the quotes
+// around the cursor on its line, or with around the text and the quotes.
+func quoteObject(quote rune, around bool) func(e SDK) (textRange, bool) {
+	return func(e SDK) (textRange, bool) {
+		row := cursorRow(e)
+		x := e.X()
+		if x >= len(row) {
+			return textRange{}, false
+		}
+
+		// Pair up the quotes from the start of the line, so that the
+		// cursor between two strings isn't taken to be inside one
+		var quotes []int
+		for i, r := range row {
+			if r == quote && (i == 0 || row[i-1] != '\\') {
+				quotes = append(quotes, i)
+			}
+		}
+
+		for i := 0; i+1 < len(quotes); i += 2 {
+			open, end := quotes[i], quotes[i+1]
+			if x < open || x > end {
+				continue
+			}
+
+			if around {
+				return textRange{start: position{open, e.Y()}, end: position{end + 1, e.Y()}}, true
+			}
+			return textRange{start: position{open + 1, e.Y()}, end: position{end, e.Y()}}, true
+		}
+
+		return textRange{}, false
+	}
+}
@@ -0,0 +1,84 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		keys  string
+		want  []string
+		// cursor after the keys
+		x, y int
+	}{
+		{"dw", []string{"one two three"}, "dw", []string{"two three"}, 0, 0},
+		{"d2w", []string{"one two three four"}, "d2w", []string{"three four"}, 0, 0},
+		{"2dw", []string{"one two three four"}, "2dw", []string{"three four"}, 0, 0},
+		{"2d2w", []string{"a b c d e f"}, "2d2w", []string{"e f"}, 0, 0},
+		{"d2j", []string{"1", "2", "3", "4"}, "d2j", []string{"4"}, 0, 0},
+		{"dd with a count", []string{"1", "2", "3"}, "2dd", []string{"3"}, 0, 0},
+		{"dG ignores the repetition", []string{"1", "2", "3"}, "jdG", []string{"1"}, 0, 0},
+		{"dk on the first row", []string{"1", "2"}, "dk", []string{"1", "2"}, 0, 0},
+		{"dj on the last row", []string{"1", "2"}, "jdj", []string{"1", "2"}, 0, 1},
+		{"dG on the last row", []string{"1", "2"}, "jdG", []string{"1"}, 0, 0},
+		{"dgg on the first row", []string{"1", "2"}, "dgg", []string{"2"}, 0, 0},
+		{"cw changes to the end of the word", []string{"one two"}, "cwXX<C-c>", []string{"XX two"}, 2, 0},
+		{"c2w", []string{"one two three"}, "c2wX<C-c>", []string{"X three"}, 1, 0},
+		{"cw on the last word", []string{"one two"}, "wcwX<C-c>", []string{"one X"}, 5, 0},
+		{"dw keeps the spaces of a word", []string{"one two"}, "dw", []string{"two"}, 0, 0},
+		{"gUU", []string{"one two", "three"}, "wgUU", []string{"ONE TWO", "three"}, 4, 0},
+		{"guu", []string{"ONE", "TWO"}, "2guu", []string{"one", "two"}, 0, 0},
+		{"g~~", []string{"One two"}, "g~~", []string{"oNE TWO"}, 0, 0},
+		{"g~w", []string{"One two"}, "g~w", []string{"oNE two"}, 0, 0},
+		{"block", []string{"abc", "def"}, "ld<C-v>j", []string{"ac", "df"}, 1, 0},
+		{"block onto a shorter row", []string{"abc", ""}, "lld<C-v>j", []string{"ab", ""}, 2, 0},
+		{"block from a shorter row", []string{"", "abc"}, "jlld<C-v>k", []string{"", "ab"}, 0, 0},
+		{"block over a shorter row", []string{"abcd", "a", "abcd"}, "lld<C-v>2j", []string{"abd", "a", "abd"}, 2, 0},
+		{"block to the left", []string{"abcd", "abcd"}, "lld<C-v>0", []string{"d", "abcd"}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.lines...)
+			pressKeys(t, e, tt.keys)
+
+			if got := rowStrings(e.rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if e.cx != tt.x || e.cy != tt.y {
+				t.Errorf("cursor at %d, %d, want %d, %d", e.cx, e.cy, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestMotionCounts(t *testing.T) {
+	lines := []string{"one two three four", "2", "3", "4", "5"}
+
+	tests := []struct {
+		keys string
+		x, y int
+	}{
+		{"w", 4, 0},
+		{"3w", 14, 0},
+		{"2j", 0, 2},
+		{"10j", 0, 4},
+		{"3G", 0, 2},
+		{"3_", 0, 2},
+		{"G2k", 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keys, func(t *testing.T) {
+			e := newTestEditor(t, lines...)
+			pressKeys(t, e, tt.keys)
+
+			if e.cx != tt.x || e.cy != tt.y {
+				t.Errorf("cursor at %d, %d, want %d, %d", e.cx, e.cy, tt.x, tt.y)
+			}
+		})
+	}
+}
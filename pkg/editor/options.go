@@ -0,0 +1,284 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// boolOptions are the options that can be toggled with ":set name",
+// ":set noname" and ":set name!".
+var boolOptions = map[string]func(cfg *DisplayConfig) *bool{
+	"wrap":           func(cfg *DisplayConfig) *bool { return &cfg.Wrap },
+	"number":         func(cfg *DisplayConfig) *bool { return &cfg.Number },
+	"nu":             func(cfg *DisplayConfig) *bool { return &cfg.Number },
+	"autosave":       func(cfg *DisplayConfig) *bool { return &cfg.Autosave },
+	"autoindent":     func(cfg *DisplayConfig) *bool { return &cfg.Autoindent },
+	"ai":             func(cfg *DisplayConfig) *bool { return &cfg.Autoindent },
+	"expandtab":      func(cfg *DisplayConfig) *bool { return &cfg.ExpandTab },
+	"et":             func(cfg *DisplayConfig) *bool { return &cfg.ExpandTab },
+	"modeline":       func(cfg *DisplayConfig) *bool { return &cfg.Modeline },
+	"detectindent":   func(cfg *DisplayConfig) *bool { return &cfg.DetectIndent },
+	"trimwhitespace": func(cfg *DisplayConfig) *bool { return &cfg.TrimWhitespace },
+	"gitgutter":      func(cfg *DisplayConfig) *bool { return &cfg.GitGutter },
+	"scrollbar":      func(cfg *DisplayConfig) *bool { return &cfg.Scrollbar },
+	"changemarks":    func(cfg *DisplayConfig) *bool { return &cfg.ChangeMarks },
+	"title":          func(cfg *DisplayConfig) *bool { return &cfg.Title },
+	"hlsearch":       func(cfg *DisplayConfig) *bool { return &cfg.HLSearch },
+	"hls":            func(cfg *DisplayConfig) *bool { return &cfg.HLSearch },
+	"autocd":         func(cfg *DisplayConfig) *bool { return &cfg.AutoCD },
+}
+
+// intOptions are the options that take a numeric value with ":set name=N".
+var intOptions = map[string]func(cfg *DisplayConfig) *int{
+	"tabstop":    func(cfg *DisplayConfig) *int { return &cfg.Tabstop },
+	"ts":         func(cfg *DisplayConfig) *int { return &cfg.Tabstop },
+	"scrolloff":  func(cfg *DisplayConfig) *int { return &cfg.ScrollOff },
+	"so":         func(cfg *DisplayConfig) *int { return &cfg.ScrollOff },
+	"shiftwidth": func(cfg *DisplayConfig) *int { return &cfg.ShiftWidth },
+	"sw":         func(cfg *DisplayConfig) *int { return &cfg.ShiftWidth },
+	"synmaxcol":  func(cfg *DisplayConfig) *int { return &cfg.SynMaxCol },
+	"smc":        func(cfg *DisplayConfig) *int { return &cfg.SynMaxCol },
+}
+
+// stringOptions are the options that take arbitrary text with ":set name=text".
+var stringOptions = map[string]func(cfg *DisplayConfig) *string{
+	"statusleft":    func(cfg *DisplayConfig) *string { return &cfg.StatusLeft },
+	"statusright":   func(cfg *DisplayConfig) *string { return &cfg.StatusRight },
+	"leader":        func(cfg *DisplayConfig) *string { return &cfg.Leader },
+	"commentstring": func(cfg *DisplayConfig) *string { return &cfg.CommentString },
+	"background":    func(cfg *DisplayConfig) *string { return &cfg.Background },
+	"reloadcmd":     func(cfg *DisplayConfig) *string { return &cfg.ReloadCommand },
+	"ambiwidth":     func(cfg *DisplayConfig) *string { return &cfg.AmbiWidth },
+	"bell":          func(cfg *DisplayConfig) *string { return &cfg.Bell },
+	"makeprg":       func(cfg *DisplayConfig) *string { return &cfg.MakeProgram },
+	"dateformat":    func(cfg *DisplayConfig) *string { return &cfg.DateFormat },
+	"secretfiles":   func(cfg *DisplayConfig) *string { return &cfg.SecretFiles },
+	"gpgrecipients": func(cfg *DisplayConfig) *string { return &cfg.GPGRecipients },
+}
+
+// SetOption applies a single ":set" argument, e.g. "wrap", "nowrap",
+// "wrap!" or "tabstop=4". Some options are remembered for the file, see
+// localOptions.
+func (e *Editor) SetOption(arg string) error {
+	if err := e.setOption(arg); err != nil {
+		return err
+	}
+
+	e.rememberOption(arg)
+	e.keepUserOption(arg)
+	return nil
+}
+
+// keepUserOption records an option set by the user, not by the config file,
+// a filetype command or a modeline, for applyFiletypeOptions to keep it.
+func (e *Editor) keepUserOption(arg string) {
+	if e.applyingOptions > 0 {
+		return
+	}
+
+	name := optionName(arg)
+	if name == "filetype" || name == "ft" {
+		// The filetype is what changes the others
+		return
+	}
+	if _, ok := boolOptions[name]; ok {
+		// The value it ended up with, for "wrap!"
+		arg = e.optionArg(name)
+	}
+
+	// Only the last value of each option is kept
+	kept := e.userOptions[:0]
+	for _, prev := range e.userOptions {
+		if optionName(prev) != name {
+			kept = append(kept, prev)
+		}
+	}
+	e.userOptions = append(kept, arg)
+}
+
+// optionName returns the name of the option a ":set" argument changes, e.g.
+// "wrap" for "nowrap" or "wrap!".
+func optionName(arg string) string {
+	name, _, hasValue := strings.Cut(arg, "=")
+	name = strings.TrimSuffix(name, "!")
+	if _, ok := boolOptions[name]; !ok && !hasValue {
+		name = strings.TrimPrefix(name, "no")
+	}
+
+	return name
+}
+
+func (e *Editor) setOption(arg string) error {
+	if name, value, ok := strings.Cut(arg, "="); ok {
+		if name == "filetype" || name == "ft" {
+			return e.SetFiletype(value)
+		}
+
+		if opt, ok := stringOptions[name]; ok {
+			switch name {
+			case "background":
+				return e.setBackground(value)
+			case "ambiwidth":
+				return e.setAmbiWidth(value)
+			case "bell":
+				return e.setBell(value)
+			}
+
+			*opt(&e.cfg) = value
+			return nil
+		}
+
+		opt, ok := intOptions[name]
+		if !ok {
+			return fmt.Errorf("unknown option: %s", name)
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s", name, value)
+		}
+
+		*opt(&e.cfg) = n
+	} else if opt, ok := boolOptions[strings.TrimSuffix(arg, "!")]; ok {
+		v := opt(&e.cfg)
+		*v = !strings.HasSuffix(arg, "!") || !*v
+	} else if opt, ok := boolOptions[strings.TrimPrefix(arg, "no")]; ok {
+		*opt(&e.cfg) = false
+	} else if arg == "filetype" || arg == "ft" {
+		e.SetMessage("filetype=%s", e.Filetype())
+		return nil
+	} else if _, ok := intOptions[arg]; ok {
+		return fmt.Errorf("option requires a value: %s", arg)
+	} else {
+		return fmt.Errorf("unknown option: %s", arg)
+	}
+
+	if e.cfg.Tabstop == 0 {
+		e.cfg.Tabstop = defaultDisplayConfig.Tabstop
+	}
+
+	// The render strings depend on options like tabstop
+	for i := range e.rows {
+		e.updateRow(i)
+	}
+
+	return nil
+}
+
+// setBackground sets the background option and reloads the colorscheme, so
+// that the variant for the new background is used.
+func (e *Editor) setBackground(value string) error {
+	switch value {
+	case BackgroundAuto, BackgroundDark, BackgroundLight:
+	default:
+		return fmt.Errorf("invalid value for background: %s", value)
+	}
+
+	e.cfg.Background = value
+	return e.setColorscheme(e.colorschemeName)
+}
+
+// Filetype returns the name of the current filetype, or "none".
+func (e *Editor) Filetype() string {
+	if e.syntax == nil {
+		return "none"
+	}
+
+	return e.syntax.filetype
+}
+
+func (e *Editor) Options() DisplayConfig {
+	return e.cfg
+}
+
+// addFiletypeCommand registers an ex command, usually a ":set", to run
+// whenever a file of the given filetype is opened.
+func (e *Editor) addFiletypeCommand(filetype, command string) {
+	if e.filetypeCommands == nil {
+		e.filetypeCommands = make(map[string][]string)
+	}
+
+	e.filetypeCommands[filetype] = append(e.filetypeCommands[filetype], command)
+}
+
+// SetFiletype forces the syntax highlighting and settings of the filetype
+// with the given name, whatever the file is called. An empty name or "none"
+// turns off syntax highlighting.
+func (e *Editor) SetFiletype(name string) error {
+	if len(name) == 0 || name == "none" {
+		e.setSyntax(nil)
+		return nil
+	}
+
+	for _, syntax := range syntaxes {
+		if syntax.filetype == name {
+			e.setSyntax(syntax)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown filetype: %s", name)
+}
+
+// setSyntax changes the syntax used to highlight the file and applies the
+// settings of its filetype.
+func (e *Editor) setSyntax(syntax *EditorSyntax) {
+	e.syntax = syntax
+	for i := range e.rows {
+		e.updateHighlight(i)
+	}
+
+	e.applyFiletypeOptions()
+	e.notify(EventFileTypeSet)
+}
+
+// applyFiletypeOptions resets the options to the ones from the config file
+// and those set by the user since, and applies the settings of the current
+// filetype on top of them.
+func (e *Editor) applyFiletypeOptions() {
+	e.applyingOptions++
+	defer func() { e.applyingOptions-- }()
+
+	e.cfg = e.globalCfg
+	for _, arg := range e.userOptions {
+		if err := e.setOption(arg); err != nil {
+			logErrorf("setting %s again: %s", arg, err)
+		}
+	}
+
+	if e.syntax != nil {
+		if len(e.syntax.scs) != 0 {
+			e.cfg.CommentString = e.syntax.scs
+		}
+
+		for _, cmd := range e.filetypeCommands[e.syntax.filetype] {
+			if err := e.ExecCommand(cmd); err != nil {
+				e.SetMessage("filetype %s: %s", e.syntax.filetype, err)
+			}
+		}
+	}
+
+	e.applyAmbiWidth()
+	for i := range e.rows {
+		e.updateRow(i)
+	}
+}
+
+// optionNames returns the names of all the options, sorted.
+func optionNames() []string {
+	names := []string{"filetype", "ft"}
+	for name := range boolOptions {
+		names = append(names, name, "no"+name)
+	}
+	for name := range intOptions {
+		names = append(names, name)
+	}
+	for name := range stringOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
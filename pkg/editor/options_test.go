@@ -0,0 +1,40 @@
+package editor
+
+import "testing"
+
+func TestFiletypeKeepsUserOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		wrap     bool
+		tabstop  int
+	}{
+		{"set before", []string{"set wrap", "set ts=3", "set ft=python"}, true, 3},
+		{"toggled", []string{"set wrap!", "set wrap!", "set wrap!", "set ft=python"}, true, 8},
+		{"set back", []string{"set wrap", "set nowrap", "set ft=python"}, false, 8},
+		{"short name", []string{"set ts=2", "set tabstop=5", "set ft=python"}, false, 5},
+		{"filetype overrides", []string{"set ts=2", "set ft=go"}, false, 4},
+		{"filetype settings don't stay", []string{"set ts=2", "set ft=go", "set ft=python"}, false, 2},
+		{"set after", []string{"set ft=go", "set ts=6"}, false, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t)
+			e.addFiletypeCommand("go", "set tabstop=4")
+
+			for _, cmd := range tt.commands {
+				if err := e.ExecCommand(cmd); err != nil {
+					t.Fatalf("%s: %s", cmd, err)
+				}
+			}
+
+			if e.cfg.Wrap != tt.wrap {
+				t.Errorf("wrap = %t, want %t", e.cfg.Wrap, tt.wrap)
+			}
+			if e.cfg.Tabstop != tt.tabstop {
+				t.Errorf("tabstop = %d, want %d", e.cfg.Tabstop, tt.tabstop)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package editor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Output too long for the message bar, from ":!cmd", ":make" and
+// ":messages", goes to the output buffer: a named scratch buffer that can't
+// be changed, opened again with ":output". The "file:line:col" locations at
+// the start of its lines, as compilers and grep print them, are highlighted,
+// and Enter on one opens the file there.
+
+// outputScratch is the name of the scratch buffer holding the output.
+const outputScratch = "output"
+
+// locationPattern matches a location at the start of a line, e.g.
+// "main.go:12:5: undefined: x", with the line and column as groups.
+var locationPattern = regexp.MustCompile(`^\s*([^\s:]+):(\d+)(?::(\d+))?`)
+
+// parseLocation returns the file and the position, counting from zero, of
+// the location at the start of line.
+func parseLocation(line string) (file string, x, y int, ok bool) {
+	m := locationPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", 0, 0, false
+	}
+
+	y, _ = strconv.Atoi(m[2])
+	if len(m[3]) > 0 {
+		x, _ = strconv.Atoi(m[3])
+	}
+
+	if x > 0 {
+		x--
+	}
+	if y > 0 {
+		y--
+	}
+	return m[1], x, y, true
+}
+
+// highlightLocation marks the file name and the numbers of the location at
+// the start of a row.
+func highlightLocation(row *Row, runes []rune) {
+	m := locationPattern.FindStringSubmatchIndex(string(runes))
+	if m == nil {
+		return
+	}
+
+	text := string(runes)
+	mark := func(start, end int, hl SyntaxHL) {
+		for i := utf8.RuneCountInString(text[:start]); i < utf8.RuneCountInString(text[:end]); i++ {
+			row.hl[i] = hl
+		}
+	}
+	mark(m[2], m[3], hlKeyword1)
+	mark(m[4], m[1], hlNumber)
+}
+
+// IsReadOnly reports whether the text can't be changed, which is only the
+// case in the output buffer.
+func (e *Editor) IsReadOnly() bool {
+	return e.scratch.open && e.scratch.name == outputScratch
+}
+
+// revertReadOnly takes back the changes of the running transaction, made in
+// the output buffer.
+func (e *Editor) revertReadOnly() {
+	h := &e.history
+	step := h.current
+	h.current = nil
+
+	// The row methods would end the transaction again
+	h.depth++
+	e.undoChanges(step.changes)
+	h.depth--
+
+	e.cx, e.cy = step.before.x, step.before.y
+	e.SetMessage("the output buffer is read-only")
+}
+
+// openOutput opens the output buffer, emptied, with title as its first line.
+func (e *Editor) openOutput(title string) error {
+	if s := e.scratch.stashed; s != nil {
+		delete(s, outputScratch)
+	}
+	if err := e.openScratch(outputScratch); err != nil {
+		return err
+	}
+
+	e.appendOutput(title)
+	return nil
+}
+
+// appendOutput adds lines to the end of the output buffer, even while
+// another file is open.
+func (e *Editor) appendOutput(lines ...string) {
+	if !e.IsReadOnly() {
+		if stashed, ok := e.scratch.stashed[outputScratch]; ok {
+			e.scratch.stashed[outputScratch] = append(stashed, lines...)
+		}
+		return
+	}
+
+	for _, line := range lines {
+		e.rows = append(e.rows, &Row{chars: []rune(line)})
+		e.updateRow(len(e.rows) - 1)
+	}
+	e.markSavedText()
+}
+
+// outputLocation returns the location on the cursor row of the output
+// buffer.
+func outputLocation(e SDK) (file string, x, y int, ok bool) {
+	if e.Y() >= e.NumRows() {
+		return "", 0, 0, false
+	}
+
+	return parseLocation(string(e.Row(e.Y())))
+}
+
+// openOutputLocation opens the file at the location on the cursor row of the
+// output buffer.
+func openOutputLocation(e SDK) error {
+	file, x, y, ok := outputLocation(e)
+	if !ok || !e.IsReadOnly() {
+		e.Bell()
+		return nil
+	}
+
+	return e.openFileAt(file, x, y)
+}
+
+// runToOutput runs a shell command in the background, its output going to
+// the output buffer as it comes.
+func runToOutput(e SDK, command string) error {
+	if err := e.openOutput("$ " + command); err != nil {
+		return err
+	}
+
+	var job *Job
+	output := func(line string) { e.appendOutput(line) }
+	job, err := e.StartJob("sh", []string{"-c", command}, JobCallbacks{
+		Stdout: output,
+		Stderr: output,
+		Exit: func(err error) {
+			status := jobStatus(job)
+			e.appendOutput("", "["+status+"]")
+			e.SetMessage("%s: %s", command, status)
+		},
+	})
+	return err
+}
+
+// shellCommand runs a shell command, showing its output in the output
+// buffer: "!<command>".
+func shellCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: !<command>")
+	}
+
+	return runToOutput(e, args)
+}
+
+// makeCommand runs the makeprg option, "make" by default, with the given
+// arguments: "make [args]".
+func makeCommand(e SDK, r *Range, args string) error {
+	return runToOutput(e, strings.TrimSpace(e.Options().MakeProgram+" "+args))
+}
+
+// outputCommand opens the output buffer again: "output".
+func outputCommand(e SDK, r *Range, args string) error {
+	return e.openScratch(outputScratch)
+}
@@ -0,0 +1,149 @@
+package editor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Paragraphs are separated by blank lines, and sentences end with '.', '!'
+// or '?', maybe followed by closing brackets or quotes, and then a space or
+// the end of the line. A blank line ends a sentence too.
+
+// isBlankRow reports whether row y is empty or only has whitespace.
+func isBlankRow(e SDK, y int) bool {
+	return len(strings.TrimSpace(string(e.Row(y)))) == 0
+}
+
+// paragraphEdge returns the first blank row in direction dir that comes after
+// a paragraph, counting the cursor's row, or -1 if there isn't one.
+func paragraphEdge(e SDK, dir int) int {
+	inParagraph := false
+	for y := e.Y(); y >= 0 && y < e.NumRows(); y += dir {
+		blank := isBlankRow(e, y)
+		if blank && inParagraph {
+			return y
+		}
+		inParagraph = inParagraph || !blank
+	}
+
+	return -1
+}
+
+// nextParagraph moves to the blank line after the paragraph, or to the end
+// of the file after the last one.
+func nextParagraph(e SDK) error {
+	y := paragraphEdge(e, 1)
+	if y == -1 {
+		if e.NumRows() > 0 {
+			e.SetY(e.NumRows() - 1)
+			e.SetX(len(e.Row(e.NumRows() - 1)))
+		}
+		return nil
+	}
+
+	e.SetY(y)
+	e.SetX(0)
+	return nil
+}
+
+// prevParagraph moves to the blank line before the paragraph, or to the
+// start of the file before the first one.
+func prevParagraph(e SDK) error {
+	y := paragraphEdge(e, -1)
+	if y == -1 {
+		y = 0
+	}
+
+	e.SetY(y)
+	e.SetX(0)
+	return nil
+}
+
+// isSentenceStart reports whether a sentence starts at x on row y. The first
+// blank line after a paragraph counts as a sentence of its own.
+func isSentenceStart(e SDK, x, y int) bool {
+	if isBlankRow(e, y) {
+		return x == 0 && (y == 0 || !isBlankRow(e, y-1))
+	}
+
+	row := e.Row(y)
+	if x >= len(row) || unicode.IsSpace(row[x]) {
+		return false
+	}
+
+	// Find the character before, which must be separated by spaces
+	px, prev := x-1, row
+	spaced := false
+	for px < 0 || unicode.IsSpace(prev[px]) {
+		spaced = true
+		if px >= 0 {
+			px--
+			continue
+		}
+
+		y--
+		if y < 0 || isBlankRow(e, y) {
+			return true
+		}
+		prev = e.Row(y)
+		px = len(prev) - 1
+	}
+
+	if !spaced {
+		return false
+	}
+
+	for px > 0 && strings.ContainsRune(`)]"'`, prev[px]) {
+		px--
+	}
+	return strings.ContainsRune(".!?", prev[px])
+}
+
+// nextSentence moves to the start of the next sentence, or to the end of
+// the file if there isn't one.
+func nextSentence(e SDK) error {
+	x, y := e.X()+1, e.Y()
+	for ; y < e.NumRows(); y, x = y+1, 0 {
+		for ; x <= len(e.Row(y)); x++ {
+			if isSentenceStart(e, x, y) {
+				e.SetY(y)
+				e.SetX(x)
+				return nil
+			}
+		}
+	}
+
+	if e.NumRows() > 0 {
+		e.SetY(e.NumRows() - 1)
+		e.SetX(len(e.Row(e.NumRows() - 1)))
+	}
+	return nil
+}
+
+// prevSentence moves to the start of the sentence, or of the one before if
+// the cursor is already at the start.
+func prevSentence(e SDK) error {
+	if e.Y() >= e.NumRows() {
+		return nil
+	}
+
+	x, y := e.X()-1, e.Y()
+	for y >= 0 {
+		for ; x >= 0; x-- {
+			if isSentenceStart(e, x, y) {
+				e.SetY(y)
+				e.SetX(x)
+				return nil
+			}
+		}
+
+		y--
+		if y >= 0 {
+			x = len(e.Row(y))
+		}
+	}
+
+	e.SetY(0)
+	e.SetX(0)
+	return nil
+}
@@ -0,0 +1,228 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Plugin is what a plugin adds to the editor.
+//
+// pluginNames are Go plugins, built with "go build -buildmode=plugin", in the
+// plugins directory next to the config file. Each one exports a variable
+// named Plugin of this type, which is registered when an editor starts.
+// Programs running the editor can also give it plugins of their own in
+// Options, or register them with RegisterPlugin:
+//
+//	var Plugin = editor.Plugin{
+//		Name: "hello",
+//		Commands: map[string]editor.ExCommand{
+//			"hello": func(e editor.SDK, r *editor.Range, args string) error {
+//				e.SetMessage("hello %s", args)
+//				return nil
+//			},
+//		},
+//	}
+type Plugin struct {
+	Name string
+
+	Actions  map[string]Action
+	Commands map[string]ExCommand
+	// Completions complete the arguments of ex commands, by command name
+	Completions map[string]CompletionFunc
+	// bindings are added to the default bindings of each keymap, so they
+	// can be changed by the config file like those
+	Bindings map[KeyMapName]map[string]string
+	// Highlight colors parts of rows on top of the syntax highlighting
+	Highlight HighlightFunc
+	// Hooks run on events, before the ones added by the config
+	Hooks map[Event]Hook
+}
+
+// HighlightFunc returns the parts of a row to color, given the filetype and
+// the row as it is displayed.
+type HighlightFunc func(filetype, row string) []HighlightSpan
+
+// HighlightSpan colors the runes from Start up to End of a row with a
+// highlight group such as "keyword1".
+type HighlightSpan struct {
+	Start, End int
+	Group      string
+}
+
+// RegisterPlugin adds the actions, commands, completions, bindings,
+// highlighting and hooks of a plugin to the editor. Actions and commands
+// can't replace existing ones. The bindings are added when the config is
+// loaded next.
+func (e *Editor) RegisterPlugin(p *Plugin) error {
+	for name := range p.Actions {
+		if _, ok := e.actions[name]; ok {
+			return errors.Errorf("%s: action %s already exists", p.Name, name)
+		}
+	}
+	for name := range p.Commands {
+		if _, ok := e.commands[name]; ok {
+			return errors.Errorf("%s: command %s already exists", p.Name, name)
+		}
+	}
+
+	for name, action := range p.Actions {
+		e.actions[name] = action
+	}
+	for name, cmd := range p.Commands {
+		e.commands[name] = cmd
+	}
+	for name, comp := range p.Completions {
+		e.completions[name] = comp
+	}
+
+	e.plugins = append(e.plugins, p)
+	return nil
+}
+
+// pluginNames returns the names of the editor's plugins, in the order they were
+// registered.
+func (e *Editor) pluginNames() []string {
+	names := make([]string, len(e.plugins))
+	for i, p := range e.plugins {
+		names[i] = p.Name
+	}
+
+	return names
+}
+
+// pluginDir returns the directory plugins are loaded from.
+func pluginDir() string {
+	path := configPath()
+	if len(path) == 0 {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(path), "plugins")
+}
+
+// LoadPlugins opens every plugin in dir, in the order of their names, for
+// them to be registered. A missing directory is not an error. Every plugin
+// is loaded even if an earlier one fails, and the first error is returned.
+func LoadPlugins(dir string) ([]*Plugin, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var plugins []*Plugin
+	var firstErr error
+	for _, path := range paths {
+		p, err := loadPlugin(path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrap(err, filepath.Base(path))
+			}
+			continue
+		}
+
+		plugins = append(plugins, p)
+	}
+
+	return plugins, firstErr
+}
+
+func loadPlugin(path string) (*Plugin, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := p.Lookup("Plugin")
+	if err != nil {
+		return nil, err
+	}
+
+	plug, ok := sym.(*Plugin)
+	if !ok {
+		return nil, fmt.Errorf("Plugin is a %T, not an editor.Plugin", sym)
+	}
+
+	if len(plug.Name) == 0 {
+		plug.Name = strings.TrimSuffix(filepath.Base(path), ".so")
+	}
+
+	return plug, nil
+}
+
+var (
+	pluginDirOnce    sync.Once
+	pluginDirPlugins []*Plugin
+	pluginDirErr     error
+)
+
+// loadPluginDir loads the plugins in the plugins directory, if it exists.
+// They are only loaded once, however many editors are started, and not
+// changed afterwards.
+func loadPluginDir() ([]*Plugin, error) {
+	pluginDirOnce.Do(func() {
+		if dir := pluginDir(); len(dir) > 0 {
+			pluginDirPlugins, pluginDirErr = LoadPlugins(dir)
+		}
+	})
+
+	return pluginDirPlugins, pluginDirErr
+}
+
+// applyPluginBindings adds the bindings of the plugins to the keymaps.
+func (e *Editor) applyPluginBindings() {
+	leader, _ := e.leader()
+
+	for _, p := range e.plugins {
+		for name, bindings := range p.Bindings {
+			keymap, ok := e.keymaps[name]
+			if !ok {
+				continue
+			}
+
+			for keys, action := range bindings {
+				if keys, err := normalizeKeys(keys, leader); err == nil {
+					keymap.Bindings[keys] = action
+				}
+			}
+		}
+	}
+}
+
+// applyPluginHighlights colors the parts of row the plugins ask for.
+func (e *Editor) applyPluginHighlights(row *Row) {
+	for _, p := range e.plugins {
+		if p.Highlight == nil {
+			continue
+		}
+
+		for _, span := range p.Highlight(e.Filetype(), row.render) {
+			group, ok := highlightNames[span.Group]
+			if !ok || span.Start < 0 {
+				continue
+			}
+
+			for i := span.Start; i < span.End && i < len(row.hl); i++ {
+				row.hl[i] = group
+			}
+		}
+	}
+}
+
+// pluginsCommand lists the registered plugins.
+func pluginsCommand(e SDK, r *Range, args string) error {
+	names := e.pluginNames()
+	if len(names) == 0 {
+		e.SetMessage("no plugins in %s", pluginDir())
+		return nil
+	}
+
+	e.SetMessage("plugins: %s", strings.Join(names, ", "))
+	return nil
+}
@@ -0,0 +1,173 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// projectMarkers are the files or directories found at the root of a
+// project.
+var projectMarkers = []string{".git", "go.mod"}
+
+// findProjectRoot returns the nearest directory at or above dir holding one
+// of the projectMarkers, or "" if there is none.
+func findProjectRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		for _, marker := range projectMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// enterProject finds the project root of the file, changing the working
+// directory to it with autocd.
+func (e *Editor) enterProject() {
+	e.root = ""
+	if len(e.filename) == 0 {
+		return
+	}
+
+	e.root = findProjectRoot(filepath.Dir(e.filename))
+	if e.cfg.AutoCD && len(e.root) > 0 {
+		if err := e.changeDir(e.root); err != nil {
+			e.SetMessage("autocd: %s", err)
+		}
+	}
+}
+
+// changeDir changes the working directory, keeping the file name pointing
+// at the same file.
+func (e *Editor) changeDir(dir string) error {
+	var abs string
+	if len(e.filename) > 0 {
+		abs, _ = filepath.Abs(e.filename)
+	}
+
+	if err := os.Chdir(expandPath(dir)); err != nil {
+		return err
+	}
+
+	if len(abs) > 0 {
+		e.filename = relativePath(abs)
+	}
+	return nil
+}
+
+// relativePath returns path relative to the working directory, unless it
+// is outside of it.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return path
+	}
+
+	return rel
+}
+
+// leaveLocalDir goes back to the global working directory when the one set
+// with ":lcd" is left for another file, returning filename relative to it.
+func (e *Editor) leaveLocalDir(filename string) string {
+	if len(e.globalDir) == 0 {
+		return filename
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filename
+	}
+
+	if err := os.Chdir(e.globalDir); err != nil {
+		e.SetMessage("%s", err)
+	}
+	e.globalDir = ""
+	return relativePath(abs)
+}
+
+// workDir returns the working directory, with the home directory written as
+// "~".
+func workDir() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 0 {
+		if wd == home {
+			return "~"
+		}
+		if strings.HasPrefix(wd, home+"/") {
+			return "~" + wd[len(home):]
+		}
+	}
+
+	return wd
+}
+
+// cdCommand changes the working directory, to the project root of the file
+// by default: "cd [dir]".
+func cdCommand(e SDK, r *Range, args string) error {
+	return e.changeWorkDir(args, false)
+}
+
+// lcdCommand changes the working directory until another file is opened:
+// "lcd [dir]".
+func lcdCommand(e SDK, r *Range, args string) error {
+	return e.changeWorkDir(args, true)
+}
+
+// pwdCommand shows the working directory: "pwd".
+func pwdCommand(e SDK, r *Range, args string) error {
+	e.SetMessage("%s", workDir())
+	return nil
+}
+
+// changeWorkDir changes the working directory to dir, or the project root of the
+// file when dir is empty. With local, the directory is only kept until
+// another file is opened.
+func (e *Editor) changeWorkDir(dir string, local bool) error {
+	if len(dir) == 0 {
+		if len(e.root) == 0 {
+			return errors.New("the file isn't in a project")
+		}
+		dir = e.root
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if err := e.changeDir(dir); err != nil {
+		return err
+	}
+
+	if !local {
+		e.globalDir = ""
+	} else if len(e.globalDir) == 0 {
+		e.globalDir = wd
+	}
+
+	e.SetMessage("%s", workDir())
+	return nil
+}
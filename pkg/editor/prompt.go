@@ -0,0 +1,148 @@
+package editor
+
+import "unicode"
+
+// lineEditor is the input of a prompt, edited with readline style keys.
+type lineEditor struct {
+	text []rune
+	// index of the rune before which the cursor is
+	pos int
+}
+
+// handle applies an editing key, reporting whether k was one.
+func (l *lineEditor) handle(k Key) bool {
+	switch k {
+	case keyArrowLeft, Key(ctrl('b')):
+		if l.pos > 0 {
+			l.pos--
+		}
+	case keyArrowRight, Key(ctrl('f')):
+		if l.pos < len(l.text) {
+			l.pos++
+		}
+	case keyHome, Key(ctrl('a')):
+		l.pos = 0
+	case keyEnd, Key(ctrl('e')):
+		l.pos = len(l.text)
+	case keyBackspace, Key(ctrl('h')):
+		if l.pos > 0 {
+			l.text = append(l.text[:l.pos-1], l.text[l.pos:]...)
+			l.pos--
+		}
+	case keyDelete:
+		if l.pos < len(l.text) {
+			l.text = append(l.text[:l.pos], l.text[l.pos+1:]...)
+		}
+	case Key(ctrl('w')):
+		start := l.pos
+		for start > 0 && unicode.IsSpace(l.text[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(l.text[start-1]) {
+			start--
+		}
+		l.text = append(l.text[:start], l.text[l.pos:]...)
+		l.pos = start
+	case Key(ctrl('u')):
+		l.text = append([]rune(nil), l.text[l.pos:]...)
+		l.pos = 0
+	default:
+		if !isPrintable(k) {
+			return false
+		}
+
+		l.text = append(l.text[:l.pos], append([]rune{rune(k)}, l.text[l.pos:]...)...)
+		l.pos++
+	}
+
+	return true
+}
+
+// set replaces the text, moving the cursor to its end.
+func (l *lineEditor) set(s string) {
+	l.text = []rune(s)
+	l.pos = len(l.text)
+}
+
+func (l *lineEditor) String() string {
+	return string(l.text)
+}
+
+// showPromptCursor puts the terminal cursor in the message bar, at the
+// position of the cursor of the input after prompt.
+func (e *Editor) showPromptCursor(prompt string, l *lineEditor) {
+	e.promptCursor = e.width.StringWidth(prompt) + e.width.StringWidth(string(l.text[:l.pos])) + 1
+}
+
+// promptOverlay is a prompt taking the keys typed instead of the keymaps.
+type promptOverlay struct {
+	prompt string
+	cb     func(k Key) (string, bool)
+	// the text shown after the prompt
+	text string
+	// the mode to go back to once the prompt is finished
+	mode EditorMode
+	// whether the input is a password, whose keys are never logged
+	hidden bool
+}
+
+// promptKey passes a key to the callback of the prompt.
+func (e *Editor) promptKey(k Key) {
+	p := e.prompt
+
+	// The prompt is closed while the callback runs, so that the callback
+	// can open another one, and so that the editor doesn't stay stuck in
+	// it if the callback panics
+	e.prompt = nil
+	e.SetMode(p.mode)
+	defer func() {
+		if e.prompt == nil {
+			e.promptCursor = 0
+		}
+	}()
+
+	text, finished := p.cb(k)
+	if finished || e.prompt != nil {
+		return
+	}
+
+	p.text = text
+	e.prompt = p
+	e.SetMode(PromptMode)
+}
+
+// setPromptText changes the text shown after the prompt, for input that
+// changes without a key being typed.
+func (e *Editor) setPromptText(text string) {
+	if e.prompt != nil {
+		e.prompt.text = text
+	}
+}
+
+// promptLine returns the line of the prompt, fitting in the message bar,
+// and the column the cursor is in on it counting from 1, or 0 if the cursor
+// isn't in the prompt. Long lines are cut at the start rather than at the
+// end while the cursor is in them, for it to stay visible.
+func (e *Editor) promptLine() (string, int) {
+	if e.prompt == nil {
+		return "", 0
+	}
+
+	line := e.prompt.prompt + e.prompt.text
+	col := e.promptCursor
+	if col == 0 {
+		if e.width.StringWidth(line) > e.screenCols {
+			line = e.width.Truncate(line, e.screenCols, "...")
+		}
+		return line, 0
+	}
+
+	// Drop columns from the start until the cursor is on the screen
+	chars := []rune(line)
+	for col > e.screenCols && len(chars) > 0 {
+		col -= e.width.RuneWidth(chars[0])
+		chars = chars[1:]
+	}
+
+	return e.width.Truncate(string(chars), e.screenCols, ""), col
+}
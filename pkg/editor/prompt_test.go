@@ -0,0 +1,106 @@
+package editor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPromptErrors(t *testing.T) {
+	errEnd := errors.New("end failed")
+
+	tests := []struct {
+		name string
+		// opens the prompt, with end as its callback
+		open func(e *Editor, end func(string) error)
+		keys string
+		// the input end is called with, or "" if it isn't called
+		input string
+		err   error
+	}{
+		{
+			name: "static prompt error",
+			open: func(e *Editor, end func(string) error) {
+				e.StaticPrompt("> ", end, nil)
+			},
+			keys:  "abc<CR>",
+			input: "abc",
+			err:   errEnd,
+		},
+		{
+			name: "static prompt cancelled",
+			open: func(e *Editor, end func(string) error) {
+				e.StaticPrompt("> ", end, nil)
+			},
+			keys: "abc<Esc>",
+		},
+		{
+			name: "password prompt error",
+			open: func(e *Editor, end func(string) error) {
+				e.passwordPrompt("Password: ", end)
+			},
+			keys:  "secret<CR>",
+			input: "secret",
+			err:   errEnd,
+		},
+		{
+			name: "password prompt cancelled",
+			open: func(e *Editor, end func(string) error) {
+				e.passwordPrompt("Password: ", end)
+			},
+			keys: "secret<C-q>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t)
+
+			var input string
+			tt.open(e, func(s string) error {
+				input = s
+				return errEnd
+			})
+			pressKeys(t, e, tt.keys)
+
+			if input != tt.input {
+				t.Errorf("input = %q, want %q", input, tt.input)
+			}
+			if err := e.scriptError(); err != tt.err {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if e.prompt != nil || e.Mode != CommandMode {
+				t.Errorf("prompt still open in mode %v", e.Mode)
+			}
+		})
+	}
+}
+
+func TestPromptNilCallback(t *testing.T) {
+	e := newTestEditor(t)
+
+	e.Prompt("> ", nil)
+	if err := e.scriptError(); err == nil {
+		t.Error("no error for a nil callback")
+	}
+	if e.prompt != nil {
+		t.Error("prompt opened without a callback")
+	}
+}
+
+// Errors sent by actions running on the main loop mustn't block it before
+// the loop gets to read them.
+func TestErrChanDoesNotBlock(t *testing.T) {
+	e := newTestEditor(t)
+
+	for i := 0; i < errChanSize; i++ {
+		e.ErrChan() <- errors.New("error")
+	}
+	for i := 0; i < errChanSize; i++ {
+		if err := e.scriptError(); err == nil {
+			t.Fatalf("error %d wasn't received", i)
+		}
+	}
+	if err := e.scriptError(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"sort"
+)
+
+// Each editor has its own actions, ex commands and completions of their
+// arguments: the built-in ones, those of its plugins and those defined by its
+// Lua scripts. Editors share none of them, so a program can run several.
+
+// Options configure an editor made by New or run by Run. The zero value is
+// the editor jk runs.
+type Options struct {
+	// pluginNames add their actions, commands, completions, bindings,
+	// highlighting and hooks to the editor, after the plugins in the
+	// plugins directory
+	Plugins []*Plugin
+	// CacheDir is the directory data about files is kept in between runs.
+	// If it is empty, jk in the XDG cache directory is used.
+	CacheDir string
+	// LogFile is the file logs are written to. If it is empty, $JK_LOG_FILE
+	// is used, or jk.log in the XDG state directory.
+	LogFile string
+}
+
+// New returns an editor reading keys from and drawing to t, or to the
+// process's terminal if t is nil.
+func New(t Terminal, opts Options) (*Editor, error) {
+	e := &Editor{term: t, opts: opts}
+	if err := e.Init(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// initRegistry gives the editor the built-in actions, commands and
+// completions, and those of its plugins. It returns the first error
+// registering a plugin.
+func (e *Editor) initRegistry() error {
+	e.actions = make(map[string]Action, len(builtinActions))
+	for name, action := range builtinActions {
+		e.actions[name] = action
+	}
+
+	e.commands = make(map[string]ExCommand, len(builtinCommands))
+	for name, cmd := range builtinCommands {
+		e.commands[name] = cmd
+	}
+
+	e.completions = make(map[string]CompletionFunc, len(builtinCompletions)+1)
+	for name, comp := range builtinCompletions {
+		e.completions[name] = comp
+	}
+	e.completions["map"] = WordCompletion(e.actionNames)
+
+	dirPlugins, err := loadPluginDir()
+	plugins := append(append([]*Plugin(nil), dirPlugins...), e.opts.Plugins...)
+	for _, p := range plugins {
+		if perr := e.RegisterPlugin(p); perr != nil && err == nil {
+			err = perr
+		}
+	}
+
+	return err
+}
+
+// action returns the named action.
+func (e *Editor) action(name string) (Action, bool) {
+	if e.lua != nil {
+		if action, ok := e.lua.actions[name]; ok {
+			return action, true
+		}
+	}
+
+	action, ok := e.actions[name]
+	return action, ok
+}
+
+// actionNames returns the names of all actions, sorted.
+func (e *Editor) actionNames() []string {
+	names := make([]string, 0, len(e.actions))
+	for name := range e.actions {
+		names = append(names, name)
+	}
+	if e.lua != nil {
+		for name := range e.lua.actions {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// command returns the named ex command.
+func (e *Editor) command(name string) (ExCommand, bool) {
+	if e.lua != nil {
+		if cmd, ok := e.lua.commands[name]; ok {
+			return cmd, true
+		}
+	}
+
+	cmd, ok := e.commands[name]
+	return cmd, ok
+}
+
+// commandNames returns the names of all ex commands, sorted.
+func (e *Editor) commandNames() []string {
+	names := make([]string, 0, len(e.commands))
+	for name := range e.commands {
+		names = append(names, name)
+	}
+	if e.lua != nil {
+		for name := range e.lua.commands {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// argCompletion returns the completion of the arguments of the named ex
+// command, or nil if they aren't completed.
+func (e *Editor) argCompletion(name string) CompletionFunc {
+	return e.completions[name]
+}
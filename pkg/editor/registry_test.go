@@ -0,0 +1,64 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestRegistryIsPerEditor(t *testing.T) {
+	called := 0
+	plugin := &Plugin{
+		Name: "test",
+		Actions: map[string]Action{
+			"test-action": {"count calls", func(SDK) error { called++; return nil }},
+		},
+		Commands: map[string]ExCommand{
+			"test-command": func(e SDK, r *Range, args string) error { called++; return nil },
+		},
+		Completions: map[string]CompletionFunc{
+			"test-command": WordCompletion(staticWords("a", "b")),
+		},
+	}
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	with, err := New(headlessTerminal{}, Options{Plugins: []*Plugin{plugin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	without := newTestEditor(t)
+
+	if err := with.RunAction("test-action"); err != nil {
+		t.Error(err)
+	}
+	if err := with.ExecCommand("test-command"); err != nil {
+		t.Error(err)
+	}
+	if called != 2 {
+		t.Errorf("the plugin was called %d times, want 2", called)
+	}
+	if with.argCompletion("test-command") == nil {
+		t.Error("the completion of the plugin is missing")
+	}
+	if names := with.pluginNames(); len(names) != 1 || names[0] != "test" {
+		t.Errorf("Plugins() = %v", names)
+	}
+
+	if _, ok := without.action("test-action"); ok {
+		t.Error("the other editor has the action")
+	}
+	if err := without.ExecCommand("test-command"); err == nil {
+		t.Error("the other editor has the command")
+	}
+	if len(without.pluginNames()) != 0 {
+		t.Errorf("the other editor has plugins: %v", without.pluginNames())
+	}
+
+	// pluginNames can't replace what an editor has
+	if err := without.RegisterPlugin(&Plugin{Commands: map[string]ExCommand{"set": nil}}); err == nil {
+		t.Error("a plugin replaced a built-in command")
+	}
+	if err := with.RegisterPlugin(plugin); err == nil {
+		t.Error("a plugin was registered twice")
+	}
+}
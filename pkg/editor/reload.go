@@ -0,0 +1,150 @@
+package editor
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// RestartEditor is returned by the reload action to rebuild the editor and
+// start it again.
+var RestartEditor = errors.New("reload the editor")
+
+const (
+	// sessionCache is the kind of cached data holding the DisplaySettings
+	// of a file, saved when the editor reloads.
+	sessionCache = "session"
+	// recoveryCache is the kind of cached data holding the unsaved text of
+	// a file, as plain text.
+	recoveryCache = "recovery"
+)
+
+// rebuild runs the reloadcmd option, if it is set, with the shell.
+func (e *Editor) rebuild() error {
+	if len(e.cfg.ReloadCommand) == 0 {
+		return nil
+	}
+
+	e.SetMessage("reload: running %s", e.cfg.ReloadCommand)
+	e.Render()
+
+	out, err := exec.Command("sh", "-c", e.cfg.ReloadCommand).CombinedOutput()
+	logInfof("build output: %s", out)
+	if err != nil {
+		return errors.Errorf("reloadcmd: %s", firstLine(out, err))
+	}
+
+	return nil
+}
+
+// saveSession keeps the cursor position, and any unsaved text in the
+// recovery file, for the next start with "-z".
+func (e *Editor) saveSession() error {
+	if e.isSecret() {
+		if e.modified {
+			return errors.Errorf("%s is secret and has unsaved changes, save it before reloading", e.filename)
+		}
+		return nil
+	}
+
+	if e.modified {
+		if err := e.writeRecovery(); err != nil {
+			return err
+		}
+	}
+
+	return e.writeCache(sessionCache, e.filename, DisplaySettings{
+		X:         e.cx,
+		Y:         e.cy,
+		RowOffset: e.rowOffset,
+		ColOffset: e.colOffset,
+		Modified:  e.modified,
+	})
+}
+
+// recoveryPath returns the path of the recovery file of the current file.
+func (e *Editor) recoveryPath() (string, error) {
+	path, err := e.cachePath(recoveryCache, e.filename)
+	if err != nil {
+		return "", err
+	}
+
+	return path + ".txt", nil
+}
+
+// writeRecovery writes the text to the recovery file.
+func (e *Editor) writeRecovery() error {
+	path, err := e.recoveryPath()
+	if err != nil {
+		return err
+	}
+
+	return e.writeText(path)
+}
+
+// writeText writes the text to the file at path.
+func (e *Editor) writeText(path string) error {
+	var text bytes.Buffer
+	for _, row := range e.rows {
+		text.WriteString(string(row.chars))
+		text.WriteByte('\n')
+	}
+
+	return writePrivate(path, text.Bytes())
+}
+
+// recoverText replaces the text with that of the recovery file, marking it as
+// modified, and removes the recovery file.
+func (e *Editor) recoverText() error {
+	path, err := e.recoveryPath()
+	if err != nil {
+		return err
+	}
+
+	return e.restoreText(path)
+}
+
+// restoreText replaces the text with that of the file at path, marking it as
+// modified, and removes the file.
+func (e *Editor) restoreText(path string) error {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+	e.rows = e.rows[:0]
+	for i, line := range lines {
+		e.rows = append(e.rows, &Row{chars: []rune(line)})
+		e.updateRow(i)
+	}
+
+	e.markModified()
+	e.WrapCursorY()
+	e.WrapCursorX()
+
+	return os.Remove(path)
+}
+
+// reexec replaces the process with a new run of the executable, with the
+// same arguments and "-z" to restore the session. It only returns if that
+// fails.
+func reexec(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	argv := []string{exe, "-z"}
+	for _, arg := range args {
+		if arg != "-z" {
+			argv = append(argv, arg)
+		}
+	}
+
+	return syscall.Exec(exe, argv, os.Environ())
+}
@@ -0,0 +1,220 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// The editor can be controlled from other programs by listening on a unix
+// socket. Requests use JSON-RPC 1.0, with methods named "Editor.Open",
+// "Editor.Goto", "Editor.Insert" and "Editor.Eval", each taking one of the
+// Remote* types below as its only parameter.
+
+// RemoteOpen opens a file. It fails if the current file has unsaved changes.
+type RemoteOpen struct {
+	Filename string
+}
+
+// RemoteGoto moves the cursor to a line and column, both counting from 1.
+// A column of 0 keeps the cursor's column.
+type RemoteGoto struct {
+	Line, Col int
+}
+
+// RemoteInsert inserts text at the cursor.
+type RemoteInsert struct {
+	Text string
+}
+
+// RemoteEval runs an ex command, without the leading ':'. The reply is the
+// message the command left on the message bar.
+type RemoteEval struct {
+	Command string
+}
+
+// DefaultSocket returns the socket used by "jk --remote" when none is given:
+// $JK_SERVER, or a socket in $XDG_RUNTIME_DIR, or else in a directory of the
+// user's own under the temporary directory.
+func DefaultSocket() string {
+	if s := os.Getenv("JK_SERVER"); s != "" {
+		return s
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "jk.sock")
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("jk-%d", os.Getuid()), "jk.sock")
+}
+
+// remoteService has the methods served over the socket.
+type remoteService struct {
+	e *Editor
+}
+
+// do runs fn on the editor's main loop, so that it doesn't race with key
+// presses, and waits for it to finish.
+func (s *remoteService) do(fn func() error) error {
+	done := make(chan error, 1)
+	if !s.e.runOnMain(func() { done <- fn() }) {
+		return fmt.Errorf("the editor has exited")
+	}
+	return <-done
+}
+
+func (s *remoteService) Open(args *RemoteOpen, _ *struct{}) error {
+	return s.do(func() error {
+		if s.e.modified {
+			return fmt.Errorf("%s has unsaved changes", s.e.filename)
+		}
+
+		return s.e.OpenFile(args.Filename)
+	})
+}
+
+func (s *remoteService) Goto(args *RemoteGoto, _ *struct{}) error {
+	return s.do(func() error {
+		if args.Line < 1 || args.Line > s.e.NumRows() {
+			return fmt.Errorf("line %d is out of range", args.Line)
+		}
+
+		s.e.SetY(args.Line - 1)
+		if args.Col > 0 {
+			s.e.SetX(args.Col - 1)
+		}
+		s.e.WrapCursorX()
+		s.e.CenterCursor()
+		return nil
+	})
+}
+
+func (s *remoteService) Insert(args *RemoteInsert, _ *struct{}) error {
+	return s.do(func() error {
+		pasteYank(s.e, Yank{Text: args.Text}, false)
+		return nil
+	})
+}
+
+func (s *remoteService) Eval(args *RemoteEval, reply *string) error {
+	return s.do(func() error {
+		msg := s.e.statusmsg
+		if err := s.e.ExecCommand(args.Command); err != nil {
+			return err
+		}
+
+		if s.e.statusmsg != msg {
+			*reply = s.e.statusmsg
+		}
+		return nil
+	})
+}
+
+// listen serves remote requests on the unix socket at path until the
+// listener is closed. A socket left behind by an editor that is no longer
+// running is replaced, as long as the user running the editor owns it. Only
+// that user can connect, as requests can run any command.
+func (e *Editor) listen(path string) (net.Listener, error) {
+	if err := checkSocketDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("another editor is listening on %s", path)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 || !ownedByUser(info) {
+			return nil, fmt.Errorf("%s is in the way of the socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// The socket is created with the umask's permissions, so set it rather
+	// than chmod afterwards, when someone else may already have connected.
+	mask := syscall.Umask(0o177)
+	ln, err := net.Listen("unix", path)
+	syscall.Umask(mask)
+	if err != nil {
+		return nil, err
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Editor", &remoteService{e: e}); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				logInfof("remote: %s", err)
+				return
+			}
+
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	return ln, nil
+}
+
+// checkSocketDir makes sure no one else can swap the socket in dir for one of
+// their own. The directory is created if it is missing, and it must belong to
+// the user or root, and only be writable by others if it is sticky, like /tmp.
+func checkSocketDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	if uid, ok := fileOwner(info); !ok || (uid != os.Getuid() && uid != 0) {
+		return fmt.Errorf("%s belongs to another user", dir)
+	}
+	if info.Mode().Perm()&0o022 != 0 && info.Mode()&os.ModeSticky == 0 {
+		return fmt.Errorf("%s can be written to by other users", dir)
+	}
+	return nil
+}
+
+// fileOwner returns the user id owning the file info describes.
+func fileOwner(info os.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
+
+// ownedByUser reports whether the user running the editor owns the file.
+func ownedByUser(info os.FileInfo) bool {
+	uid, ok := fileOwner(info)
+	return ok && uid == os.Getuid()
+}
+
+// Remote asks the editor listening on socket to open filename.
+func Remote(socket, filename string) error {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+
+	client, err := jsonrpc.Dial("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Call("Editor.Open", &RemoteOpen{Filename: filename}, &struct{}{})
+}
@@ -0,0 +1,72 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenOnlyForUser(t *testing.T) {
+	e := newTestEditor(t)
+	path := filepath.Join(t.TempDir(), "jk.sock")
+
+	ln, err := e.listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket permissions are %o, want 600", perm)
+	}
+
+	if _, err := e.listen(path); err == nil {
+		t.Error("listened on a socket already in use")
+	}
+}
+
+func TestListenCreatesPrivateDir(t *testing.T) {
+	e := newTestEditor(t)
+	dir := filepath.Join(t.TempDir(), "jk")
+
+	ln, err := e.listen(filepath.Join(dir, "jk.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("socket directory permissions are %o, want 700", perm)
+	}
+}
+
+func TestListenRefusesUnsafePaths(t *testing.T) {
+	e := newTestEditor(t)
+
+	path := filepath.Join(t.TempDir(), "jk.sock")
+	if err := os.WriteFile(path, []byte("notes"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.listen(path); err == nil {
+		t.Error("listened in place of a regular file")
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "notes" {
+		t.Errorf("file in the way was changed: %q, %v", b, err)
+	}
+
+	shared := t.TempDir()
+	if err := os.Chmod(shared, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.listen(filepath.Join(shared, "jk.sock")); err == nil {
+		t.Error("listened in a directory anyone can write to")
+	}
+}
@@ -0,0 +1,49 @@
+package editor
+
+// "r" replaces the character under the cursor with the next key typed, or
+// with a count that many characters, and "R" enters replace mode, where typed characters take the place of the
+// ones under the cursor rather than being inserted before them.
+
+// replaceChar replaces the character at x on row y, all the runes of its
+// grapheme cluster, with c. Past the end of the row c is added to it.
+func replaceChar(e SDK, y, x int, c rune) {
+	if row := e.Row(y); x < len(row) {
+		e.Delete(y, x, nextCluster(row, x)-1)
+	}
+
+	e.InsertChars(y, x, c)
+}
+
+// replaceCharUnderCursor waits for a key and replaces the character under
+// the cursor, and the ones after it for a count, with it. Nothing is
+// replaced if the row doesn't have as many characters left.
+func replaceCharUnderCursor(e SDK) error {
+	n := e.Count()
+	if n < 1 {
+		n = 1
+	}
+
+	x, y := e.X(), e.Y()
+	row := cursorRow(e)
+	for i, end := 0, x; i < n; i++ {
+		if end >= len(row) {
+			e.Bell()
+			return nil
+		}
+		end = nextCluster(row, end)
+	}
+
+	e.awaitKey(func(k Key) error {
+		if isPrintable(k) {
+			for i := 0; i < n; i++ {
+				replaceChar(e, y, x+i, rune(k))
+			}
+			// On the last character replaced, as in vi
+			e.SetX(x + n - 1)
+		} else if k != keyEscape {
+			e.Bell()
+		}
+		return nil
+	})
+	return nil
+}
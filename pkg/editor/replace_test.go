@@ -0,0 +1,37 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplaceChar(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		keys string
+		want string
+		// cursor after the keys
+		x int
+	}{
+		{"one", "abcd", "rx", "xbcd", 0},
+		{"count", "abcd", "l2rx", "axxd", 2},
+		{"count to the end", "abcd", "l3rx", "axxx", 3},
+		{"count past the end", "abcd", "l4rx", "abcd", 1},
+		{"escape", "abcd", "r<Esc>", "abcd", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.line)
+			pressKeys(t, e, tt.keys)
+
+			if got := rowStrings(e.rows); !reflect.DeepEqual(got, []string{tt.want}) {
+				t.Errorf("rows = %q, want %q", got, []string{tt.want})
+			}
+			if e.cx != tt.x {
+				t.Errorf("cursor at %d, want %d", e.cx, tt.x)
+			}
+		})
+	}
+}
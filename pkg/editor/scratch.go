@@ -0,0 +1,122 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scratch buffers hold text that is never written to disk unless it is saved
+// under a file name, such as pasted snippets or command output. ":new" opens
+// an unnamed one and ":scratch <name>" a named one, whose text is kept while
+// other files are open so that it can be opened again.
+
+// scratchState is the scratch buffer being edited, if any, and the text of
+// the named ones that aren't.
+type scratchState struct {
+	open bool
+	// empty for an unnamed scratch buffer
+	name    string
+	stashed map[string][]string
+}
+
+// stashScratch keeps the text of the scratch buffer being edited, if it is
+// a named one, before something else is opened.
+func (e *Editor) stashScratch() {
+	s := &e.scratch
+	if !s.open {
+		return
+	}
+
+	if len(s.name) > 0 {
+		if s.stashed == nil {
+			s.stashed = map[string][]string{}
+		}
+		s.stashed[s.name] = rowStrings(e.rows)
+	}
+	s.open, s.name = false, ""
+}
+
+// openScratch opens the scratch buffer with the given name, or a new
+// unnamed one when name is empty.
+func (e *Editor) openScratch(name string) error {
+	if e.modified {
+		return fmt.Errorf("%s has unsaved changes", e.filename)
+	}
+
+	e.stashScratch()
+	lines := e.scratch.stashed[name]
+	delete(e.scratch.stashed, name)
+
+	e.filename = e.leaveLocalDir("")
+	e.root = ""
+	e.detectSyntax()
+	if name == outputScratch {
+		e.SetFiletype("output")
+	}
+
+	e.rows = make([]*Row, 0, len(lines))
+	for i, line := range lines {
+		e.rows = append(e.rows, &Row{chars: []rune(line)})
+		e.updateRow(i)
+	}
+
+	e.scratch.open, e.scratch.name = true, name
+	e.secret.file = false
+	e.modified = false
+	e.cx, e.cy, e.rowOffset, e.colOffset = 0, 0, 0, 0
+
+	e.updateDiff(true)
+	e.markSavedText()
+	e.resetHistory()
+	e.vars.buffer = nil
+
+	e.notify(EventBufOpen)
+	return nil
+}
+
+// bufferName returns the name of the file, or of the scratch buffer, to show
+// to the user.
+func (e *Editor) bufferName() string {
+	switch {
+	case len(e.filename) > 0:
+		return e.filename
+	case e.scratch.open && len(e.scratch.name) > 0:
+		return "[Scratch: " + e.scratch.name + "]"
+	case e.scratch.open:
+		return "[Scratch]"
+	}
+
+	return "[No Name]"
+}
+
+// scratchNames returns the names of the named scratch buffers, including
+// the one being edited.
+func (e *Editor) scratchNames() []string {
+	var names []string
+	for name := range e.scratch.stashed {
+		names = append(names, name)
+	}
+	if e.scratch.open && len(e.scratch.name) > 0 {
+		names = append(names, e.scratch.name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// newCommand opens a new unnamed scratch buffer: "new".
+func newCommand(e SDK, r *Range, args string) error {
+	return e.openScratch("")
+}
+
+// scratchCommand opens a named scratch buffer, "scratch" by default:
+// "scratch [name]".
+func scratchCommand(e SDK, r *Range, args string) error {
+	name := strings.TrimSpace(args)
+	if len(name) == 0 {
+		name = "scratch"
+	}
+
+	return e.openScratch(name)
+}
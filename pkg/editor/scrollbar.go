@@ -0,0 +1,99 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The scrollbar takes up the last column of the screen when the scrollbar
+// option is set. Its thumb shows which part of the file is on the screen,
+// and marks show where the last search matches and the line of the last
+// error are.
+
+// scrollbarWidth returns the number of columns taken up by the scrollbar.
+func (e *Editor) scrollbarWidth() int {
+	if !e.cfg.Scrollbar {
+		return 0
+	}
+
+	return 1
+}
+
+// scrollbarRow returns the line of the scrollbar standing for filerow.
+func (e *Editor) scrollbarRow(filerow int) int {
+	if len(e.rows) <= e.screenRows {
+		return filerow
+	}
+
+	return filerow * e.screenRows / len(e.rows)
+}
+
+// scrollbarMarks returns the highlight of the marks on each line of the
+// scrollbar that has one.
+func (e *Editor) scrollbarMarks() map[int]SyntaxHL {
+	marks := map[int]SyntaxHL{}
+
+	if query := e.search.Query; len(query) > 0 {
+		if lines := splitQuery(query); len(lines) > 1 {
+			for y := range e.rows {
+				if e.matchLines(y, lines) != -1 {
+					marks[e.scrollbarRow(y)] = hlMatch
+				}
+			}
+		} else {
+			s := newSearcher(query)
+			for y, row := range e.rows {
+				if s.index(row.chars) != -1 {
+					marks[e.scrollbarRow(y)] = hlMatch
+				}
+			}
+		}
+	}
+
+	if m := e.errorMark; m != nil && m.tick == e.changeTick {
+		marks[e.scrollbarRow(m.y)] = hlError
+	}
+
+	return marks
+}
+
+// drawScrollbar draws the scrollbar on every line of the text area, after
+// the text has been drawn.
+func (e *Editor) drawScrollbar(w io.Writer) {
+	if e.scrollbarWidth() == 0 {
+		return
+	}
+
+	thumbStart, thumbEnd := 0, e.screenRows
+	if len(e.rows) > e.screenRows {
+		thumbStart = e.scrollbarRow(e.rowOffset)
+		thumbEnd = thumbStart + e.screenRows*e.screenRows/len(e.rows)
+		if thumbEnd == thumbStart {
+			thumbEnd++
+		}
+	}
+
+	marks := e.scrollbarMarks()
+	for y := 0; y < e.screenRows; y++ {
+		fmt.Fprintf(w, "\x1b[%d;%dH", y+1, e.screenCols)
+
+		var styles []string
+		if y >= thumbStart && y < thumbEnd {
+			styles = append(styles, e.color(hlScrollbar))
+		}
+
+		// A mark on the thumb is drawn over the thumb's style
+		text := " "
+		if hl, ok := marks[y]; ok {
+			styles = append(styles, e.color(hl))
+			text = "-"
+		}
+
+		if len(styles) > 0 {
+			setStyle(w, strings.Join(styles, ";"))
+		}
+		w.Write([]byte(text))
+		clearFormatting(w)
+	}
+}
@@ -0,0 +1,835 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SDK is what actions, ex commands and hooks, including those of plugins,
+// use to work with the editor.
+type SDK interface {
+	InsertChars(y, x int, c ...rune)
+	InsertText(text string)
+	DeleteRow(at int)
+	FindInteractive()
+	// Position of the first match of query at or after x, y, or of the
+	// last one at or before it, or -1, -1 if there isn't one
+	Find(x, y int, query []rune) (x1, y1 int)
+	FindBack(x, y int, query []rune) (x1, y1 int)
+	MatchEnd(x, y int, query []rune) (x2, y2 int)
+
+	// Text of row y, which mustn't be changed other than with SetRow, or
+	// nil if there is no row y
+	Row(y int) []rune
+	SetRow(y int, chars []rune)
+	NumRows() int
+
+	// Query of the last search, or nil
+	LastSearch() []rune
+	// The last search, with its direction and options, and a new one
+	// for n and N to repeat
+	Search() SearchState
+	SetSearch(s SearchState)
+
+	Word() int
+	BackWord() int
+
+	IsModified() bool
+	// Whether the text can't be changed, as in the output buffer
+	IsReadOnly() bool
+
+	ErrChan() chan<- error
+	OpenFile(f string) error
+	Prompt(prompt string, cb func(Key) (string, bool))
+	// Run a line as if it was entered at the ':' prompt
+	ExecCommand(line string) error
+	StaticPrompt(prompt string, end func(string) error, cmpl CompletionFunc)
+	// Complete the text of the row from start to the cursor, showing the
+	// candidates in a menu
+	CompleteAtCursor(start int, comp CompletionFunc)
+	Save() error
+	SetMessage(format string, args ...interface{})
+	// Signal that a key did nothing, as set by the bell option
+	Bell()
+	// Show lines over the text area until the next key press
+	ShowLines(lines []string)
+	// Show items to pick one from, calling pick with the index of the
+	// chosen one
+	ShowMenu(items []string, selected int, pick func(i int) error)
+	// Variables set with ":let", "g:name" global and "b:name" the file's
+	Var(name string) (string, bool)
+	SetVar(name, value string) error
+	UnsetVar(name string) error
+	Filename() string
+	Filetype() string
+
+	Delete(y, x1, x2 int)
+
+	// Set the absolute position of the cursor's y (wrapped)
+	SetY(y int)
+	// Set the absolute position of the cursor's x (wrapped)
+	SetX(x int)
+
+	// Set the absolute position of the cursor's x (wrapped)
+	WrapCursorX()
+	// Wrap the cursor to keep it in bounds
+	WrapCursorY()
+
+	SetMode(m EditorMode)
+	// The count typed before the command being run, like the 5 of "5G",
+	// or 0
+	Count() int
+	// The mode the editor is in
+	CurrentMode() EditorMode
+
+	InsertRow(at int, chars []rune)
+
+	X() int
+	Y() int
+
+	Cols() int
+	Rows() int
+
+	CenterCursor()
+
+	SetOption(arg string) error
+	Options() DisplayConfig
+	// The number of columns chars take up on the screen, with the tabstop
+	// and ambiwidth options
+	VisualWidth(chars []rune) int
+
+	// Bind keys in key notation to an action in a keymap
+	Map(keymap, keys, action string) error
+	Unmap(keymap, keys string) error
+	RunAction(name string) error
+
+	// Add text to the kill ring, and get its entries, most recent first
+	AddYank(text string, linewise bool)
+	Yanks() []Yank
+	// Apply an operator like "delete" to the text of the next motion or
+	// text object
+	StartOperator(name string) error
+
+	// Run fn so that the changes it makes are undone as a whole, updating
+	// the highlighting of the rows it changes once at the end
+	Transaction(fn func() error) error
+	Undo() error
+	Redo() error
+
+	// Run hook whenever event happens
+	On(event Event, hook Hook)
+
+	// Run a command in the background, getting its output on the main loop
+	StartJob(name string, args []string, cb JobCallbacks) (*Job, error)
+
+	ScreenBottom() int
+	ScreenTop() int
+	ScreenLeft() int
+	ScreenRight() int
+
+	// The rest are for the editor's own actions and commands
+
+	// Whether nothing about the file is kept outside of it, like the
+	// session or recovery files, see secret.go
+	isSecret() bool
+	setSecret()
+	// Move to x on row y of a file, opening it if it isn't the current one
+	openFileAt(file string, x, y int) error
+	// Change the working directory, to the project root of the file when
+	// dir is empty, and only until another file is opened with local
+	changeWorkDir(dir string, local bool) error
+	// Open a named scratch buffer, never written unless saved under a file
+	// name, or a new unnamed one when name is empty
+	openScratch(name string) error
+	// Open the output buffer, emptied, with title as its first line, and
+	// add lines to it
+	openOutput(title string) error
+	appendOutput(lines ...string)
+	// Insert the template with the given name above the cursor row
+	insertTemplate(name string) error
+	commandNames() []string
+	// The named action, and the names of all actions, sorted
+	action(name string) (Action, bool)
+	actionNames() []string
+	// Completion of the arguments of the named ex command, or nil
+	argCompletion(name string) CompletionFunc
+	// Names of the plugins, in the order they were registered
+	pluginNames() []string
+	awaitKey(cb func(Key) error)
+	// Save the file as root, asking for the sudo password if needed
+	sudoSave() error
+	messageHistory() []string
+	// Search the files under the working directory for a regular
+	// expression, listing the matches as they are found
+	grep(pattern string) error
+	// The differences between the file as last opened or saved and the
+	// text
+	unsavedChanges() []DiffHunk
+	// Put row y back the way it is in the file
+	revertLine(y int) error
+	varLines() []string
+	// Move the cursor by screen lines rather than rows when wrapping
+	moveDisplayLine(n int)
+	// Run a command whenever a file of the filetype is opened
+	addFiletypeCommand(filetype, command string)
+	// Reset the options and run the config file again
+	loadConfig() error
+	setColorscheme(name string) error
+	// Update the highlighting of every row
+	rehighlight()
+	// Color the matches of a regexp with a highlight group or SGR
+	// parameters, on top of the syntax highlighting
+	addHighlight(color, expr string) error
+	clearHighlights()
+	currentColorscheme() string
+	bindings(keymap KeyMapName) map[string]string
+	// Position of the bracket matching the one at x, y, or -1, -1
+	matchingBracketAt(x, y int) (int, int)
+	// Differences between the text and the git index
+	diffHunks() []DiffHunk
+	// Add the change at row y to the git index, or undo it
+	stageHunk(y int) error
+	revertHunk(y int) error
+	// Show the text and another version of the file side by side
+	showDiff(path string) error
+	// Character separating the cells of tabular data, or 0
+	delimiter() rune
+	// Highlight row y as the place of an error until the text changes
+	highlightError(y int)
+	// Add a rectangle of text to the kill ring, one line per row
+	addBlockYank(text string)
+	// Run Lua code using the "jk" table
+	runLua(code string) error
+	jobList() []*Job
+	killJob(id int) error
+}
+
+// Row returns the text of row y, or nil if there is no row y.
+func (e *Editor) Row(y int) []rune {
+	if y < 0 || y >= len(e.rows) {
+		return nil
+	}
+
+	return e.rows[y].chars
+}
+
+// NumRows returns the number of rows of the text.
+func (e *Editor) NumRows() int {
+	return len(e.rows)
+}
+
+type CompletionFunc func(a string) ([]CmplItem, error)
+
+type CmplItem struct {
+	Display string
+	Real    string
+}
+
+// FileCompletion completes the last part of a path, relative to the
+// current directory unless it is absolute. "~" and environment variables
+// are expanded to find the files, but kept as they are in the completions.
+func FileCompletion(a string) ([]CmplItem, error) {
+	if a == "~" {
+		return []CmplItem{{Display: "~/", Real: "~/"}}, nil
+	}
+
+	// Yes this will break on windows, idc
+	i := strings.LastIndex(a, "/")
+	if i == -1 {
+		i = 0
+	} else {
+		i++
+	}
+
+	fileBasename := a[:i]
+	fileHead := a[i:]
+
+	dir := expandPath(fileBasename)
+	if len(dir) == 0 {
+		dir = "."
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []CmplItem
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), fileHead) {
+			continue
+		}
+
+		if f.IsDir() {
+			res = append(res, CmplItem{
+				Display: f.Name() + "/",
+				Real:    fileBasename + f.Name() + "/",
+			})
+		} else if f.Type().IsRegular() {
+			res = append(res, CmplItem{
+				Display: f.Name(),
+				Real:    fileBasename + f.Name(),
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// expandPath replaces a leading "~" or "~user" with the home directory and
+// $VAR or ${VAR} with the value of the environment variable.
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~") {
+		name, rest, _ := strings.Cut(path, "/")
+
+		var home string
+		if name == "~" {
+			home, _ = os.UserHomeDir()
+		} else if u, err := user.Lookup(name[1:]); err == nil {
+			home = u.HomeDir
+		}
+
+		if len(home) > 0 {
+			path = filepath.Join(home, rest)
+			if len(rest) == 0 || strings.HasSuffix(rest, "/") {
+				path += "/"
+			}
+		}
+	}
+
+	return os.ExpandEnv(path)
+}
+
+func Find(s []rune, f func(rune) bool) int {
+	for i := range s {
+		if f(s[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func FindLeft(s []rune, f func(rune) bool) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if f(s[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (e *Editor) Word() int {
+	x, y := e.X(), e.Y()
+	row := e.rows[y].chars
+
+	i := Find(row[x:], unicode.IsSpace)
+	if i == -1 {
+		return len(row)
+	}
+
+	j := Find(row[x+i:], func(r rune) bool { return !unicode.IsSpace(r) })
+	if j == -1 {
+		return len(row)
+	}
+
+	return x + i + j
+}
+
+func (e *Editor) BackWord() int {
+	x, y := e.X(), e.Y()
+	chars := e.rows[y].chars
+
+	i := FindLeft(chars[:x], unicode.IsSpace)
+	if i == -1 {
+		return 0
+	}
+
+	// If the cursor is already at the beginning of the word, go to
+	// the beginning of the next word
+	if i == x-1 {
+		i = FindLeft(chars[:x], func(r rune) bool { return !unicode.IsSpace(r) })
+		if i == -1 {
+			return 0
+		}
+
+		i = FindLeft(chars[:i], unicode.IsSpace)
+		if i == -1 {
+			return 0
+		}
+	}
+
+	return i + 1
+}
+
+func (e *Editor) CenterCursor() {
+	e.rowOffset = e.cy - (e.screenRows / 2)
+	if e.rowOffset < 0 {
+		e.rowOffset = 0
+	}
+}
+
+func (e *Editor) Rows() int {
+	return e.screenRows
+}
+
+func (e *Editor) Cols() int {
+	return e.screenCols
+}
+
+func (e *Editor) Filename() string {
+	return e.filename
+}
+
+func (e *Editor) IsModified() bool {
+	return e.modified
+}
+
+// markModified records that the text has changed.
+func (e *Editor) markModified() {
+	// Scratch buffers are never written, so they can always be left
+	e.modified = !e.scratch.open
+	e.changeTick++
+}
+
+func (e *Editor) X() int {
+	return e.cx
+}
+
+func (e *Editor) Y() int {
+	return e.cy
+}
+
+func (e *Editor) ScreenBottom() int {
+	return e.rowOffset + e.screenRows - 1
+}
+
+func (e *Editor) ScreenTop() int {
+	return e.rowOffset + 1
+}
+
+func (e *Editor) ScreenLeft() int {
+	return e.colOffset + 1
+}
+
+func (e *Editor) ScreenRight() int {
+	return e.colOffset + e.screenCols + 1
+}
+
+func (row *Row) insertChar(at int, c rune) {
+}
+
+func (e *Editor) InsertChars(y, x int, chars ...rune) {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	if e.cy == len(e.rows) {
+		e.InsertRow(len(e.rows), []rune(""))
+	}
+
+	row := e.rows[e.cy]
+	before := copyRunes(row.chars)
+
+	// make some room for the new chars
+	row.chars = append(row.chars, make([]rune, len(chars))...)
+
+	// shift the existing data back, and insert the chars in between
+	copy(row.chars[x+len(chars):], row.chars[x:])
+	copy(row.chars[x:], chars)
+
+	e.recordChange(rowChange{kind: rowSet, y: e.cy, before: before, after: copyRunes(row.chars)})
+	e.touchRow(e.cy)
+	e.markModified()
+}
+
+func (e *Editor) DeleteRow(at int) {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	e.recordChange(rowChange{kind: rowDeleted, y: at, before: copyRunes(e.rows[at].chars)})
+	e.rows = append(e.rows[:at], e.rows[at+1:]...)
+	e.markModified()
+}
+
+// Prompt shows the given prompt in the message bar and passes the keys typed
+// to the callback instead of the keymaps, until it reports that the prompt is
+// finished. The callback returns the text to show after the prompt.
+func (e *Editor) Prompt(prompt string, cb func(k Key) (string, bool)) {
+	if cb == nil {
+		e.ErrChan() <- fmt.Errorf("can't give a nil function to Prompt")
+		return
+	}
+
+	mode := e.Mode
+	if e.prompt != nil {
+		mode = e.prompt.mode
+	}
+
+	e.prompt = &promptOverlay{prompt: prompt, cb: cb, mode: mode}
+	e.SetMode(PromptMode)
+}
+
+// awaitKey passes the next key press to cb instead of the current
+// keymapping. It is used for commands made of several keys like "gj".
+func (e *Editor) awaitKey(cb func(k Key) error) {
+	backup := e.keymapping
+	e.keymapping = []KeyMap{{
+		Name: PendingKeyName,
+		Handler: func(_ SDK, k Key) (bool, error) {
+			e.keymapping = backup
+			return true, cb(k)
+		},
+	}}
+}
+
+// LastSearch returns the query of the last search, or nil if there hasn't
+// been one.
+func (e *Editor) LastSearch() []rune {
+	return e.search.Query
+}
+
+func (e *Editor) FindInteractive() {
+	savedCx := e.cx
+	savedCy := e.cy
+	savedColOffset := e.colOffset
+	savedRowOffset := e.rowOffset
+
+	var input lineEditor
+	e.showPromptCursor("Search: ", &input)
+
+	onKeyPress := func(k Key) (string, bool) {
+		switch k {
+		case keyEscape, Key(ctrl('q')):
+			e.incsearch = nil
+
+			// restore cursor position when the user cancels search
+			e.cx = savedCx
+			e.cy = savedCy
+			e.colOffset = savedColOffset
+			e.rowOffset = savedRowOffset
+
+			e.SetMessage("")
+			e.promptCursor = 0
+
+			return "", true
+		case keyEnter, keyCarriageReturn:
+			e.incsearch = nil
+			e.SetMessage("")
+			e.search = SearchState{Query: parseSearch(input.text)}
+			e.promptCursor = 0
+
+			return "", true
+		default:
+			before := input.String()
+			input.handle(k)
+
+			if !strings.HasPrefix(input.String(), before) {
+				// This forces the editor to search again to
+				// see if the current word is indeed the
+				// closest match. Yes making a stack containing
+				// the previous matches would be better, but it
+				// is somewhat unecessary at the moment
+				e.cx = savedCx
+				e.cy = savedCy
+			}
+		}
+
+		e.showPromptCursor("Search: ", &input)
+		query := parseSearch(input.text)
+		e.incsearch = query
+
+		x, y := e.Find(e.cx, e.cy, query)
+		if x == -1 {
+			e.cx = savedCx
+			e.cy = savedCy
+			e.colOffset = savedColOffset
+			e.rowOffset = savedRowOffset
+
+			return input.String(), false
+		}
+
+		// Set cursor to beginning of match
+		e.cy = y
+		e.cx = x
+
+		// Try to make the text in the middle of the screen
+		e.SetRowOffset(e.cy - e.screenRows/2)
+
+		return input.String(), false
+	}
+
+	e.Prompt("Search: ", onKeyPress)
+}
+
+// Find returns the position of the first match of query at or after x1, y1,
+// or -1, -1 if there isn't one.
+func (e *Editor) Find(x1, y1 int, query []rune) (x, y int) {
+	if len(query) == 0 || y1 < 0 || y1 >= len(e.rows) {
+		return -1, -1
+	}
+
+	if lines := splitQuery(query); len(lines) > 1 {
+		return e.findLines(x1, y1, lines)
+	}
+
+	if x1 < 0 {
+		x1 = 0
+	}
+
+	s := newSearcher(query)
+	if x1 <= len(e.rows[y1].chars) {
+		if x = s.index(e.rows[y1].chars[x1:]); x != -1 {
+			return x1 + x, y1
+		}
+	}
+
+	// The real search
+	for y = y1 + 1; y < len(e.rows); y++ {
+		if x = s.index(e.rows[y].chars); x != -1 {
+			return x, y
+		}
+	}
+
+	return -1, -1
+}
+
+// FindBack returns the position of the last match of query starting at or
+// before x1, y1, or -1, -1 if there isn't one.
+func (e *Editor) FindBack(x1, y1 int, query []rune) (x, y int) {
+	if len(query) == 0 || y1 < 0 || y1 >= len(e.rows) {
+		return -1, -1
+	}
+
+	if lines := splitQuery(query); len(lines) > 1 {
+		return e.findLinesBack(x1, y1, lines)
+	}
+
+	s := newSearcher(query)
+	if x = s.lastIndex(e.rows[y1].chars, x1); x != -1 {
+		return x, y1
+	}
+
+	// The real search
+	for y = y1 - 1; y >= 0; y-- {
+		if x = s.lastIndex(e.rows[y].chars, len(e.rows[y].chars)); x != -1 {
+			return x, y
+		}
+	}
+
+	return -1, -1
+}
+
+func (e *Editor) SetRowOffset(y int) {
+	if y < 0 {
+		y = 0
+	}
+
+	e.rowOffset = y
+}
+
+func (e *Editor) SetColOffset(x int) {
+	if x < 0 {
+		x = 0
+	}
+
+	e.colOffset = x
+}
+
+func (e *Editor) SetRow(at int, chars []rune) {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	e.recordChange(rowChange{kind: rowSet, y: at, before: copyRunes(e.rows[at].chars), after: copyRunes(chars)})
+	e.rows[at].chars = chars
+
+	e.touchRow(at)
+	e.markModified()
+}
+
+func (e *Editor) InsertRow(at int, chars []rune) {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	e.recordChange(rowChange{kind: rowInserted, y: at, after: copyRunes(chars)})
+	row := Row{chars: chars}
+	if at > 0 {
+		row.hasUnclosedComment = e.rows[at-1].hasUnclosedComment
+	}
+
+	// grow the buffer
+	e.rows = append(e.rows, &Row{})
+	copy(e.rows[at+1:], e.rows[at:])
+	e.rows[at] = &row
+
+	e.touchRow(at)
+	e.markModified()
+}
+
+func (e *Editor) Delete(y, x1, x2 int) {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	row := e.rows[y].chars
+	before := copyRunes(row)
+	e.rows[y].chars = append(row[:x1], row[x2+1:]...)
+
+	e.recordChange(rowChange{kind: rowSet, y: y, before: before, after: copyRunes(e.rows[y].chars)})
+	e.touchRow(y)
+	e.markModified()
+}
+
+func (e *Editor) SetY(y int) {
+	e.cy = y
+}
+
+func (e *Editor) WrapCursorX() {
+	if e.cx < 0 {
+		e.cx = 0
+		return
+	}
+
+	if len(e.rows) == 0 {
+		e.cx = 0
+		return
+	}
+
+	if len(e.rows[e.cy].chars) == 0 {
+		e.cx = 0
+		return
+	}
+
+	if e.cx >= len(e.rows[e.cy].chars) {
+		e.cx = len(e.rows[e.cy].chars)
+	}
+
+	// Never leave the cursor inside a grapheme cluster
+	e.cx = clusterStart(e.rows[e.cy].chars, e.cx)
+}
+
+func (e *Editor) WrapCursorY() {
+	if e.cy < 0 {
+		e.cy = 0
+		return
+	}
+
+	if len(e.rows) == 0 {
+		e.cy = 0
+		return
+	}
+
+	if e.cy >= len(e.rows) {
+		e.cy = len(e.rows) - 1
+	}
+}
+
+func (e *Editor) SetX(x int) {
+	e.cx = x
+}
+
+// Count returns the count typed before the command being run, or 0.
+func (e *Editor) Count() int {
+	return e.count
+}
+
+func (e *Editor) CurrentMode() EditorMode {
+	return e.Mode
+}
+
+func (e *Editor) SetMode(m EditorMode) {
+	if (m == InsertMode || m == ReplaceMode) && e.IsReadOnly() {
+		e.SetMessage("the output buffer is read-only")
+		return
+	}
+
+	if (e.Mode == InsertMode || e.Mode == ReplaceMode) && m == CommandMode {
+		e.autosave()
+	}
+
+	if m != e.Mode {
+		defer e.notify(EventModeChanged)
+	}
+
+	e.Mode = m
+
+	// Replace mode types with the insert mode keymap
+	if m == InsertMode || m == ReplaceMode {
+		for i, keymap := range e.keymapping {
+			if keymap.Name == CommandModeName {
+				e.keymapping[i] = e.keymaps[InsertModeName]
+				return
+			}
+		}
+	} else {
+		for i, keymap := range e.keymapping {
+			if keymap.Name == InsertModeName {
+				e.keymapping[i] = e.keymaps[CommandModeName]
+				return
+			}
+		}
+	}
+}
+
+// autosave saves the file if it has unsaved changes and the autosave
+// option is enabled.
+func (e *Editor) autosave() {
+	if !e.cfg.Autosave || !e.modified || len(e.filename) == 0 {
+		return
+	}
+
+	if err := e.saveFile(e.filename); err != nil && err != errAskingPassphrase {
+		e.SetMessage("autosave failed: %s", err)
+	}
+}
+
+// ErrChan returns the channel for the errors of prompts and other callbacks,
+// which the main loop shows in the message bar.
+func (e *Editor) ErrChan() chan<- error {
+	return e.errChan
+}
+
+// StaticPrompt is a "normal" prompt designed to only get input from the user.
+// It you want things to happen when you press any key, then use Prompt
+func (e *Editor) StaticPrompt(prompt string, end func(string) error, comp CompletionFunc) {
+	var input lineEditor
+	e.showPromptCursor(prompt, &input)
+
+	e.Prompt(prompt, func(k Key) (string, bool) {
+		// The menu stays open only while cycling through it
+		if k != Key('\t') && k != keyShiftTab {
+			e.completion = nil
+		}
+
+		switch k {
+		case keyEnter, keyCarriageReturn:
+			e.promptCursor = 0
+			if err := end(input.String()); err != nil {
+				e.ErrChan() <- err
+			}
+
+			return input.String(), true
+		case keyEscape, Key(ctrl('q')):
+			e.promptCursor = 0
+			return "", true
+		case Key('\t'), keyShiftTab:
+			if comp == nil {
+				break
+			}
+
+			dir := 1
+			if k == keyShiftTab {
+				dir = -1
+			}
+			input.set(e.complete(input.String(), comp, dir))
+		default:
+			input.handle(k)
+		}
+
+		e.showPromptCursor(prompt, &input)
+		return input.String(), false
+	})
+}
@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestRow(t *testing.T) {
+	e := newTestEditor(t, "one", "two")
+
+	tests := []struct {
+		y    int
+		want []rune
+	}{
+		{0, []rune("one")},
+		{1, []rune("two")},
+		{-1, nil},
+		{2, nil},
+		{100, nil},
+	}
+
+	for _, tt := range tests {
+		if got := e.Row(tt.y); string(got) != string(tt.want) || (got == nil) != (tt.want == nil) {
+			t.Errorf("Row(%d) = %q, want %q", tt.y, string(got), string(tt.want))
+		}
+	}
+
+	if n := e.NumRows(); n != 2 {
+		t.Errorf("NumRows() = %d, want 2", n)
+	}
+}
+
+func TestFind(t *testing.T) {
+	e := newTestEditor(t, "foo bar foo", "baz", "foo")
+
+	tests := []struct {
+		name         string
+		x, y         int
+		query        string
+		back         bool
+		wantX, wantY int
+	}{
+		{"at the cursor", 0, 0, "foo", false, 0, 0},
+		{"later on the row", 1, 0, "foo", false, 8, 0},
+		{"on a later row", 9, 0, "foo", false, 0, 2},
+		{"past the end of the row", 20, 0, "foo", false, 0, 2},
+		{"before the start of the row", -5, 0, "bar", false, 4, 0},
+		{"multi-line", 0, 0, "foo\nbaz", false, 8, 0},
+		// Find and FindBack don't wrap around the ends of the buffer,
+		// leaving that to their callers
+		{"no wraparound at the end", 1, 2, "foo", false, -1, -1},
+		{"no wraparound at the start", 2, 0, "baz", true, -1, -1},
+		{"back at the cursor", 8, 0, "foo", true, 8, 0},
+		{"back on row 0", 7, 0, "foo", true, 0, 0},
+		{"back on row 0 before any match", 0, 0, "bar", true, -1, -1},
+		{"back from an earlier row", 2, 2, "bar", true, 4, 0},
+		{"back past the end of the row", 20, 1, "baz", true, 0, 1},
+		{"back multi-line", 0, 2, "foo\nbaz", true, 8, 0},
+		{"row out of range", 0, 3, "foo", false, -1, -1},
+		{"negative row", 0, -1, "foo", false, -1, -1},
+		{"back row out of range", 0, 3, "foo", true, -1, -1},
+		{"empty query", 0, 0, "", false, -1, -1},
+		{"no match", 0, 0, "qux", false, -1, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			find := e.Find
+			if tt.back {
+				find = e.FindBack
+			}
+
+			if x, y := find(tt.x, tt.y, []rune(tt.query)); x != tt.wantX || y != tt.wantY {
+				t.Errorf("got %d, %d, want %d, %d", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestLastSearch(t *testing.T) {
+	e := newTestEditor(t, "foo bar", "bar")
+
+	if q := e.LastSearch(); q != nil {
+		t.Errorf("LastSearch() = %q before searching", string(q))
+	}
+
+	pressKeys(t, e, "<C-f>bar<CR>")
+	if q := e.LastSearch(); string(q) != "bar" {
+		t.Errorf("LastSearch() = %q, want %q", string(q), "bar")
+	}
+	if e.X() != 4 || e.Y() != 0 {
+		t.Errorf("cursor at %d, %d, want 4, 0", e.X(), e.Y())
+	}
+}
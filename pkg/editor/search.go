@@ -0,0 +1,372 @@
+package editor
+
+// Searches can span several lines: a query containing '\n' matches the end
+// of a row, any whole rows in between and the start of a later row. In the
+// search prompt "\n" is typed for a line break and "\\" for a backslash.
+
+// SearchState is the last search, which n repeats in the same direction and
+// N in the other.
+type SearchState struct {
+	Query []rune
+	// Search towards the start of the file
+	Backward bool
+	// Only match whole words, as "*" searches for
+	WholeWord bool
+}
+
+// Search returns the last search.
+func (e *Editor) Search() SearchState {
+	return e.search
+}
+
+// SetSearch sets the search that n and N repeat.
+func (e *Editor) SetSearch(s SearchState) {
+	e.search = s
+}
+
+// parseSearch turns the text typed in the search prompt into a query.
+func parseSearch(text []rune) []rune {
+	query := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch text[i+1] {
+			case 'n':
+				query = append(query, '\n')
+				i++
+				continue
+			case '\\':
+				query = append(query, '\\')
+				i++
+				continue
+			}
+		}
+
+		query = append(query, text[i])
+	}
+
+	return query
+}
+
+// splitQuery splits a query into the parts matched on each row.
+func splitQuery(query []rune) [][]rune {
+	var lines [][]rune
+	start := 0
+	for i, r := range query {
+		if r == '\n' {
+			lines = append(lines, query[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(lines, query[start:])
+}
+
+// matchLines returns where in row y a match of the query split into lines,
+// more than one, starts, or -1 if none does.
+func (e *Editor) matchLines(y int, lines [][]rune) int {
+	last := len(lines) - 1
+	if y+last >= len(e.rows) {
+		return -1
+	}
+
+	row := e.rows[y].chars
+	x := len(row) - len(lines[0])
+	if x < 0 || !runesEqual(row[x:], lines[0]) {
+		return -1
+	}
+
+	for i := 1; i < last; i++ {
+		if !runesEqual(e.rows[y+i].chars, lines[i]) {
+			return -1
+		}
+	}
+
+	end := e.rows[y+last].chars
+	if len(end) < len(lines[last]) || !runesEqual(end[:len(lines[last])], lines[last]) {
+		return -1
+	}
+
+	return x
+}
+
+// findLines finds the first match of a multi-line query at or after x1 on
+// row y1.
+func (e *Editor) findLines(x1, y1 int, lines [][]rune) (x, y int) {
+	for y = y1; y < len(e.rows); y++ {
+		if x = e.matchLines(y, lines); x != -1 && (y > y1 || x >= x1) {
+			return x, y
+		}
+	}
+
+	return -1, -1
+}
+
+// findLinesBack finds the last match of a multi-line query at or before x1
+// on row y1.
+func (e *Editor) findLinesBack(x1, y1 int, lines [][]rune) (x, y int) {
+	for y = y1; y >= 0; y-- {
+		if x = e.matchLines(y, lines); x != -1 && (y < y1 || x <= x1) {
+			return x, y
+		}
+	}
+
+	return -1, -1
+}
+
+// searcher finds a query in rows with the Boyer-Moore-Horspool algorithm,
+// which skips ahead by up to the length of the query after a mismatch.
+type searcher struct {
+	query []rune
+	// How far to move a window ending, or when searching backwards
+	// starting, with a given rune. Runes not in the table move it by the
+	// length of the query.
+	skip, skipBack map[rune]int
+}
+
+func newSearcher(query []rune) *searcher {
+	s := &searcher{
+		query:    query,
+		skip:     make(map[rune]int, len(query)),
+		skipBack: make(map[rune]int, len(query)),
+	}
+
+	m := len(query)
+	for j := 0; j < m-1; j++ {
+		s.skip[query[j]] = m - 1 - j
+	}
+	for j := m - 1; j > 0; j-- {
+		s.skipBack[query[j]] = j
+	}
+
+	return s
+}
+
+// index returns the index of the first match in text, or -1.
+func (s *searcher) index(text []rune) int {
+	m := len(s.query)
+	for i := 0; i <= len(text)-m; {
+		if runesEqual(text[i:i+m], s.query) {
+			return i
+		}
+
+		i += s.shift(s.skip, text[i+m-1])
+	}
+
+	return -1
+}
+
+// lastIndex returns the index of the last match in text starting at or
+// before offset, or -1.
+func (s *searcher) lastIndex(text []rune, offset int) int {
+	m := len(s.query)
+	if offset > len(text)-m {
+		offset = len(text) - m
+	}
+
+	for i := offset; i >= 0; {
+		if runesEqual(text[i:i+m], s.query) {
+			return i
+		}
+
+		i -= s.shift(s.skipBack, text[i])
+	}
+
+	return -1
+}
+
+func (s *searcher) shift(table map[rune]int, r rune) int {
+	if n, ok := table[r]; ok {
+		return n
+	}
+
+	return len(s.query)
+}
+
+// MatchEnd returns the position just after a match of query starting at x,
+// y, which is on a later row when the query spans several lines.
+func (e *Editor) MatchEnd(x, y int, query []rune) (x2, y2 int) {
+	lines := splitQuery(query)
+	if len(lines) == 1 {
+		return x + len(query), y
+	}
+
+	return len(lines[len(lines)-1]), y + len(lines) - 1
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// highlightedSearch returns the search whose matches are highlighted: the
+// query being typed in the search prompt, or the last search with hlsearch.
+func (e *Editor) highlightedSearch() (SearchState, bool) {
+	if len(e.incsearch) > 0 {
+		return SearchState{Query: e.incsearch}, true
+	}
+
+	if e.cfg.HLSearch && len(e.search.Query) > 0 {
+		return e.search, true
+	}
+
+	return SearchState{}, false
+}
+
+// searchHighlight returns a copy of hl, the highlighting of row y, with the
+// parts of the matches of s on the row highlighted. The matches are found
+// when the row is drawn, so the syntax highlighting is never changed.
+func (e *Editor) searchHighlight(y int, s SearchState, hl []SyntaxHL) []SyntaxHL {
+	row := e.rows[y]
+	hl = append([]SyntaxHL(nil), hl...)
+
+	mark := func(x1, x2 int) {
+		start, end := e.renderIndex(row, x1), len(hl)
+		if x2 < len(row.chars) {
+			end = e.renderIndex(row, x2)
+		}
+		for i := start; i < end && i < len(hl); i++ {
+			hl[i] = hlMatch
+		}
+	}
+
+	lines := splitQuery(s.Query)
+	if len(lines) == 1 {
+		q := newSearcher(s.Query)
+		for x := 0; x <= len(row.chars); {
+			i := q.index(row.chars[x:])
+			if i == -1 {
+				break
+			}
+
+			if x += i; !s.WholeWord || isWholeWord(row.chars, x, len(s.Query)) {
+				mark(x, x+len(s.Query))
+			}
+			x++
+		}
+
+		return hl
+	}
+
+	// Row y is line i of a match starting i rows above
+	last := len(lines) - 1
+	for i := 0; i <= last && i <= y; i++ {
+		x := e.matchLines(y-i, lines)
+		switch {
+		case x == -1:
+		case i == 0:
+			mark(x, len(row.chars))
+		case i == last:
+			mark(0, len(lines[last]))
+		default:
+			mark(0, len(row.chars))
+		}
+	}
+
+	return hl
+}
+
+// isWholeWord reports whether the n runes at x in row are a word of their
+// own, without word characters just before or after them.
+func isWholeWord(row []rune, x, n int) bool {
+	return (x == 0 || charClass(row[x-1]) != 1) && (x+n >= len(row) || charClass(row[x+n]) != 1)
+}
+
+// findSearch finds the next match of a search from x, y, or the previous one
+// when backward, skipping the matches that aren't whole words if the search
+// asks for them.
+func findSearch(e SDK, s SearchState, x, y int, backward bool) (int, int) {
+	for {
+		if backward {
+			x, y = e.FindBack(x, y, s.Query)
+		} else {
+			x, y = e.Find(x, y, s.Query)
+		}
+
+		if x == -1 || !s.WholeWord || isWholeWord(e.Row(y), x, len(s.Query)) {
+			return x, y
+		}
+
+		if backward {
+			x--
+		} else {
+			x++
+		}
+	}
+}
+
+// repeatSearch returns an action moving to the next match of the last search
+// in its direction, or in the other one with reverse.
+func repeatSearch(reverse bool) func(e SDK) error {
+	return func(e SDK) error {
+		s := e.Search()
+		if len(s.Query) == 0 {
+			e.SetMessage("There is no last search")
+			return nil
+		}
+
+		// Start next to the cursor, so that a match under it is
+		// skipped
+		x, y := e.X(), e.Y()
+		backward := s.Backward != reverse
+		if backward {
+			if x--; x < 0 {
+				if y == 0 {
+					e.Bell()
+					return nil
+				}
+
+				y--
+				x = len(e.Row(y))
+			}
+		} else {
+			if x++; x > len(e.Row(y)) {
+				if y == e.NumRows()-1 {
+					e.Bell()
+					return nil
+				}
+
+				x = 0
+				y++
+			}
+		}
+
+		x, y = findSearch(e, s, x, y, backward)
+		if x == -1 {
+			e.Bell()
+			return nil
+		}
+
+		e.SetY(y)
+		e.SetX(x)
+		return nil
+	}
+}
+
+// searchWord returns an action searching for the word under the cursor as a
+// whole word, forward or backward.
+func searchWord(backward bool) func(e SDK) error {
+	return func(e SDK) error {
+		r, ok := wordObject(false)(e)
+		if !ok || charClass(e.Row(r.start.y)[r.start.x]) != 1 {
+			e.Bell()
+			return nil
+		}
+
+		query := copyRunes(e.Row(r.start.y)[r.start.x:r.end.x])
+		e.SetSearch(SearchState{Query: query, Backward: backward, WholeWord: true})
+		e.SetMessage("Search: %s", string(query))
+
+		// Search from the start of the word, for "#" not to find it
+		e.SetX(r.start.x)
+		return repeatSearch(false)(e)
+	}
+}
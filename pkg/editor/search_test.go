@@ -0,0 +1,217 @@
+package editor
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// naiveIndex returns the rune index of the first match of query in text, or
+// -1, using strings.Index.
+func naiveIndex(text, query string) int {
+	i := strings.Index(text, query)
+	if i == -1 {
+		return -1
+	}
+	return utf8.RuneCountInString(text[:i])
+}
+
+// naiveLastIndex returns the rune index of the last match of query in text
+// starting at or before offset, or -1, using strings.LastIndex.
+func naiveLastIndex(text, query string, offset int) int {
+	runes := []rune(text)
+	end := offset + utf8.RuneCountInString(query)
+	if end > len(runes) {
+		end = len(runes)
+	}
+	if end < 0 {
+		return -1
+	}
+
+	prefix := string(runes[:end])
+	i := strings.LastIndex(prefix, query)
+	if i == -1 {
+		return -1
+	}
+	return utf8.RuneCountInString(prefix[:i])
+}
+
+var searchTests = []struct {
+	text, query string
+}{
+	{"hello world", "world"},
+	{"hello world", "o"},
+	{"hello world", "hello world"},
+	{"hello world", "hello world!"},
+	{"hello world", "xyz"},
+	{"", "a"},
+	{"aaaaaaaaab", "aab"},
+	{"abababab", "abab"},
+	{"héllo wörld", "wör"},
+	{"héllo wörld", "ö"},
+	{"日本語のテキスト日本語", "日本語"},
+	{"日本語のテキスト", "キスト"},
+	{"emoji 👍🏽 and 👍 again", "👍 "},
+	{"emoji 👍🏽 and 👍 again", "🏽"},
+	{"ΣΊΣΥΦΟΣ", "ΣΥ"},
+	{"straße STRASSE", "ße"},
+	// The last rune of the query appears earlier in it, which the skip
+	// table has to account for
+	{"xxabcbxxabcb", "bcb"},
+}
+
+func TestSearcherMatchesNaive(t *testing.T) {
+	fold := func(s string) string { return strings.ToLower(s) }
+
+	for _, tt := range searchTests {
+		for _, f := range []func(string) string{func(s string) string { return s }, fold} {
+			text, query := f(tt.text), f(tt.query)
+			s := newSearcher([]rune(query))
+
+			if got, want := s.index([]rune(text)), naiveIndex(text, query); got != want {
+				t.Errorf("index(%q, %q) = %d, want %d", text, query, got, want)
+			}
+
+			n := utf8.RuneCountInString(text)
+			for offset := 0; offset <= n; offset++ {
+				got := s.lastIndex([]rune(text), offset)
+				if want := naiveLastIndex(text, query, offset); got != want {
+					t.Errorf("lastIndex(%q, %q, %d) = %d, want %d", text, query, offset, got, want)
+				}
+			}
+		}
+	}
+}
+
+// Random text over a small alphabet has many partial matches, which is where
+// the skips of the search can go wrong.
+func TestSearcherRandom(t *testing.T) {
+	alphabet := []rune("abé日👍")
+	random := func(r *rand.Rand, n int) string {
+		runes := make([]rune, n)
+		for i := range runes {
+			runes[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(runes)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		text, query := random(r, r.Intn(40)), random(r, 1+r.Intn(4))
+		s := newSearcher([]rune(query))
+
+		if got, want := s.index([]rune(text)), naiveIndex(text, query); got != want {
+			t.Fatalf("index(%q, %q) = %d, want %d", text, query, got, want)
+		}
+
+		offset := r.Intn(utf8.RuneCountInString(text) + 1)
+		if got, want := s.lastIndex([]rune(text), offset), naiveLastIndex(text, query, offset); got != want {
+			t.Fatalf("lastIndex(%q, %q, %d) = %d, want %d", text, query, offset, got, want)
+		}
+	}
+}
+
+func TestFindMatchesNaive(t *testing.T) {
+	lines := []string{"first line", "ünïcödé ünïcödé", "", "日本語 line", "last line"}
+	e := newTestEditor(t, lines...)
+
+	for _, query := range []string{"line", "ünï", "cödé", "日本", "e", "missing"} {
+		for y := range lines {
+			for x := 0; x <= utf8.RuneCountInString(lines[y]); x++ {
+				// The first match at or after x, y
+				wantX, wantY := -1, -1
+				for y2 := y; y2 < len(lines); y2++ {
+					text := lines[y2]
+					skip := 0
+					if y2 == y {
+						skip = x
+						text = string([]rune(text)[x:])
+					}
+					if i := naiveIndex(text, query); i != -1 {
+						wantX, wantY = skip+i, y2
+						break
+					}
+				}
+
+				if gotX, gotY := e.Find(x, y, []rune(query)); gotX != wantX || gotY != wantY {
+					t.Errorf("Find(%d, %d, %q) = %d, %d, want %d, %d", x, y, query, gotX, gotY, wantX, wantY)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	lines := make([]string, 100000)
+	for i := range lines {
+		lines[i] = strings.Repeat("the quick brown fox jumps over the lazy dog ", 2)
+	}
+	lines[len(lines)-1] += "needle"
+
+	e := &Editor{}
+	for _, line := range lines {
+		e.rows = append(e.rows, &Row{chars: []rune(line)})
+	}
+	query := []rune("needle")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if x, _ := e.Find(0, 0, query); x == -1 {
+			b.Fatal("needle not found")
+		}
+	}
+}
+
+// hasMatchHighlight reports whether row y is drawn or syntax highlighted
+// with hlMatch anywhere.
+func hasMatchHighlight(e *Editor, y int) bool {
+	for _, hl := range [][]SyntaxHL{e.rows[y].hl, e.rowHighlight(y)} {
+		for _, h := range hl {
+			if h == hlMatch {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Typing a search moves the cursor from match to match over several rows;
+// finishing it must leave no row highlighted as a match.
+func TestSearchLeavesNoHighlight(t *testing.T) {
+	tests := []struct {
+		name string
+		// keys finishing the search
+		end string
+	}{
+		{"cancelled", "<Esc>"},
+		{"cancelled with Ctrl-Q", "<C-q>"},
+		{"accepted", "<CR>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, "foo", "bar foo", "baz", "bar")
+
+			// "ba" matches on row 1, "baz" on row 2, and "ba"
+			// again on row 2 after a backspace
+			for _, keys := range []string{"<C-f>ba", "z", "<BS>"} {
+				pressKeys(t, e, keys)
+				e.Render()
+
+				if !hasMatchHighlight(e, e.cy) {
+					t.Errorf("after %s, the match on row %d isn't highlighted", keys, e.cy)
+				}
+			}
+
+			pressKeys(t, e, tt.end)
+			e.Render()
+
+			for y := range e.rows {
+				if hasMatchHighlight(e, y) {
+					t.Errorf("row %d is still highlighted as a match", y)
+				}
+			}
+		})
+	}
+}
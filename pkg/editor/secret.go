@@ -0,0 +1,76 @@
+package editor
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Secret files, like .env files and private keys, are edited without
+// anything about them being written anywhere but to the file itself: no
+// session or recovery files, no crash snapshot, no remembered options, and
+// the keys typed aren't logged. Unsaved changes to a secret file are lost if
+// the editor crashes. Files whose name matches the secretfiles option are
+// secret, every file is with "jk --secret", and ":secret" makes the current
+// one secret until another file is opened.
+
+// matchesSecret reports whether filename matches one of the comma-separated
+// patterns, e.g. "*.pem". Patterns without a '/' are matched against the
+// base name of the file, the others against its absolute path.
+func matchesSecret(patterns, filename string) bool {
+	if len(filename) == 0 {
+		return false
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		abs = filename
+	}
+
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+
+		name := filepath.Base(filename)
+		if strings.ContainsRune(pattern, '/') {
+			name = abs
+		}
+
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSecret reports whether the file being edited is secret.
+func (e *Editor) isSecret() bool {
+	return e.secret.all || e.secret.file
+}
+
+// detectSecret makes the file being edited secret if it is encrypted or its
+// name matches the secretfiles option.
+func (e *Editor) detectSecret() {
+	e.secret.file = isEncrypted(e.filename) || matchesSecret(e.cfg.SecretFiles, e.filename)
+}
+
+// logsKeys reports whether the keys typed can be logged: not while editing
+// a secret file, as they could be the secret, nor into a password prompt.
+func (e *Editor) logsKeys() bool {
+	return !e.isSecret() && (e.prompt == nil || !e.prompt.hidden)
+}
+
+// setSecret makes the current file secret until another one is opened.
+func (e *Editor) setSecret() {
+	e.secret.file = true
+}
+
+// secretCommand makes the current file secret until another one is opened:
+// "secret".
+func secretCommand(e SDK, r *Range, args string) error {
+	e.setSecret()
+	e.SetMessage("%s is secret: nothing about it is kept outside the file", e.Filename())
+	return nil
+}
@@ -0,0 +1,60 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeysLogged(t *testing.T) {
+	tests := []struct {
+		name   string
+		open   func(e *Editor)
+		logged bool
+	}{
+		{
+			name:   "prompt",
+			open:   func(e *Editor) { e.StaticPrompt("> ", func(string) error { return nil }, nil) },
+			logged: true,
+		},
+		{
+			name: "password prompt",
+			open: func(e *Editor) { e.passwordPrompt("Password: ", func(string) error { return nil }) },
+		},
+		{
+			name: "secret file",
+			open: func(e *Editor) {
+				e.setSecret()
+				e.StaticPrompt("> ", func(string) error { return nil }, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t)
+
+			path := filepath.Join(t.TempDir(), "jk.log")
+			closeLog, err := StartLogging("debug", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer StartLogging("", "")
+			defer closeLog()
+
+			tt.open(e)
+			if err := e.ProcessKeys([]Key{'q', 'z', 'x', keyEnter}); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if logged := strings.Contains(string(out), "received key: z"); logged != tt.logged {
+				t.Errorf("keys logged: %v, want %v\n%s", logged, tt.logged, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// textStats are the counts shown by the count command. Every line counts
+// as ending with a newline, as it does once saved.
+type textStats struct {
+	lines, words, chars, bytes int
+}
+
+// countText returns the statistics of the rows in r.
+func countText(e SDK, r Range) textStats {
+	var s textStats
+	for y := r.Start; y <= r.End; y++ {
+		row := e.Row(y)
+		s.lines++
+		s.words += len(strings.Fields(string(row)))
+		s.chars += len(row) + 1
+		s.bytes += len(string(row)) + 1
+	}
+
+	return s
+}
+
+// countCommand shows the number of lines, words, characters and bytes in
+// the range, or the whole file.
+func countCommand(e SDK, r *Range, args string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("count takes no arguments")
+	}
+
+	rng := r.orAll(e)
+	s := countText(e, rng)
+
+	what := "file"
+	if r != nil {
+		what = fmt.Sprintf("lines %d-%d", rng.Start+1, rng.End+1)
+	}
+
+	e.SetMessage("%s: %d lines, %d words, %d characters, %d bytes",
+		what, s.lines, s.words, s.chars, s.bytes)
+	return nil
+}
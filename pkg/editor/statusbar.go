@@ -0,0 +1,134 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statusSegments are the values that can be referenced from the status bar
+// templates as "{name}".
+var statusSegments = map[string]func(e *Editor) string{
+	"filename": func(e *Editor) string {
+		return fmt.Sprintf("%.20s", e.bufferName())
+	},
+	"modified": func(e *Editor) string {
+		if e.modified {
+			return "(modified)"
+		}
+
+		return ""
+	},
+	"filetype": func(e *Editor) string {
+		if e.syntax == nil {
+			return "no filetype"
+		}
+
+		return e.syntax.filetype
+	},
+	"git": func(e *Editor) string {
+		return e.git.String(e.filename)
+	},
+	"indent": func(e *Editor) string {
+		return e.indentStyle()
+	},
+	"mode": func(e *Editor) string {
+		switch e.Mode {
+		case InsertMode:
+			return "-- INSERT --"
+		case ReplaceMode:
+			return "-- REPLACE --"
+		case CommandMode:
+			return "-- COMMAND --"
+		case PromptMode:
+			return "-- PROMPT --"
+		case OperatorPendingMode:
+			return "-- OPERATOR --"
+		}
+
+		return ""
+	},
+	"line": func(e *Editor) string {
+		return strconv.Itoa(e.cy + 1)
+	},
+	"col": func(e *Editor) string {
+		return strconv.Itoa(e.cx + 1)
+	},
+	"lines": func(e *Editor) string {
+		return strconv.Itoa(len(e.rows))
+	},
+	"percent": func(e *Editor) string {
+		if len(e.rows) == 0 {
+			return "0%"
+		}
+
+		return fmt.Sprintf("%d%%", (e.cy+1)*100/len(e.rows))
+	},
+	"secret": func(e *Editor) string {
+		if e.isSecret() {
+			return "[secret]"
+		}
+
+		return ""
+	},
+	"cwd": func(e *Editor) string {
+		return workDir()
+	},
+	"clock": func(e *Editor) string {
+		return time.Now().Format("15:04")
+	},
+}
+
+// expandStatus replaces each "{name}" in the template with the value of the
+// status segment of the same name. Unknown names are left untouched.
+func (e *Editor) expandStatus(template string) string {
+	var b strings.Builder
+
+	for {
+		i := strings.IndexByte(template, '{')
+		if i == -1 {
+			break
+		}
+
+		j := strings.IndexByte(template[i:], '}')
+		if j == -1 {
+			break
+		}
+
+		b.WriteString(template[:i])
+		if segment, ok := statusSegments[template[i+1:i+j]]; ok {
+			b.WriteString(segment(e))
+		} else {
+			b.WriteString(template[i : i+j+1])
+		}
+
+		template = template[i+j+1:]
+	}
+
+	b.WriteString(template)
+	return b.String()
+}
+
+func (e *Editor) drawStatusBar(b io.Writer) {
+	setStyle(b, e.color(hlStatusBar))
+	defer clearFormatting(b)
+
+	lmsg := e.expandStatus(e.cfg.StatusLeft)
+	if e.width.StringWidth(lmsg) > e.screenCols {
+		lmsg = e.width.Truncate(lmsg, e.screenCols, "...")
+	}
+	b.Write([]byte(lmsg))
+
+	// Add padding between the left and right message
+	l := e.width.StringWidth(lmsg)
+	rmsg := e.width.Truncate(e.expandStatus(e.cfg.StatusRight), e.screenCols-l, "")
+	r := e.width.StringWidth(rmsg)
+	for i := 0; i < e.screenCols-l-r; i++ {
+		b.Write([]byte{' '})
+	}
+
+	b.Write([]byte(rmsg))
+	b.Write([]byte("\r\n"))
+}
@@ -0,0 +1,125 @@
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sudoSave writes the file as root through "sudo tee", for files the user
+// can't write to. It asks for confirmation first, and for the password if
+// sudo needs one.
+func (e *Editor) sudoSave() error {
+	if len(e.filename) == 0 {
+		return errors.New("no file name")
+	}
+	if isEncrypted(e.filename) {
+		return errors.New("encrypted files can't be saved with sudo")
+	}
+
+	e.Prompt(fmt.Sprintf("Save %s as root with sudo? (y/n) ", e.filename), func(k Key) (string, bool) {
+		if k != 'y' && k != 'Y' {
+			e.SetMessage("")
+			return "", true
+		}
+
+		// No password is needed if sudo remembers it
+		if exec.Command("sudo", "-n", "true").Run() == nil {
+			if err := e.sudoWrite(); err != nil {
+				e.ErrChan() <- err
+			}
+			return "", true
+		}
+
+		e.passwordPrompt("[sudo] password: ", func(password string) error {
+			cmd := exec.Command("sudo", "-S", "-v", "-p", "")
+			cmd.Stdin = strings.NewReader(password + "\n")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("sudo: %s", firstLine(out, err))
+			}
+
+			return e.sudoWrite()
+		})
+		return "", true
+	})
+
+	return nil
+}
+
+// sudoWrite writes the text to the file with "sudo tee", which must be
+// able to run without asking for a password.
+func (e *Editor) sudoWrite() error {
+	if err := e.fire(EventBufWritePre); err != nil {
+		return err
+	}
+
+	if e.cfg.TrimWhitespace {
+		e.trimTrailingWhitespace()
+	}
+
+	var text bytes.Buffer
+	for _, row := range e.rows {
+		text.WriteString(string(row.chars))
+		text.WriteByte('\n')
+	}
+
+	cmd := exec.Command("sudo", "-n", "tee", "--", e.filename)
+	cmd.Stdin = &text
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sudo tee: %s", firstLine(stderr.Bytes(), err))
+	}
+
+	e.markSaved()
+	e.SetMessage("saved file with sudo: %s", e.filename)
+	e.notify(EventBufWritePost)
+	return nil
+}
+
+// passwordPrompt asks for input without showing it, and passes it to end.
+func (e *Editor) passwordPrompt(prompt string, end func(string) error) {
+	var input []rune
+
+	e.Prompt(prompt, func(k Key) (string, bool) {
+		switch k {
+		case keyEnter, keyCarriageReturn:
+			e.SetMessage("")
+			if err := end(string(input)); err != nil {
+				e.ErrChan() <- err
+			}
+			return "", true
+		case keyEscape, Key(ctrl('q')):
+			e.SetMessage("")
+			return "", true
+		case keyBackspace, keyDelete:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+			}
+		default:
+			if isPrintable(k) {
+				input = append(input, rune(k))
+			}
+		}
+
+		return strings.Repeat("*", len(input)), false
+	})
+	e.prompt.hidden = true
+}
+
+// firstLine returns the first line of a command's output, or err if there
+// wasn't any.
+func firstLine(out []byte, err error) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if len(line) == 0 {
+		return err.Error()
+	}
+
+	return line
+}
+
+func sudoSaveCommand(e SDK, r *Range, args string) error {
+	return e.sudoSave()
+}
@@ -0,0 +1,129 @@
+package editor
+
+type SyntaxHL uint8
+
+// Syntax highlight enums
+const (
+	hlNormal SyntaxHL = iota + 1
+	hlComment
+	hlMlComment
+	hlKeyword1
+	hlKeyword2
+	hlString
+	hlNumber
+	hlMatch
+	// TODO, FIXME and the like within comments
+	hlTodo
+	// URLs within comments and markdown text
+	hlURL
+	// The bracket under the cursor and the one matching it
+	hlMatchParen
+	// Signs for lines that differ from the git index
+	hlDiffAdd
+	hlDiffChange
+	hlDiffDelete
+	// The lines of merge conflicts
+	hlConflictMarker
+	hlConflictOurs
+	hlConflictBase
+	hlConflictTheirs
+	// The part of a changed line that changed, in the side by side diff
+	hlDiffText
+	// Every other column of delimited files like CSV
+	hlColumn
+	// The line where a command found an error in the text
+	hlError
+	// The candidates of prompt completion
+	hlMenu
+
+	// Parts of the interface, which are colored by the colorscheme along
+	// with the syntax
+	hlStatusBar
+	hlLineNumber
+	hlSelection
+	hlScrollbar
+)
+
+// Colorscheme maps each kind of text to the SGR parameters it is drawn with,
+// e.g. "94" or "1;38;5;208".
+type Colorscheme map[SyntaxHL]string
+
+// defaultColorscheme is for terminals with a dark background, and
+// defaultLightColorscheme for ones with a light background.
+var defaultColorscheme = Colorscheme{
+	hlComment:        "90",
+	hlMlComment:      "90",
+	hlKeyword1:       "94",
+	hlKeyword2:       "96",
+	hlString:         "36",
+	hlNumber:         "33",
+	hlMatch:          "32",
+	hlTodo:           "30;43",
+	hlURL:            "4;94",
+	hlMatchParen:     "30;46",
+	hlDiffAdd:        "32",
+	hlDiffChange:     "33",
+	hlDiffDelete:     "31",
+	hlConflictMarker: "1;31",
+	hlConflictOurs:   "32",
+	hlConflictBase:   "90",
+	hlConflictTheirs: "34",
+	hlDiffText:       "1;7;33",
+	hlColumn:         "36",
+	hlError:          "97;41",
+	hlMenu:           "30;47",
+	hlNormal:         "39",
+	hlStatusBar:      "7",
+	hlLineNumber:     "90",
+	hlSelection:      "7",
+	hlScrollbar:      "100",
+}
+
+var defaultLightColorscheme = Colorscheme{
+	hlComment:        "90",
+	hlMlComment:      "90",
+	hlKeyword1:       "34",
+	hlKeyword2:       "36",
+	hlString:         "31",
+	hlNumber:         "35",
+	hlMatch:          "32",
+	hlTodo:           "30;43",
+	hlURL:            "4;34",
+	hlMatchParen:     "30;46",
+	hlDiffAdd:        "32",
+	hlDiffChange:     "33",
+	hlDiffDelete:     "31",
+	hlConflictMarker: "1;31",
+	hlConflictOurs:   "32",
+	hlConflictBase:   "90",
+	hlConflictTheirs: "34",
+	hlDiffText:       "1;7;33",
+	hlColumn:         "34",
+	hlError:          "97;41",
+	hlMenu:           "30;47",
+	hlNormal:         "39",
+	hlStatusBar:      "7",
+	hlLineNumber:     "90",
+	hlSelection:      "7",
+	hlScrollbar:      "47",
+}
+
+// color returns the SGR parameters that text of the highlight group is
+// drawn with.
+func (e *Editor) color(hl SyntaxHL) string {
+	if hl >= hlUser && int(hl-hlUser) < len(e.highlightRules) {
+		rule := e.highlightRules[hl-hlUser]
+		if rule.group != 0 {
+			return e.color(rule.group)
+		}
+
+		return rule.color
+	}
+
+	color, ok := e.colorscheme[hl]
+	if !ok {
+		return "37"
+	}
+
+	return color
+}
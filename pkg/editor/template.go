@@ -0,0 +1,214 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// ":date" inserts the date and time in the dateformat option's format, or in
+// its own, using strftime's "%Y-%m-%d" notation. Templates are files in the
+// templates directory next to the config file, named after a filetype. A new
+// file of that filetype starts with the text of its template, and
+// ":template <name>" inserts one. Templates can use the placeholders in
+// templateFields, and {{cursor}} where the cursor goes.
+
+// strftimeVerbs are the Go layouts of the strftime conversions.
+var strftimeVerbs = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'p': "PM",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'Z': "MST",
+	'z': "-0700",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+}
+
+// strftime formats t like the C function does, with the conversions in
+// strftimeVerbs, "%j" for the day of the year, "%s" for Unix time and "%%"
+// for a percent sign. Unknown conversions are left as they are.
+func strftime(format string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		switch c := format[i]; c {
+		case '%':
+			b.WriteByte('%')
+		case 'j':
+			b.WriteString(t.Format("002"))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		default:
+			if layout, ok := strftimeVerbs[c]; ok {
+				b.WriteString(t.Format(layout))
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(c)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// insertBeforeCursor inserts text before the cursor, leaving the cursor on
+// its last character.
+func insertBeforeCursor(e SDK, text string) {
+	e.InsertChars(e.Y(), e.X(), []rune(text)...)
+	e.SetX(e.X() + utf8.RuneCountInString(text) - 1)
+}
+
+// dateCommand inserts the date and time before the cursor, in the
+// dateformat option's format by default: "date [format]".
+func dateCommand(e SDK, r *Range, args string) error {
+	format := args
+	if len(format) == 0 {
+		format = e.Options().DateFormat
+	}
+
+	insertBeforeCursor(e, strftime(format, time.Now()))
+	return nil
+}
+
+// insertDate types the date and time in the dateformat option's format.
+func insertDate(e SDK) error {
+	e.InsertText(strftime(e.Options().DateFormat, time.Now()))
+	return nil
+}
+
+// templateDir returns the directory the templates are in.
+func templateDir() string {
+	path := configPath()
+	if len(path) == 0 {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(path), "templates")
+}
+
+// cursorPlaceholder marks where the cursor goes in a template.
+const cursorPlaceholder = "{{cursor}}"
+
+// templateFields returns the values of the placeholders of the templates,
+// e.g. "{{name}}", for the file being edited.
+func (e *Editor) templateFields() map[string]string {
+	abs, _ := filepath.Abs(e.filename)
+	base := filepath.Base(e.filename)
+	now := time.Now()
+
+	return map[string]string{
+		// The date in the dateformat option's format
+		"date": strftime(e.cfg.DateFormat, now),
+		"year": strconv.Itoa(now.Year()),
+		// The file name, with and without its extension
+		"file": base,
+		"name": strings.TrimSuffix(base, filepath.Ext(base)),
+		// The name of the file's directory, e.g. the Go package
+		"dir": filepath.Base(filepath.Dir(abs)),
+	}
+}
+
+// insertTemplate inserts the template with the given name above the cursor
+// row, moving the cursor to its {{cursor}} placeholder if it has one.
+func (e *Editor) insertTemplate(name string) error {
+	dir := templateDir()
+	if len(dir) == 0 || len(name) == 0 || strings.ContainsRune(name, filepath.Separator) {
+		return errors.Errorf("no template: %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.Errorf("no template: %s", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	text := strings.TrimSuffix(string(data), "\n")
+	for field, value := range e.templateFields() {
+		text = strings.ReplaceAll(text, "{{"+field+"}}", value)
+	}
+
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	at := e.cy
+	cx, cy := -1, -1
+	for i, line := range strings.Split(text, "\n") {
+		if x := strings.Index(line, cursorPlaceholder); x != -1 && cy == -1 {
+			line = line[:x] + line[x+len(cursorPlaceholder):]
+			cx, cy = utf8.RuneCountInString(line[:x]), at+i
+		}
+		e.InsertRow(at+i, []rune(line))
+	}
+
+	if cy != -1 {
+		e.SetY(cy)
+		e.SetX(cx)
+	}
+	return nil
+}
+
+// applyTemplate fills a new file with the template of its filetype, if
+// there is one.
+func (e *Editor) applyTemplate() {
+	dir := templateDir()
+	if e.syntax == nil || len(e.rows) > 0 || len(dir) == 0 {
+		return
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, e.syntax.filetype)); err != nil {
+		return
+	}
+
+	e.cx, e.cy = 0, 0
+	if err := e.insertTemplate(e.syntax.filetype); err != nil {
+		e.SetMessage("template: %s", err)
+	}
+}
+
+// templateCommand inserts a template above the cursor row: "template <name>".
+func templateCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		return errors.New("usage: template <name>")
+	}
+
+	return e.insertTemplate(args)
+}
+
+// templateNames returns the names of the templates.
+func templateNames() []string {
+	entries, err := os.ReadDir(templateDir())
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// Terminal is what the editor reads keys from and draws to. The editor uses
+// the process's own terminal by default, but any implementation can be
+// passed to RunTerminal, e.g. a virtual terminal for tests or for embedding
+// the editor in another program.
+type Terminal interface {
+	// Read reads raw input, blocking until some is available. Reading io.EOF
+	// quits the editor, and any other error stops it with that error. A
+	// Read may be left blocked when the editor returns, see RunTerminal.
+	io.Reader
+	// Write draws escape sequences and text to the screen
+	io.Writer
+
+	// Size returns the number of rows and columns of the screen
+	Size() (rows, cols int, err error)
+
+	// MakeRaw puts the terminal into raw mode, and Restore undoes it
+	MakeRaw() error
+	Restore() error
+}
+
+// inputWaiter is implemented by terminals that can wait for input with a
+// timeout. The editor only asks such terminals questions that might never be
+// answered, like their background color.
+type inputWaiter interface {
+	// waitInput reports whether there is input to read within timeout
+	waitInput(timeout time.Duration) bool
+}
+
+// stdTerminal is the terminal the process is running in.
+type stdTerminal struct {
+	in, out  *os.File
+	oldState *term.State
+}
+
+// StdTerminal returns the terminal connected to stdin and stdout.
+func StdTerminal() Terminal {
+	return &stdTerminal{in: os.Stdin, out: os.Stdout}
+}
+
+func (t *stdTerminal) Read(p []byte) (int, error) {
+	return t.in.Read(p)
+}
+
+func (t *stdTerminal) Write(p []byte) (int, error) {
+	return t.out.Write(p)
+}
+
+func (t *stdTerminal) Size() (rows, cols int, err error) {
+	cols, rows, err = term.GetSize(int(t.in.Fd()))
+	return rows, cols, err
+}
+
+func (t *stdTerminal) MakeRaw() error {
+	state, err := term.MakeRaw(int(t.in.Fd()))
+	if err != nil {
+		return err
+	}
+
+	t.oldState = state
+	return nil
+}
+
+func (t *stdTerminal) Restore() error {
+	if t.oldState == nil {
+		return nil
+	}
+
+	return term.Restore(int(t.in.Fd()), t.oldState)
+}
+
+func (t *stdTerminal) waitInput(timeout time.Duration) bool {
+	fds := []unix.PollFd{{Fd: int32(t.in.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout.Milliseconds())+1)
+	return err == nil && n > 0
+}
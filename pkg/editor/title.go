@@ -0,0 +1,50 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Terminals that keep a stack of titles save the current one with
+	// pushTitleCode and bring it back with popTitleCode
+	pushTitleCode = "\x1b[22;0t"
+	popTitleCode  = "\x1b[23;0t"
+)
+
+// title returns the window title for the current file, e.g. "jk — main.go [+]".
+// Control characters in the file name are left out, as a BEL or ESC would
+// end the escape sequence setting the title and send the rest to the
+// terminal.
+func (e *Editor) title() string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, e.bufferName())
+
+	if e.modified {
+		return fmt.Sprintf("jk — %s [+]", name)
+	}
+
+	return fmt.Sprintf("jk — %s", name)
+}
+
+// updateTitle sets the title of the terminal window when the title option is
+// on and the title has changed since it was last set.
+func (e *Editor) updateTitle(w io.Writer) {
+	if !e.cfg.Title {
+		return
+	}
+
+	title := e.title()
+	if title == e.lastTitle {
+		return
+	}
+
+	e.lastTitle = title
+	fmt.Fprintf(w, "\x1b]2;%s\x07", title)
+}
@@ -0,0 +1,33 @@
+package editor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"main.go", "\x1b]2;jk — main.go\x07"},
+		{"a\x07b.txt", "\x1b]2;jk — ab.txt\x07"},
+		{"a\x1b]2;evil\x1b\\.txt", "\x1b]2;jk — a]2;evil\\.txt\x07"},
+		{"tab\there\n\u009b", "\x1b]2;jk — tabhere\x07"},
+		{"日本.txt", "\x1b]2;jk — 日本.txt\x07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			e := newTestEditor(t)
+			e.cfg.Title = true
+			e.filename = tt.filename
+
+			var b bytes.Buffer
+			e.updateTitle(&b)
+			if got := b.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
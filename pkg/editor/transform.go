@@ -0,0 +1,129 @@
+package editor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// transform converts text to and from an encoding.
+type transform struct {
+	encode, decode func(string) (string, error)
+}
+
+var transforms = map[string]transform{
+	"base64": {
+		encode: func(s string) (string, error) {
+			return base64.StdEncoding.EncodeToString([]byte(s)), nil
+		},
+		decode: func(s string) (string, error) {
+			// encoded text is often wrapped onto several lines
+			b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+			return string(b), err
+		},
+	},
+	"url": {
+		encode: func(s string) (string, error) { return url.QueryEscape(s), nil },
+		decode: url.QueryUnescape,
+	},
+	"json": {
+		encode: jsonEscape,
+		decode: jsonUnescape,
+	},
+}
+
+// jsonEscape escapes s for use within a JSON string, leaving out the
+// quotes around it.
+func jsonEscape(s string) (string, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return "", err
+	}
+
+	quoted := strings.TrimSuffix(b.String(), "\n")
+	return quoted[1 : len(quoted)-1], nil
+}
+
+// jsonUnescape undoes jsonEscape. The quotes around the string are
+// optional.
+func jsonUnescape(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		s = `"` + s + `"`
+	}
+
+	var res string
+	err := json.Unmarshal([]byte(s), &res)
+	return res, err
+}
+
+func transformNames() string {
+	var names []string
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, "|")
+}
+
+// transformRows runs the lines of the range, or the current line, through
+// the encoding or decoding of the transform named by args.
+func transformRows(e SDK, r *Range, args string, decode bool) error {
+	t, ok := transforms[args]
+	if !ok {
+		return fmt.Errorf("unknown encoding: %q, must be one of %s", args, transformNames())
+	}
+
+	if e.NumRows() == 0 {
+		return fmt.Errorf("no text to transform")
+	}
+
+	rng := r.orLine(e)
+	var lines []string
+	for y := rng.Start; y <= rng.End; y++ {
+		lines = append(lines, string(e.Row(y)))
+	}
+
+	f := t.encode
+	if decode {
+		f = t.decode
+	}
+
+	text, err := f(strings.Join(lines, "\n"))
+	if err != nil {
+		return err
+	}
+
+	replaceRows(e, rng, strings.Split(text, "\n"))
+	return nil
+}
+
+// replaceRows replaces the rows in r with lines, and moves the cursor to
+// the start of them.
+func replaceRows(e SDK, r Range, lines []string) {
+	for y := r.End; y >= r.Start; y-- {
+		e.DeleteRow(y)
+	}
+	for i, line := range lines {
+		e.InsertRow(r.Start+i, []rune(line))
+	}
+
+	e.SetY(r.Start)
+	e.SetX(0)
+}
+
+// encodeCommand encodes the lines: "encode base64|json|url".
+func encodeCommand(e SDK, r *Range, args string) error {
+	return transformRows(e, r, args, false)
+}
+
+// decodeCommand decodes the lines: "decode base64|json|url".
+func decodeCommand(e SDK, r *Range, args string) error {
+	return transformRows(e, r, args, true)
+}
@@ -0,0 +1,256 @@
+package editor
+
+import "github.com/pkg/errors"
+
+// Changes to the text are made in transactions. The changes of a transaction,
+// or of all the keys typed in one stay in insert mode, are undone and redone
+// as a whole, and the rows they change are only rehighlighted once at the end.
+// Every key press is a transaction, and the methods changing the text start
+// one of their own when called outside of one.
+
+// Maximum number of steps kept in the undo history
+const undoLevels = 1000
+
+type rowChangeKind int8
+
+const (
+	rowSet rowChangeKind = iota
+	rowInserted
+	rowDeleted
+)
+
+// rowChange is a change to one row, with copies of its text before and
+// after. before is nil for an inserted row and after for a deleted one.
+type rowChange struct {
+	kind          rowChangeKind
+	y             int
+	before, after []rune
+}
+
+// undoStep is the changes undone or redone together, with where the cursor
+// was before and after them.
+type undoStep struct {
+	changes       []rowChange
+	before, after position
+}
+
+// undoHistory holds the steps that can be undone, oldest first, and the ones
+// undone that can be redone, most recently undone last.
+type undoHistory struct {
+	undo, redo []undoStep
+	// the step of the running transaction, or of the stay in insert mode
+	current *undoStep
+	// number of transactions started and not ended yet
+	depth int
+	// rows changed by the running transaction
+	dirty map[*Row]bool
+	// set while undoing or redoing, for the changes not to be recorded
+	replaying bool
+}
+
+func copyRunes(chars []rune) []rune {
+	return append([]rune{}, chars...)
+}
+
+// Transaction runs fn, so that the changes it makes are undone as a whole
+// and the rows it changes are only rehighlighted once.
+func (e *Editor) Transaction(fn func() error) error {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	return fn()
+}
+
+func (e *Editor) beginTransaction() {
+	h := &e.history
+	if h.current == nil {
+		h.current = &undoStep{before: position{e.cx, e.cy}}
+	}
+	h.depth++
+}
+
+// endTransaction ends the innermost transaction. Ending the outermost one
+// updates the rows it changed, and adds its changes to the undo history
+// unless typing in insert mode.
+func (e *Editor) endTransaction() {
+	h := &e.history
+	if h.depth--; h.depth > 0 {
+		return
+	}
+
+	if e.IsReadOnly() && h.current != nil && len(h.current.changes) > 0 {
+		e.revertReadOnly()
+	}
+
+	if len(h.dirty) > 0 {
+		for y, row := range e.rows {
+			if h.dirty[row] {
+				e.updateRow(y)
+			}
+		}
+		h.dirty = nil
+	}
+
+	if e.Mode != InsertMode && e.Mode != ReplaceMode {
+		e.commitUndoStep()
+	}
+}
+
+// commitUndoStep adds the current step to the undo history if it changed
+// anything, which makes the steps undone so far impossible to redo.
+func (e *Editor) commitUndoStep() {
+	h := &e.history
+	step := h.current
+	h.current = nil
+	if step == nil || len(step.changes) == 0 {
+		return
+	}
+
+	step.after = position{e.cx, e.cy}
+	h.undo = append(h.undo, *step)
+	if len(h.undo) > undoLevels {
+		h.undo = h.undo[len(h.undo)-undoLevels:]
+	}
+	h.redo = nil
+}
+
+// recordChange adds a change to the current step. Repeated changes to the
+// same row, like typing into it, are merged into one.
+func (e *Editor) recordChange(c rowChange) {
+	h := &e.history
+	if h.replaying {
+		return
+	}
+	if h.current == nil {
+		h.current = &undoStep{before: position{e.cx, e.cy}}
+	}
+
+	changes := h.current.changes
+	if n := len(changes); n > 0 && c.kind == rowSet {
+		if last := &changes[n-1]; last.y == c.y && last.kind != rowDeleted {
+			last.after = c.after
+			return
+		}
+	}
+
+	h.current.changes = append(changes, c)
+}
+
+// touchRow marks row y as changed, to be updated at the end of the
+// transaction.
+func (e *Editor) touchRow(y int) {
+	h := &e.history
+	if h.dirty == nil {
+		h.dirty = map[*Row]bool{}
+	}
+	h.dirty[e.rows[y]] = true
+}
+
+// resetHistory forgets the undo history, when another file is opened.
+func (e *Editor) resetHistory() {
+	h := &e.history
+	h.undo, h.redo, h.current = nil, nil, nil
+}
+
+// Undo undoes the last step in the undo history.
+func (e *Editor) Undo() error {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	// Changes made so far in the transaction are undone first
+	e.commitUndoStep()
+
+	h := &e.history
+	if len(h.undo) == 0 {
+		return errors.New("already at the oldest change")
+	}
+
+	step := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+
+	e.undoChanges(step.changes)
+
+	h.redo = append(h.redo, step)
+	e.cx, e.cy = step.before.x, step.before.y
+	e.updateModified()
+	return nil
+}
+
+// undoChanges puts the rows changed by changes back the way they were,
+// without recording it.
+func (e *Editor) undoChanges(changes []rowChange) {
+	h := &e.history
+	h.replaying = true
+	for i := len(changes) - 1; i >= 0; i-- {
+		switch c := changes[i]; c.kind {
+		case rowSet:
+			e.SetRow(c.y, copyRunes(c.before))
+		case rowInserted:
+			e.DeleteRow(c.y)
+		case rowDeleted:
+			e.InsertRow(c.y, copyRunes(c.before))
+		}
+	}
+	h.replaying = false
+}
+
+// Redo redoes the step undone last.
+func (e *Editor) Redo() error {
+	e.beginTransaction()
+	defer e.endTransaction()
+
+	e.commitUndoStep()
+
+	h := &e.history
+	if len(h.redo) == 0 {
+		return errors.New("already at the newest change")
+	}
+
+	step := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+
+	h.replaying = true
+	for _, c := range step.changes {
+		switch c.kind {
+		case rowSet:
+			e.SetRow(c.y, copyRunes(c.after))
+		case rowInserted:
+			e.InsertRow(c.y, copyRunes(c.after))
+		case rowDeleted:
+			e.DeleteRow(c.y)
+		}
+	}
+	h.replaying = false
+
+	h.undo = append(h.undo, step)
+	e.cx, e.cy = step.after.x, step.after.y
+	e.updateModified()
+	return nil
+}
+
+// updateModified marks the file as unmodified if undoing or redoing put the
+// text back the way it was saved.
+func (e *Editor) updateModified() {
+	saved := e.changes.saved
+	if len(saved) != len(e.rows) {
+		return
+	}
+
+	for i, row := range e.rows {
+		if string(row.chars) != saved[i] {
+			return
+		}
+	}
+
+	e.modified = false
+}
+
+// undoCommand undoes the last change: "undo".
+func undoCommand(e SDK, r *Range, args string) error {
+	return e.Undo()
+}
+
+// redoCommand redoes the last undone change: "redo".
+func redoCommand(e SDK, r *Range, args string) error {
+	return e.Redo()
+}
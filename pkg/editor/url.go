@@ -0,0 +1,113 @@
+package editor
+
+import (
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// URLs in comments and in markdown text are underlined, and "gx" opens the
+// one under the cursor in the browser.
+
+// urlPattern matches URLs, along with any punctuation right after them that
+// trimURL takes off.
+var urlPattern = regexp.MustCompile("(?:https?|ftp|file)://[^\\s<>\"'`]+")
+
+// trimURL removes the punctuation that ends a sentence or closes brackets
+// around a URL, rather than being part of it.
+func trimURL(url string) string {
+	for len(url) > 0 {
+		switch last := url[len(url)-1]; {
+		case strings.IndexByte(".,;:!?*", last) != -1:
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+		case last == ']' && strings.Count(url, "[") < strings.Count(url, "]"):
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+
+	return url
+}
+
+// findURLs returns the rune index of the start and end of each URL in text.
+func findURLs(text string) [][2]int {
+	var urls [][2]int
+	for _, m := range urlPattern.FindAllStringIndex(text, -1) {
+		url := trimURL(text[m[0]:m[1]])
+		start := utf8.RuneCountInString(text[:m[0]])
+		urls = append(urls, [2]int{start, start + utf8.RuneCountInString(url)})
+	}
+
+	return urls
+}
+
+// highlightURLs marks the URLs within comments, or anywhere outside code in
+// markdown files.
+func highlightURLs(row *Row, runes []rune, markdown bool) {
+	if !strings.Contains(row.render, "://") {
+		return
+	}
+
+	for _, u := range findURLs(string(runes)) {
+		hl := row.hl[u[0]]
+		if hl != hlComment && hl != hlMlComment && !(markdown && hl == hlNormal) {
+			continue
+		}
+
+		for i := u[0]; i < u[1]; i++ {
+			row.hl[i] = hlURL
+		}
+	}
+}
+
+// urlUnderCursor returns the URL the cursor is on.
+func urlUnderCursor(e SDK) (string, bool) {
+	row, x := cursorRow(e), e.X()
+	for _, u := range findURLs(string(row)) {
+		if x >= u[0] && x < u[1] {
+			return string(row[u[0]:u[1]]), true
+		}
+	}
+
+	return "", false
+}
+
+// openURL opens a URL with the program the desktop uses for it, without
+// waiting for it.
+func openURL(url string) error {
+	name, args := "xdg-open", []string{url}
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "windows":
+		name, args = "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	}
+
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "opening %s", url)
+	}
+
+	go cmd.Wait()
+	return nil
+}
+
+// openURLUnderCursor opens the URL under the cursor in the browser.
+func openURLUnderCursor(e SDK) error {
+	url, ok := urlUnderCursor(e)
+	if !ok {
+		return errors.New("no URL under the cursor")
+	}
+
+	if err := openURL(url); err != nil {
+		return err
+	}
+
+	e.SetMessage("Opening %s", url)
+	return nil
+}
@@ -0,0 +1,164 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Variables are named text values that the config file, ex commands and Lua
+// scripts can set and read, so that features can be turned on and off
+// without an option of their own. "g:name" is global and "b:name" belongs to
+// the file being edited, forgotten when another file is opened. A name
+// without a scope is set globally, and looked up in the file's variables
+// first, so that e.g. "b:format_on_save" overrides "g:format_on_save".
+
+// variables holds the global and the file's variables by name, without
+// their scope.
+type variables struct {
+	global, buffer map[string]string
+}
+
+// parseVarName splits a variable name into its scope, 'g' or 'b', or 0 when
+// it has none, and the rest of the name.
+func parseVarName(name string) (byte, string, error) {
+	var scope byte
+	if len(name) > 2 && name[1] == ':' {
+		scope, name = name[0], name[2:]
+		if scope != 'g' && scope != 'b' {
+			return 0, "", errors.Errorf("unknown variable scope: %c:", scope)
+		}
+	}
+
+	valid := len(name) > 0
+	for i, c := range name {
+		letter := c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		valid = valid && (letter || i > 0 && c >= '0' && c <= '9')
+	}
+	if !valid {
+		return 0, "", errors.Errorf("invalid variable name: %s", name)
+	}
+
+	return scope, name, nil
+}
+
+// Var returns the value of a variable and whether it is set. A name without
+// a scope is looked up in the file's variables, then in the global ones.
+func (e *Editor) Var(name string) (string, bool) {
+	scope, name, err := parseVarName(name)
+	if err != nil {
+		return "", false
+	}
+
+	if scope != 'g' {
+		if v, ok := e.vars.buffer[name]; ok {
+			return v, true
+		}
+		if scope == 'b' {
+			return "", false
+		}
+	}
+
+	v, ok := e.vars.global[name]
+	return v, ok
+}
+
+// SetVar sets a variable, globally if the name has no scope.
+func (e *Editor) SetVar(name, value string) error {
+	scope, name, err := parseVarName(name)
+	if err != nil {
+		return err
+	}
+
+	vars := &e.vars.global
+	if scope == 'b' {
+		vars = &e.vars.buffer
+	}
+	if *vars == nil {
+		*vars = map[string]string{}
+	}
+
+	(*vars)[name] = value
+	return nil
+}
+
+// UnsetVar removes a variable, the global one if the name has no scope.
+func (e *Editor) UnsetVar(name string) error {
+	scope, name, err := parseVarName(name)
+	if err != nil {
+		return err
+	}
+
+	if scope == 'b' {
+		delete(e.vars.buffer, name)
+	} else {
+		delete(e.vars.global, name)
+	}
+	return nil
+}
+
+// varLines returns a line for each variable, "g:name = value", sorted by
+// name.
+func (e *Editor) varLines() []string {
+	var lines []string
+	for _, scope := range []struct {
+		prefix string
+		vars   map[string]string
+	}{{"g:", e.vars.global}, {"b:", e.vars.buffer}} {
+		for name, v := range scope.vars {
+			lines = append(lines, fmt.Sprintf("%s%s = %s", scope.prefix, name, v))
+		}
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+// letCommand sets a variable, shows one, or lists them all without an
+// argument: "let [name [= value]]". Quotes around the value are removed.
+func letCommand(e SDK, r *Range, args string) error {
+	if len(args) == 0 {
+		if lines := e.varLines(); len(lines) > 0 {
+			e.ShowLines(lines)
+		} else {
+			e.SetMessage("no variables")
+		}
+		return nil
+	}
+
+	name, value, found := strings.Cut(args, "=")
+	name = strings.TrimSpace(name)
+	if !found {
+		v, ok := e.Var(name)
+		if !ok {
+			return errors.Errorf("undefined variable: %s", name)
+		}
+
+		e.SetMessage("%s = %s", name, v)
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return e.SetVar(name, value)
+}
+
+// unletCommand removes variables: "unlet <name>...".
+func unletCommand(e SDK, r *Range, args string) error {
+	names := strings.Fields(args)
+	if len(names) == 0 {
+		return errors.New("usage: unlet <name>...")
+	}
+
+	for _, name := range names {
+		if err := e.UnsetVar(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
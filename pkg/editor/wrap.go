@@ -0,0 +1,194 @@
+package editor
+
+import "io"
+
+// screenLine is the part of a row's render string that is drawn on a single
+// line of the screen when soft wrapping is enabled.
+type screenLine struct {
+	// start and end are rune indexes into Row.render
+	start, end int
+	// col is the visual column in the row at which the line begins
+	col int
+}
+
+// screenLines splits the render string of the row into lines that fit the
+// width of the screen. A row always occupies at least one line.
+func (e *Editor) screenLines(row *Row) []screenLine {
+	var (
+		lines []screenLine
+		cur   screenLine
+		width int
+	)
+
+	render := []rune(row.render)
+	widths := charWidths(render, e.width)
+	for i := range render {
+		w := widths[i]
+		if width+w > e.textCols() && i > cur.start {
+			cur.end = i
+			lines = append(lines, cur)
+			cur = screenLine{start: i, col: cur.col + width}
+			width = 0
+		}
+
+		width += w
+	}
+
+	cur.end = len(render)
+	return append(lines, cur)
+}
+
+// screenLineAt returns the index of the line containing the visual column rx.
+func screenLineAt(lines []screenLine, rx int) int {
+	for i := len(lines) - 1; i > 0; i-- {
+		if rx >= lines[i].col {
+			return i
+		}
+	}
+
+	return 0
+}
+
+func (e *Editor) drawWrappedRows(w io.Writer) {
+	y := 0
+	for filerow := e.rowOffset; filerow < len(e.rows) && y < e.screenRows; filerow++ {
+		row := e.rows[filerow]
+		runes := []rune(row.render)
+		hl := e.rowHighlight(filerow)
+
+		for i, line := range e.screenLines(row) {
+			if y == e.screenRows {
+				break
+			}
+
+			if i == 0 {
+				e.drawLineNumber(w, filerow)
+			} else {
+				e.drawLineNumber(w, -1)
+			}
+
+			e.drawLine(w, string(runes[line.start:line.end]), hl[line.start:line.end])
+
+			w.Write([]byte(ClearLineCode))
+			w.Write([]byte("\r\n"))
+			y++
+		}
+	}
+
+	for ; y < e.screenRows; y++ {
+		w.Write([]byte("~"))
+		w.Write([]byte(ClearLineCode))
+		w.Write([]byte("\r\n"))
+	}
+}
+
+// scrollWrapped adjusts the row offset so the screen line holding the cursor
+// is visible. Horizontal scrolling is never needed when wrapping.
+func (e *Editor) scrollWrapped() {
+	e.colOffset = 0
+
+	// The scrolloff margin is counted in rows rather than screen lines
+	so := e.scrollOff()
+	if e.cy-so < e.rowOffset {
+		e.rowOffset = e.cy - so
+		if e.rowOffset < 0 {
+			e.rowOffset = 0
+		}
+	}
+
+	if e.cy >= len(e.rows) {
+		return
+	}
+
+	// number of screen lines between the top of the screen and the cursor
+	lines := screenLineAt(e.screenLines(e.rows[e.cy]), e.rx)
+	for y := e.rowOffset; y < e.cy; y++ {
+		lines += len(e.screenLines(e.rows[y]))
+	}
+
+	// screen lines needed below the cursor to satisfy the margin
+	for y := e.cy + 1; y <= e.cy+so && y < len(e.rows); y++ {
+		lines++
+	}
+
+	for lines >= e.screenRows && e.rowOffset < e.cy {
+		lines -= len(e.screenLines(e.rows[e.rowOffset]))
+		e.rowOffset++
+	}
+}
+
+// wrappedCursorPosition returns the position of the cursor on the screen,
+// relative to the top left of the text area.
+func (e *Editor) wrappedCursorPosition() (y, x int) {
+	if e.cy >= len(e.rows) {
+		return e.cy - e.rowOffset, 0
+	}
+
+	for i := e.rowOffset; i < e.cy; i++ {
+		y += len(e.screenLines(e.rows[i]))
+	}
+
+	lines := e.screenLines(e.rows[e.cy])
+	i := screenLineAt(lines, e.rx)
+
+	x = e.rx - lines[i].col
+	if x >= e.textCols() {
+		x = e.textCols() - 1
+	}
+
+	return y + i, x
+}
+
+// moveDisplayLine moves the cursor n screen lines down, or up if n is
+// negative, keeping the visual column where possible. When wrapping is off
+// this is the same as moving by rows.
+func (e *Editor) moveDisplayLine(n int) {
+	if !e.cfg.Wrap || len(e.rows) == 0 {
+		e.cy += n
+		return
+	}
+
+	e.WrapCursorY()
+	e.WrapCursorX()
+
+	lines := e.screenLines(e.rows[e.cy])
+	rx := e.rowCxToRx(e.rows[e.cy], e.cx)
+	i := screenLineAt(lines, rx)
+	col := rx - lines[i].col
+
+	for ; n > 0; n-- {
+		if i+1 < len(lines) {
+			i++
+			continue
+		}
+		if e.cy+1 >= len(e.rows) {
+			break
+		}
+
+		e.cy++
+		lines = e.screenLines(e.rows[e.cy])
+		i = 0
+	}
+
+	for ; n < 0; n++ {
+		if i > 0 {
+			i--
+			continue
+		}
+		if e.cy == 0 {
+			break
+		}
+
+		e.cy--
+		lines = e.screenLines(e.rows[e.cy])
+		i = len(lines) - 1
+	}
+
+	// Don't let the cursor spill over onto the following screen line
+	rx = lines[i].col + col
+	if i+1 < len(lines) && rx >= lines[i+1].col {
+		rx = lines[i+1].col - 1
+	}
+
+	e.cx = e.rowRxToCx(e.rows[e.cy], rx)
+}